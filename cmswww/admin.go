@@ -168,7 +168,9 @@ func (c *cmswww) HandleUserDetails(
 	ud := req.(*v1.UserDetails)
 
 	// Fetch the database user.
-	targetUser, err := c.findUser(ud.UserID, ud.Email, ud.Username, user.Admin)
+	canViewUsers := hasPermission(user, permissionViewUsers)
+	targetUser, err := c.findUser(ud.UserID, ud.Email, ud.Username,
+		canViewUsers)
 	if err != nil {
 		return nil, err
 	}
@@ -177,14 +179,25 @@ func (c *cmswww) HandleUserDetails(
 	if targetUser == nil {
 		return &udr, nil
 	}
-	if !user.Admin && targetUser.ID != user.ID {
+	if !canViewUsers && targetUser.ID != user.ID {
 		// Don't return user details for another user unless the requesting
-		// user is an admin.
+		// user is an admin or has a role.
 		return &udr, nil
 	}
 
 	// Convert the database user into a proper response.
 	udr.User = convertDatabaseUserToUser(targetUser)
+
+	// Only return the contract history to users who can view all users.
+	if canViewUsers {
+		contractChanges, err := c.db.GetContractChanges(targetUser.ID)
+		if err != nil {
+			return nil, err
+		}
+		udr.ContractChanges =
+			convertDatabaseContractChangesToContractChanges(contractChanges)
+	}
+
 	return &udr, nil
 }
 
@@ -198,8 +211,7 @@ func (c *cmswww) HandleManageUser(
 	var mur v1.ManageUserReply
 
 	// Fetch the database user.
-	targetUser, err := c.findUser(mu.UserID, mu.Email, mu.Username,
-		adminUser.Admin)
+	targetUser, err := c.findUser(mu.UserID, mu.Email, mu.Username, true)
 	if err != nil {
 		return nil, err
 	}
@@ -211,8 +223,22 @@ func (c *cmswww) HandleManageUser(
 		}
 	}
 
+	// Only admins can manage other admins, reset two-factor authentication
+	// or change roles, so that user managers can't escalate privileges.
+	if !adminUser.Admin && (targetUser.Admin ||
+		mu.Action == v1.UserManageResetTOTP ||
+		mu.Action == v1.UserManageSetRoles) {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusRoleRequired,
+			ErrorContext: []string{"admin"},
+		}
+	}
+
 	// Validate that the reason is supplied for certain actions.
-	if mu.Action == v1.UserManageLock {
+	if mu.Action == v1.UserManageLock || mu.Action == v1.UserManageResetTOTP ||
+		mu.Action == v1.UserManageSetRoles ||
+		mu.Action == v1.UserManageDeactivate ||
+		mu.Action == v1.UserManageReactivate {
 		mu.Reason = strings.TrimSpace(mu.Reason)
 		if len(mu.Reason) == 0 {
 			return nil, v1.UserError{
@@ -239,13 +265,81 @@ func (c *cmswww) HandleManageUser(
 			return nil, err
 		}
 	case v1.UserManageUnlock:
+		targetUser.Locked = false
 		targetUser.FailedLoginAttempts = 0
 		err = c.db.UpdateUser(targetUser)
 		if err != nil {
 			return nil, err
 		}
 	case v1.UserManageLock:
-		targetUser.FailedLoginAttempts = v1.LoginAttemptsToLockUser
+		targetUser.Locked = true
+		err = c.db.UpdateUser(targetUser)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.revokeUserSessions(targetUser, nil)
+		if err != nil {
+			return nil, err
+		}
+	case v1.UserManageSetRoles:
+		if mu.Roles&^v1.AllUserRoles != 0 {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"invalid roles"},
+			}
+		}
+
+		targetUser.Roles = mu.Roles
+		err = c.db.UpdateUser(targetUser)
+		if err != nil {
+			return nil, err
+		}
+	case v1.UserManageResetTOTP:
+		targetUser.TOTPSecret = ""
+		targetUser.TOTPVerified = false
+		targetUser.TOTPRecoveryCodes = nil
+		err = c.db.UpdateUser(targetUser)
+		if err != nil {
+			return nil, err
+		}
+	case v1.UserManageDeactivate:
+		// The end date is the contractor's last day of work; invoices can
+		// still be submitted up to that month.
+		if mu.EndDate < 0 {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"invalid end date"},
+			}
+		}
+
+		now := time.Now()
+		targetUser.EndDate = mu.EndDate
+		if targetUser.EndDate == 0 {
+			targetUser.EndDate = now.Unix()
+		}
+		err = c.db.UpdateUser(targetUser)
+		if err != nil {
+			return nil, err
+		}
+
+		// The identities are deactivated right away if the deadline for
+		// the final invoice has already passed.
+		err = c.deactivateUserIdentities(targetUser, now)
+		if err != nil {
+			return nil, err
+		}
+	case v1.UserManageReactivate:
+		if !targetUser.IsDeactivated() {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"user is not deactivated"},
+			}
+		}
+
+		// Identities which have been deactivated stay deactivated; the
+		// user needs to create a new identity.
+		targetUser.EndDate = 0
 		err = c.db.UpdateUser(targetUser)
 		if err != nil {
 			return nil, err
@@ -256,8 +350,15 @@ func (c *cmswww) HandleManageUser(
 	}
 
 	// Append this action to the admin log file.
-	err = c.logAdminUserAction(adminUser, targetUser,
-		v1.UserManageAction[mu.Action], mu.Reason)
+	action := v1.UserManageAction[mu.Action]
+	switch mu.Action {
+	case v1.UserManageSetRoles:
+		action = fmt.Sprintf("%v: %v", action, formatUserRoles(mu.Roles))
+	case v1.UserManageDeactivate:
+		action = fmt.Sprintf("%v: end date %v", action,
+			time.Unix(targetUser.EndDate, 0).UTC().Format("2006-01-02"))
+	}
+	err = c.logAdminUserAction(adminUser, targetUser, action, mu.Reason)
 	if err != nil {
 		return nil, err
 	}
@@ -265,6 +366,22 @@ func (c *cmswww) HandleManageUser(
 	return &mur, nil
 }
 
+// formatUserRoles returns the human readable list of roles for the audit
+// log.
+func formatUserRoles(roles v1.UserRoleT) string {
+	var names []string
+	for role := v1.UserRoleReviewer; role <= v1.UserRoleDomainLead; role <<= 1 {
+		if roles&role != 0 {
+			names = append(names, v1.UserRole[role])
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, " ")
+}
+
 // resendInvite sets a new verification token and expiry for a new user;
 // the token must be verified before it expires.
 func (c *cmswww) resendInvite(adminUser, targetUser *database.User) (string, error) {
@@ -314,7 +431,19 @@ func (c *cmswww) HandleUsers(
 	u := req.(*v1.Users)
 	var ur v1.UsersReply
 
-	users, numMatches, err := c.db.GetUsers(u.Username, int(u.Page))
+	if _, ok := v1.ContractDomain[u.Domain]; !ok {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid contract domain"},
+		}
+	}
+
+	users, numMatches, err := c.db.GetUsers(database.UsersRequest{
+		Username:           u.Username,
+		Domain:             u.Domain,
+		ContractEndsBefore: u.ContractEndsBefore,
+		Page:               int(u.Page),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,10 @@ const (
 	// Forward is the proxy header
 	Forward = "X-Forwarded-For"
 
+	// Authorization is the header used to authenticate with an API token;
+	// its value should be "Bearer <token>".
+	Authorization = "Authorization"
+
 	// CookieSession is the cookie name that indicates that a user is
 	// logged in.
 	CookieSession = "session"
@@ -48,7 +52,61 @@ const (
 
 	// LoginAttemptsToLockUser is the number of consecutive failed
 	// login attempts permitted before the system locks the user.
+	//
+	// Deprecated: accounts are no longer locked after failed login
+	// attempts; logins are delayed instead and accounts are locked by
+	// admins.
 	LoginAttemptsToLockUser = 5
+
+	// APITokenSize is the size of an API token in bytes
+	APITokenSize = 32
+
+	// PolicyMaxAPITokenNameLength is the max length of an API token name
+	PolicyMaxAPITokenNameLength = 50
+
+	// PolicyMaxAPITokenLifetime is the max amount of time an API token
+	// can be valid for
+	PolicyMaxAPITokenLifetime = 365 * 24 * time.Hour
+
+	// LoginChallengeSize is the size of a login challenge in bytes
+	LoginChallengeSize = 32
+
+	// LoginChallengeExpiryTime is the amount of time before a login
+	// challenge expires
+	LoginChallengeExpiryTime = 5 * time.Minute
+
+	// LoginChallengePrefix is prepended to the login challenge before it
+	// is signed, so that a login signature can't be mistaken for any other
+	// signature made with the user's identity.
+	LoginChallengePrefix = "cmswww login challenge:"
+
+	// PolicyMaxContractDocuments is the max number of document references
+	// in a contract
+	PolicyMaxContractDocuments = 10
+
+	// PolicyMaxContractDocumentLength is the max length of a contract
+	// document reference
+	PolicyMaxContractDocumentLength = 200
+
+	// PolicyMaxCommentLength is the max length of an invoice comment
+	PolicyMaxCommentLength = 8000
+
+	// PolicyMaxAttachments is the max number of files, such as receipts,
+	// which can be attached to an invoice
+	PolicyMaxAttachments = 5
+
+	// PolicyMaxAttachmentSize is the max size (in bytes) of an invoice
+	// attachment
+	PolicyMaxAttachmentSize = 512 * 1024
+
+	// PolicyMaxBatchStatusChanges is the max number of invoice status
+	// changes which can be made in a single request
+	PolicyMaxBatchStatusChanges = 100
+
+	// PolicyInvoiceExpenseType is the type of work which marks a line item
+	// as an expense. The subtype of an expense is its category, and its
+	// hours must be 0.
+	PolicyInvoiceExpenseType = "Expense"
 )
 
 var (
@@ -58,6 +116,25 @@ var (
 		"A-z", "0-9", "&", ".", ",", ":", ";", "-", " ", "@", "+", "#", "/",
 		"(", ")", "!", "?", "\"", "'"}
 
+	// PolicyValidMIMETypes is the list of MIME types accepted for invoice
+	// attachments, as they are detected from the file contents
+	PolicyValidMIMETypes = []string{
+		"text/plain; charset=utf-8",
+		"image/png",
+		"image/jpeg",
+		"application/pdf",
+	}
+
+	// PolicyExpenseCategories is the list of valid categories of expense
+	// line items
+	PolicyExpenseCategories = []string{
+		"Travel",
+		"Hosting",
+		"Software",
+		"Hardware",
+		"Other",
+	}
+
 	// PolicyUsernameSupportedChars is the regular expression of a valid
 	// username
 	PolicyUsernameSupportedChars = []string{
@@ -5,48 +5,83 @@ type InvoiceStatusT int
 type UserManageActionT int
 type InvoiceFieldTypeT int
 type EmailNotificationT int
+type APITokenScopeT uint64
+type UserRoleT uint64
+type ContractDomainT int
+type LeadReviewActionT int
+type LineItemKindT int
+type InvoiceSortT int
 
 const (
 	// Error status codes
-	ErrorStatusInvalid                        ErrorStatusT = 0
-	ErrorStatusInvalidEmailOrPassword         ErrorStatusT = 1
-	ErrorStatusMalformedEmail                 ErrorStatusT = 2
-	ErrorStatusVerificationTokenInvalid       ErrorStatusT = 3
-	ErrorStatusVerificationTokenExpired       ErrorStatusT = 4
-	ErrorStatusVerificationTokenUnexpired     ErrorStatusT = 5
-	ErrorStatusInvoiceNotFound                ErrorStatusT = 6
-	ErrorStatusMalformedPassword              ErrorStatusT = 7
-	ErrorStatusInvalidFileDigest              ErrorStatusT = 8
-	ErrorStatusInvalidBase64                  ErrorStatusT = 9
-	ErrorStatusInvalidMIMEType                ErrorStatusT = 10
-	ErrorStatusUnsupportedMIMEType            ErrorStatusT = 11
-	ErrorStatusInvalidInvoiceStatusTransition ErrorStatusT = 12
-	ErrorStatusInvalidPublicKey               ErrorStatusT = 13
-	ErrorStatusDuplicatePublicKey             ErrorStatusT = 14
-	ErrorStatusNoPublicKey                    ErrorStatusT = 15
-	ErrorStatusInvalidSignature               ErrorStatusT = 16
-	ErrorStatusInvalidInput                   ErrorStatusT = 17
-	ErrorStatusInvalidSigningKey              ErrorStatusT = 18
-	ErrorStatusUserNotFound                   ErrorStatusT = 19
-	ErrorStatusNotLoggedIn                    ErrorStatusT = 20
-	ErrorStatusMalformedUsername              ErrorStatusT = 21
-	ErrorStatusDuplicateUsername              ErrorStatusT = 22
-	ErrorStatusUserLocked                     ErrorStatusT = 23
-	ErrorStatusInvalidUserManageAction        ErrorStatusT = 24
-	ErrorStatusUserAlreadyExists              ErrorStatusT = 25
-	ErrorStatusReasonNotProvided              ErrorStatusT = 26
-	ErrorStatusMalformedInvoiceFile           ErrorStatusT = 27
-	ErrorStatusInvoicePaymentNotFound         ErrorStatusT = 28
-	ErrorStatusDuplicateInvoice               ErrorStatusT = 29
+	ErrorStatusInvalid                         ErrorStatusT = 0
+	ErrorStatusInvalidEmailOrPassword          ErrorStatusT = 1
+	ErrorStatusMalformedEmail                  ErrorStatusT = 2
+	ErrorStatusVerificationTokenInvalid        ErrorStatusT = 3
+	ErrorStatusVerificationTokenExpired        ErrorStatusT = 4
+	ErrorStatusVerificationTokenUnexpired      ErrorStatusT = 5
+	ErrorStatusInvoiceNotFound                 ErrorStatusT = 6
+	ErrorStatusMalformedPassword               ErrorStatusT = 7
+	ErrorStatusInvalidFileDigest               ErrorStatusT = 8
+	ErrorStatusInvalidBase64                   ErrorStatusT = 9
+	ErrorStatusInvalidMIMEType                 ErrorStatusT = 10
+	ErrorStatusUnsupportedMIMEType             ErrorStatusT = 11
+	ErrorStatusInvalidInvoiceStatusTransition  ErrorStatusT = 12
+	ErrorStatusInvalidPublicKey                ErrorStatusT = 13
+	ErrorStatusDuplicatePublicKey              ErrorStatusT = 14
+	ErrorStatusNoPublicKey                     ErrorStatusT = 15
+	ErrorStatusInvalidSignature                ErrorStatusT = 16
+	ErrorStatusInvalidInput                    ErrorStatusT = 17
+	ErrorStatusInvalidSigningKey               ErrorStatusT = 18
+	ErrorStatusUserNotFound                    ErrorStatusT = 19
+	ErrorStatusNotLoggedIn                     ErrorStatusT = 20
+	ErrorStatusMalformedUsername               ErrorStatusT = 21
+	ErrorStatusDuplicateUsername               ErrorStatusT = 22
+	ErrorStatusUserLocked                      ErrorStatusT = 23
+	ErrorStatusInvalidUserManageAction         ErrorStatusT = 24
+	ErrorStatusUserAlreadyExists               ErrorStatusT = 25
+	ErrorStatusReasonNotProvided               ErrorStatusT = 26
+	ErrorStatusMalformedInvoiceFile            ErrorStatusT = 27
+	ErrorStatusInvoicePaymentNotFound          ErrorStatusT = 28
+	ErrorStatusDuplicateInvoice                ErrorStatusT = 29
+	ErrorStatusTOTPCodeRequired                ErrorStatusT = 30
+	ErrorStatusTOTPCodeInvalid                 ErrorStatusT = 31
+	ErrorStatusTOTPNotEnabled                  ErrorStatusT = 32
+	ErrorStatusTOTPAlreadyEnabled              ErrorStatusT = 33
+	ErrorStatusInvalidAPIToken                 ErrorStatusT = 34
+	ErrorStatusAPITokenScopeNotGranted         ErrorStatusT = 35
+	ErrorStatusAPITokenNotFound                ErrorStatusT = 36
+	ErrorStatusRoleRequired                    ErrorStatusT = 37
+	ErrorStatusDuplicateApproval               ErrorStatusT = 38
+	ErrorStatusSessionNotFound                 ErrorStatusT = 39
+	ErrorStatusInvalidLoginChallenge           ErrorStatusT = 40
+	ErrorStatusTooManyRequests                 ErrorStatusT = 41
+	ErrorStatusDuplicateEmail                  ErrorStatusT = 42
+	ErrorStatusUserDeactivated                 ErrorStatusT = 43
+	ErrorStatusInvoiceOutsideContract          ErrorStatusT = 44
+	ErrorStatusHourlyRateCapExceeded           ErrorStatusT = 45
+	ErrorStatusCommentNotFound                 ErrorStatusT = 46
+	ErrorStatusCommentLengthExceededPolicy     ErrorStatusT = 47
+	ErrorStatusMaxAttachmentsExceededPolicy    ErrorStatusT = 48
+	ErrorStatusMaxAttachmentSizeExceededPolicy ErrorStatusT = 49
+	ErrorStatusInvalidProposal                 ErrorStatusT = 50
+	ErrorStatusInvalidSearchCursor             ErrorStatusT = 51
+	ErrorStatusMaxStatusChangesExceededPolicy  ErrorStatusT = 52
+	ErrorStatusInvoiceOnHold                   ErrorStatusT = 53
 
 	// Invoice status codes
-	InvoiceStatusInvalid           InvoiceStatusT = 0 // Invalid status
-	InvoiceStatusNotFound          InvoiceStatusT = 1 // Invoice not found
-	InvoiceStatusNotReviewed       InvoiceStatusT = 2 // Invoice has not been reviewed
-	InvoiceStatusUnreviewedChanges InvoiceStatusT = 3 // Invoice has unreviewed changes
-	InvoiceStatusRejected          InvoiceStatusT = 4 // Invoice needs to be revised
-	InvoiceStatusApproved          InvoiceStatusT = 5 // Invoice has been approved
-	InvoiceStatusPaid              InvoiceStatusT = 6 // Invoice has been paid
+	InvoiceStatusInvalid           InvoiceStatusT = 0  // Invalid status
+	InvoiceStatusNotFound          InvoiceStatusT = 1  // Invoice not found
+	InvoiceStatusNotReviewed       InvoiceStatusT = 2  // Invoice has not been reviewed
+	InvoiceStatusUnreviewedChanges InvoiceStatusT = 3  // Invoice has unreviewed changes
+	InvoiceStatusRejected          InvoiceStatusT = 4  // Invoice needs to be revised
+	InvoiceStatusApproved          InvoiceStatusT = 5  // Invoice has been approved
+	InvoiceStatusPaid              InvoiceStatusT = 6  // Invoice has been paid
+	InvoiceStatusAwaitingApproval  InvoiceStatusT = 7  // Invoice needs approval from additional admins
+	InvoiceStatusWithdrawn         InvoiceStatusT = 8  // Invoice has been withdrawn by the contractor
+	InvoiceStatusOnHold            InvoiceStatusT = 9  // Approved invoice is held back from payment
+	InvoiceStatusDisputed          InvoiceStatusT = 10 // Payment of invoice is disputed
+	InvoiceStatusReadyForPayment   InvoiceStatusT = 11 // Approved invoice has been cleared for payment
 
 	// User manage actions
 	UserManageInvalid                          UserManageActionT = 0 // Invalid action type
@@ -54,6 +89,10 @@ const (
 	UserManageExpireUpdateIdentityVerification UserManageActionT = 2
 	UserManageUnlock                           UserManageActionT = 3
 	UserManageLock                             UserManageActionT = 4
+	UserManageResetTOTP                        UserManageActionT = 5
+	UserManageSetRoles                         UserManageActionT = 6
+	UserManageDeactivate                       UserManageActionT = 7
+	UserManageReactivate                       UserManageActionT = 8
 
 	InvoiceFieldTypeInvalid InvoiceFieldTypeT = 0
 	InvoiceFieldTypeString  InvoiceFieldTypeT = 1
@@ -63,40 +102,106 @@ const (
 	NotificationEmailMyInvoiceApproved EmailNotificationT = 1 << 0
 	NotificationEmailMyInvoiceRejected EmailNotificationT = 1 << 1
 	NotificationEmailMyInvoicePaid     EmailNotificationT = 1 << 2
+	NotificationEmailInvoiceComment    EmailNotificationT = 1 << 3 // New comment on my invoice or reply to my comment
+	NotificationEmailMyInvoiceHeld     EmailNotificationT = 1 << 4 // My invoice has been put on hold or disputed
+
+	// API token scopes
+	APITokenScopeRead          APITokenScopeT = 1 << 0
+	APITokenScopeSubmitInvoice APITokenScopeT = 1 << 1
+	APITokenScopeAdminReview   APITokenScopeT = 1 << 2
+	APITokenScopeAdminPay      APITokenScopeT = 1 << 3
+	APITokenScopeAdminUsers    APITokenScopeT = 1 << 4
+
+	// User roles
+	UserRoleReviewer    UserRoleT = 1 << 0 // Can approve and reject invoices
+	UserRoleTreasurer   UserRoleT = 1 << 1 // Can pay invoices and update payments
+	UserRoleAuditor     UserRoleT = 1 << 2 // Can view all invoices
+	UserRoleUserManager UserRoleT = 1 << 3 // Can invite, lock and unlock users
+	UserRoleDomainLead  UserRoleT = 1 << 4 // Can review the invoices of contractors in their contract domain
+
+	// Contract domains
+	ContractDomainInvalid       ContractDomainT = 0 // No domain set
+	ContractDomainDevelopment   ContractDomainT = 1
+	ContractDomainDesign        ContractDomainT = 2
+	ContractDomainMarketing     ContractDomainT = 3
+	ContractDomainDocumentation ContractDomainT = 4
+	ContractDomainResearch      ContractDomainT = 5
+	ContractDomainCommunity     ContractDomainT = 6
+	ContractDomainOperations    ContractDomainT = 7
+
+	// Domain lead review actions
+	LeadReviewInvalid        LeadReviewActionT = 0 // Invalid action
+	LeadReviewEndorse        LeadReviewActionT = 1 // Invoice is ready for admin review
+	LeadReviewRequestChanges LeadReviewActionT = 2 // Invoice needs to be revised
+
+	// Invoice line item kinds
+	LineItemKindInvalid LineItemKindT = 0 // Invalid kind
+	LineItemKindLabor   LineItemKindT = 1 // Hours of work
+	LineItemKindExpense LineItemKindT = 2 // Expense, billed as an amount without hours
+
+	// Invoice search sort orders
+	InvoiceSortNewest      InvoiceSortT = 0 // Most recently submitted first
+	InvoiceSortOldest      InvoiceSortT = 1 // Least recently submitted first
+	InvoiceSortHighestCost InvoiceSortT = 2 // Highest total cost first
+	InvoiceSortLowestCost  InvoiceSortT = 3 // Lowest total cost first
 )
 
 var (
 	// ErrorStatus converts error status codes to human readable text.
 	ErrorStatus = map[ErrorStatusT]string{
-		ErrorStatusInvalid:                        "invalid status",
-		ErrorStatusInvalidEmailOrPassword:         "invalid email or password",
-		ErrorStatusMalformedEmail:                 "malformed email",
-		ErrorStatusVerificationTokenInvalid:       "invalid verification token",
-		ErrorStatusVerificationTokenExpired:       "expired verification token",
-		ErrorStatusVerificationTokenUnexpired:     "verification token not yet expired",
-		ErrorStatusInvoiceNotFound:                "invoice not found",
-		ErrorStatusMalformedPassword:              "malformed password",
-		ErrorStatusInvalidFileDigest:              "invalid file digest",
-		ErrorStatusInvalidBase64:                  "invalid base64 file content",
-		ErrorStatusInvalidMIMEType:                "invalid MIME type detected for file",
-		ErrorStatusUnsupportedMIMEType:            "unsupported MIME type for file",
-		ErrorStatusInvalidInvoiceStatusTransition: "invalid invoice status",
-		ErrorStatusInvalidPublicKey:               "invalid public key",
-		ErrorStatusNoPublicKey:                    "no active public key",
-		ErrorStatusInvalidSignature:               "invalid signature",
-		ErrorStatusInvalidInput:                   "invalid input",
-		ErrorStatusInvalidSigningKey:              "invalid signing key",
-		ErrorStatusUserNotFound:                   "user not found",
-		ErrorStatusNotLoggedIn:                    "user not logged in",
-		ErrorStatusMalformedUsername:              "malformed username",
-		ErrorStatusDuplicateUsername:              "duplicate username",
-		ErrorStatusUserLocked:                     "user locked due to too many login attempts",
-		ErrorStatusInvalidUserManageAction:        "invalid user manage action",
-		ErrorStatusUserAlreadyExists:              "user already exists",
-		ErrorStatusReasonNotProvided:              "reason for action not provided",
-		ErrorStatusMalformedInvoiceFile:           "malformed invoice file",
-		ErrorStatusInvoicePaymentNotFound:         "invoice payment not found",
-		ErrorStatusDuplicateInvoice:               "duplicate invoice for this month and year",
+		ErrorStatusInvalid:                         "invalid status",
+		ErrorStatusInvalidEmailOrPassword:          "invalid email or password",
+		ErrorStatusMalformedEmail:                  "malformed email",
+		ErrorStatusVerificationTokenInvalid:        "invalid verification token",
+		ErrorStatusVerificationTokenExpired:        "expired verification token",
+		ErrorStatusVerificationTokenUnexpired:      "verification token not yet expired",
+		ErrorStatusInvoiceNotFound:                 "invoice not found",
+		ErrorStatusMalformedPassword:               "malformed password",
+		ErrorStatusInvalidFileDigest:               "invalid file digest",
+		ErrorStatusInvalidBase64:                   "invalid base64 file content",
+		ErrorStatusInvalidMIMEType:                 "invalid MIME type detected for file",
+		ErrorStatusUnsupportedMIMEType:             "unsupported MIME type for file",
+		ErrorStatusInvalidInvoiceStatusTransition:  "invalid invoice status",
+		ErrorStatusInvalidPublicKey:                "invalid public key",
+		ErrorStatusNoPublicKey:                     "no active public key",
+		ErrorStatusInvalidSignature:                "invalid signature",
+		ErrorStatusInvalidInput:                    "invalid input",
+		ErrorStatusInvalidSigningKey:               "invalid signing key",
+		ErrorStatusUserNotFound:                    "user not found",
+		ErrorStatusNotLoggedIn:                     "user not logged in",
+		ErrorStatusMalformedUsername:               "malformed username",
+		ErrorStatusDuplicateUsername:               "duplicate username",
+		ErrorStatusUserLocked:                      "user locked by an admin",
+		ErrorStatusInvalidUserManageAction:         "invalid user manage action",
+		ErrorStatusUserAlreadyExists:               "user already exists",
+		ErrorStatusReasonNotProvided:               "reason for action not provided",
+		ErrorStatusMalformedInvoiceFile:            "malformed invoice file",
+		ErrorStatusInvoicePaymentNotFound:          "invoice payment not found",
+		ErrorStatusDuplicateInvoice:                "duplicate invoice for this month and year",
+		ErrorStatusTOTPCodeRequired:                "two-factor authentication code required",
+		ErrorStatusTOTPCodeInvalid:                 "invalid two-factor authentication code",
+		ErrorStatusTOTPNotEnabled:                  "two-factor authentication is not enabled",
+		ErrorStatusTOTPAlreadyEnabled:              "two-factor authentication is already enabled",
+		ErrorStatusInvalidAPIToken:                 "invalid or expired API token",
+		ErrorStatusAPITokenScopeNotGranted:         "API token does not grant access to this route",
+		ErrorStatusAPITokenNotFound:                "API token not found",
+		ErrorStatusRoleRequired:                    "user does not have the role required for this action",
+		ErrorStatusDuplicateApproval:               "invoice status change already approved by this user",
+		ErrorStatusSessionNotFound:                 "session not found",
+		ErrorStatusInvalidLoginChallenge:           "invalid or expired login challenge",
+		ErrorStatusTooManyRequests:                 "too many requests, try again later",
+		ErrorStatusDuplicateEmail:                  "email address already in use",
+		ErrorStatusUserDeactivated:                 "user has been deactivated",
+		ErrorStatusInvoiceOutsideContract:          "invoice month is outside the contract period",
+		ErrorStatusHourlyRateCapExceeded:           "line item exceeds the contract's hourly rate cap",
+		ErrorStatusCommentNotFound:                 "comment not found",
+		ErrorStatusCommentLengthExceededPolicy:     "comment is too long",
+		ErrorStatusMaxAttachmentsExceededPolicy:    "too many attachments",
+		ErrorStatusMaxAttachmentSizeExceededPolicy: "attachment is too large",
+		ErrorStatusInvalidProposal:                 "invalid proposal",
+		ErrorStatusInvalidSearchCursor:             "invalid search cursor",
+		ErrorStatusMaxStatusChangesExceededPolicy:  "too many status changes",
+		ErrorStatusInvoiceOnHold:                   "invoice is on hold",
 	}
 
 	// InvoiceStatus converts propsal status codes to human readable text
@@ -108,6 +213,11 @@ var (
 		InvoiceStatusRejected:          "rejected",
 		InvoiceStatusApproved:          "approved",
 		InvoiceStatusPaid:              "paid",
+		InvoiceStatusAwaitingApproval:  "awaiting additional approval",
+		InvoiceStatusWithdrawn:         "withdrawn",
+		InvoiceStatusOnHold:            "on hold",
+		InvoiceStatusDisputed:          "disputed",
+		InvoiceStatusReadyForPayment:   "ready for payment",
 	}
 
 	// UserManageAction converts user manage actions to human readable text
@@ -117,5 +227,71 @@ var (
 		UserManageExpireUpdateIdentityVerification: "expire update identity verification token",
 		UserManageUnlock:                           "unlock user",
 		UserManageLock:                             "lock user",
+		UserManageResetTOTP:                        "reset two-factor authentication",
+		UserManageSetRoles:                         "set roles",
+		UserManageDeactivate:                       "deactivate user",
+		UserManageReactivate:                       "reactivate user",
+	}
+
+	// UserRole converts user roles to human readable text
+	UserRole = map[UserRoleT]string{
+		UserRoleReviewer:    "reviewer",
+		UserRoleTreasurer:   "treasurer",
+		UserRoleAuditor:     "auditor",
+		UserRoleUserManager: "user manager",
+		UserRoleDomainLead:  "domain lead",
+	}
+
+	// ContractDomain converts contract domains to human readable text
+	ContractDomain = map[ContractDomainT]string{
+		ContractDomainInvalid:       "none",
+		ContractDomainDevelopment:   "development",
+		ContractDomainDesign:        "design",
+		ContractDomainMarketing:     "marketing",
+		ContractDomainDocumentation: "documentation",
+		ContractDomainResearch:      "research",
+		ContractDomainCommunity:     "community",
+		ContractDomainOperations:    "operations",
 	}
+
+	// LeadReviewAction converts domain lead review actions to human readable
+	// text
+	LeadReviewAction = map[LeadReviewActionT]string{
+		LeadReviewInvalid:        "invalid action",
+		LeadReviewEndorse:        "endorse",
+		LeadReviewRequestChanges: "request changes",
+	}
+
+	// LineItemKind converts line item kinds to human readable text.
+	LineItemKind = map[LineItemKindT]string{
+		LineItemKindInvalid: "invalid",
+		LineItemKindLabor:   "labor",
+		LineItemKindExpense: "expense",
+	}
+
+	// InvoiceSort converts invoice search sort orders to human readable text.
+	InvoiceSort = map[InvoiceSortT]string{
+		InvoiceSortNewest:      "newest",
+		InvoiceSortOldest:      "oldest",
+		InvoiceSortHighestCost: "highest cost",
+		InvoiceSortLowestCost:  "lowest cost",
+	}
+
+	// AllUserRoles is the sum of all valid user roles.
+	AllUserRoles = UserRoleReviewer | UserRoleTreasurer | UserRoleAuditor |
+		UserRoleUserManager | UserRoleDomainLead
+
+	// APITokenScope converts API token scopes to human readable text
+	APITokenScope = map[APITokenScopeT]string{
+		APITokenScopeRead:          "read",
+		APITokenScopeSubmitInvoice: "submit invoices",
+		APITokenScopeAdminReview:   "admin review",
+		APITokenScopeAdminPay:      "admin pay",
+		APITokenScopeAdminUsers:    "admin users",
+	}
+
+	// APITokenAdminScopes is the set of scopes which can only be granted
+	// to tokens owned by admins.
+	APITokenAdminScopes = APITokenScopeAdminReview | APITokenScopeAdminPay |
+		APITokenScopeAdminUsers
 )
@@ -10,25 +10,53 @@ const (
 	RouteRegister                  = "/user/new"
 	RouteNewIdentity               = "/user/identity"
 	RouteVerifyNewIdentity         = "/user/identity/verify"
+	RouteSetTOTP                   = "/user/totp"
+	RouteVerifyTOTP                = "/user/totp/verify"
+	RouteNewAPIToken               = "/user/tokens/new"
+	RouteAPITokens                 = "/user/tokens"
+	RouteRevokeAPIToken            = "/user/tokens/revoke"
+	RouteUserSessions              = "/user/sessions"
+	RouteRevokeSessions            = "/user/sessions/revoke"
 	RouteUserInvoices              = "/user/invoices"
+	RouteUserEarnings              = "/user/earnings"
 	RouteUserDetails               = "/user"
 	RouteChangePassword            = "/user/password/change"
 	RouteResetPassword             = "/user/password/reset"
 	RouteManageUser                = "/user/manage"
 	RouteEditUser                  = "/user/edit"
 	RouteEditUserExtendedPublicKey = "/user/edit/xpublickey"
+	RouteChangeEmail               = "/user/edit/email"
+	RouteEditUserContract          = "/user/contract"
 	RouteUsers                     = "/users"
 	RouteLogin                     = "/login"
+	RouteLoginChallenge            = "/login/challenge"
 	RouteLogout                    = "/logout"
 	RouteInvoices                  = "/invoices"
 	RouteReviewInvoices            = "/invoices/review"
 	RoutePayInvoices               = "/invoices/pay"
+	RouteMissingInvoices           = "/invoices/missing"
+	RouteSpendingReport            = "/invoices/report"
+	RouteSearchInvoices            = "/invoices/search"
 	RouteSubmitInvoice             = "/invoice/submit"
 	RouteEditInvoice               = "/invoice/edit"
+	RouteWithdrawInvoice           = "/invoice/withdraw"
 	RouteInvoiceDetails            = "/invoice"
+	RouteInvoicePDF                = "/invoice/pdf"
 	RouteSetInvoiceStatus          = "/invoice/status"
+	RouteSetInvoiceStatuses        = "/invoices/status"
 	RoutePayInvoice                = "/invoice/pay"
 	RouteUpdateInvoicePayment      = "/invoice/payments/update"
+	RouteLeadReviewInvoice         = "/invoice/leadreview"
+	RouteInvoiceComments           = "/invoice/comments"
+	RouteNewInvoiceComment         = "/invoice/comments/new"
+	RouteInvoiceDraft              = "/invoice/draft"
+	RouteNewDraftLineItem          = "/invoice/draft/lineitems/new"
+	RouteEditDraftLineItem         = "/invoice/draft/lineitems/edit"
+	RouteDeleteDraftLineItem       = "/invoice/draft/lineitems/delete"
+	RouteTeamInvoices              = "/team/invoices"
+	RouteProposals                 = "/proposals"
+	RouteSetProposalBudget         = "/proposals/budget"
+	RouteProposalReport            = "/proposals/report"
 	RoutePolicy                    = "/policy"
 	RouteRate                      = "/rate"
 )
@@ -42,14 +70,18 @@ var (
 // directory structure must be flattened.  The server side SHALL verify MIME
 // and Digest.
 type File struct {
-	Digest  string `json:"digest"`  // Digest of unencoded payload
-	Payload string `json:"payload"` // File content, base64 encoded
+	Name    string `json:"name,omitempty"` // Filename, only set for attachments
+	MIME    string `json:"mime,omitempty"` // MIME type, only set for attachments
+	Digest  string `json:"digest"`         // Digest of unencoded payload
+	Payload string `json:"payload"`        // File content, base64 encoded
 }
 
 // CensorshipRecord contains the proof that an invoice was accepted for review.
 // The proof is verifiable on the client side.
 //
-// The Merkle field contains the digest of the invoice file.
+// The Merkle field contains the merkle root of the digests of the invoice
+// file and its attachments, which is the digest of the invoice file if it
+// has no attachments.
 // The Token field contains a random censorship token that is signed by the
 // server private key.  The token can be used on the client to verify the
 // authenticity of the CensorshipRecord.
@@ -71,11 +103,40 @@ type InvoiceRecord struct {
 	PublicKey          string         `json:"publickey"`                    // User's public key, used to verify signature.
 	Signature          string         `json:"signature"`                    // Signature of file digest
 	File               *File          `json:"file"`                         // Actual invoice file
+	Attachments        []File         `json:"attachments,omitempty"`        // Receipts and other supporting files
 	Version            string         `json:"version"`                      // Record version
+	Late               bool           `json:"late"`                         // Whether the invoice was submitted after the deadline
+
+	// Only populated when the invoice is awaiting additional approval.
+	ApprovalFor       InvoiceStatusT    `json:"approvalfor,omitempty"`       // Status that is being approved
+	Approvals         []InvoiceApproval `json:"approvals,omitempty"`         // Approvals collected so far
+	ApprovalsRequired uint              `json:"approvalsrequired,omitempty"` // Number of distinct admins that must approve
+
+	LeadReviews []InvoiceLeadReview `json:"leadreviews,omitempty"` // Reviews by domain leads, oldest first
 
 	CensorshipRecord CensorshipRecord `json:"censorshiprecord"`
 }
 
+// InvoiceApproval is an admin's signed approval of an invoice status change.
+type InvoiceApproval struct {
+	PublicKey string `json:"publickey"` // Public key of admin
+	Signature string `json:"signature"` // Signature of Token+string(InvoiceStatus)
+	Timestamp int64  `json:"timestamp"` // Time of the approval
+}
+
+// InvoiceLeadReview is a domain lead's signed first-level review of an
+// invoice from a contractor in their domain.
+type InvoiceLeadReview struct {
+	UserID    string            `json:"userid"`           // ID of the domain lead
+	Username  string            `json:"username"`         // Username of the domain lead
+	PublicKey string            `json:"publickey"`        // Public key of the domain lead
+	Signature string            `json:"signature"`        // Signature of Token+string(LeadReviewAction)
+	Action    LeadReviewActionT `json:"action"`           // Review action
+	Reason    string            `json:"reason,omitempty"` // Reason for the action
+	Version   string            `json:"version"`          // Version of the invoice that was reviewed
+	Timestamp int64             `json:"timestamp"`        // Time of the review
+}
+
 // UserError represents an error that is caused by something that the user
 // did (malformed input, bad timing, etc).
 type UserError struct {
@@ -126,11 +187,12 @@ type Version struct{}
 // is running and additionally the route to the API and the public signing key of
 // the server.
 type VersionReply struct {
-	Version   uint        `json:"version"`        // politeia WWW API version
-	Route     string      `json:"route"`          // prefix to API calls
-	PublicKey string      `json:"publickey"`      // Server public key
-	TestNet   bool        `json:"testnet"`        // Network indicator
-	User      *LoginReply `json:"user,omitempty"` // Currently logged in user
+	Version          uint        `json:"version"`          // politeia WWW API version
+	Route            string      `json:"route"`            // prefix to API calls
+	PublicKey        string      `json:"publickey"`        // Server public key
+	SigningPublicKey string      `json:"signingpublickey"` // Key that cmswww signs documents with
+	TestNet          bool        `json:"testnet"`          // Network indicator
+	User             *LoginReply `json:"user,omitempty"`   // Currently logged in user
 }
 
 // InviteNewUser is used to request that a new user invitation be sent via email.
@@ -185,6 +247,24 @@ type VerifyNewIdentityReply struct{}
 type Login struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	TOTPCode string `json:"totpcode"` // Two-factor authentication code or recovery code
+
+	// Instead of a password, the user can provide a login challenge and its
+	// signature made with the user's active identity.
+	Challenge string `json:"challenge,omitempty"` // Challenge from LoginChallengeReply
+	Signature string `json:"signature,omitempty"` // Signature of LoginChallengePrefix+Challenge
+}
+
+// LoginChallenge is used to request a challenge which the user can sign with
+// their active identity to log in without a password.
+type LoginChallenge struct {
+	Email string `json:"email"`
+}
+
+// LoginChallengeReply is used to reply to the LoginChallenge command.
+type LoginChallengeReply struct {
+	Challenge string `json:"challenge"` // Hex encoded random challenge
+	Expiry    int64  `json:"expiry"`    // Unix timestamp when the challenge expires
 }
 
 // LoginReply is used to reply to the Login command.
@@ -195,6 +275,11 @@ type LoginReply struct {
 	Username  string `json:"username"`  // Username
 	PublicKey string `json:"publickey"` // Active public key
 	LastLogin int64  `json:"lastlogin"` // Unix timestamp of last login date
+
+	Roles UserRoleT `json:"roles"` // Sum of the user's roles
+
+	TOTPEnabled  bool `json:"totpenabled"`  // Set if two-factor authentication is enabled
+	TOTPRequired bool `json:"totprequired"` // Set if two-factor authentication must be enabled before using admin or role routes
 }
 
 // Logout attempts to log the user out.
@@ -205,11 +290,12 @@ type LogoutReply struct{}
 
 // SubmitInvoice attempts to submit a new invoice.
 type SubmitInvoice struct {
-	Month     uint16 `json:"month"`
-	Year      uint16 `json:"year"`
-	File      File   `json:"file"`      // Invoice file
-	PublicKey string `json:"publickey"` // Key used to verify signature
-	Signature string `json:"signature"` // Signature of file hash
+	Month       uint16 `json:"month"`
+	Year        uint16 `json:"year"`
+	File        File   `json:"file"`                  // Invoice file
+	Attachments []File `json:"attachments,omitempty"` // Receipts and other supporting files
+	PublicKey   string `json:"publickey"`             // Key used to verify signature
+	Signature   string `json:"signature"`             // Signature of the merkle root of the file digests
 }
 
 // SubmitInvoiceReply is used to reply to the SubmitInvoice command.
@@ -219,10 +305,11 @@ type SubmitInvoiceReply struct {
 
 // EditInvoice attempts to submit an edit to an existing invoice.
 type EditInvoice struct {
-	Token     string `json:"token"`     // Invoice token
-	File      File   `json:"file"`      // Invoice file
-	PublicKey string `json:"publickey"` // Key used to verify signature
-	Signature string `json:"signature"` // Signature of file hash
+	Token       string `json:"token"`                 // Invoice token
+	File        File   `json:"file"`                  // Invoice file
+	Attachments []File `json:"attachments,omitempty"` // Receipts and other supporting files, replacing the previous ones
+	PublicKey   string `json:"publickey"`             // Key used to verify signature
+	Signature   string `json:"signature"`             // Signature of the merkle root of the file digests
 
 }
 
@@ -241,6 +328,21 @@ type InvoiceDetailsReply struct {
 	Invoice InvoiceRecord `json:"invoice"`
 }
 
+// InvoicePDF is used to retrieve an invoice rendered as a PDF document,
+// along with a payment receipt if the invoice has been paid.
+type InvoicePDF struct {
+	Token string `json:"token"`
+}
+
+// InvoicePDFReply is used to reply to the InvoicePDF command. The signature
+// is of the digest of the document, made with the key returned as the
+// signingpublickey of the version reply.
+type InvoicePDFReply struct {
+	File      File   `json:"file"`      // The PDF document
+	PublicKey string `json:"publickey"` // Key the document was signed with
+	Signature string `json:"signature"` // Signature of the document's digest
+}
+
 // SetInvoiceStatus is used to approve or reject an unreviewed invoice.
 type SetInvoiceStatus struct {
 	Token     string         `json:"token"`
@@ -255,6 +357,231 @@ type SetInvoiceStatusReply struct {
 	Invoice InvoiceRecord `json:"invoice"`
 }
 
+// WithdrawInvoice is used by a contractor to withdraw one of their invoices
+// before it's approved, so that a new invoice can be submitted for the same
+// month.
+type WithdrawInvoice struct {
+	Token     string `json:"token"`
+	Reason    string `json:"reason,omitempty"`
+	Signature string `json:"signature"` // Signature of Token+string(InvoiceStatusWithdrawn)
+	PublicKey string `json:"publickey"` // Public key of contractor
+}
+
+// WithdrawInvoiceReply is used to reply to a WithdrawInvoice command.
+type WithdrawInvoiceReply struct {
+	Invoice InvoiceRecord `json:"invoice"`
+}
+
+// SetInvoiceStatuses is used to change the status of many invoices in a
+// single request. Each status change is signed, validated and applied on its
+// own, so a failed change doesn't prevent the others from being applied.
+type SetInvoiceStatuses struct {
+	StatusChanges []SetInvoiceStatus `json:"statuschanges"`
+}
+
+// SetInvoiceStatusesReply is used to reply to a SetInvoiceStatuses command.
+type SetInvoiceStatusesReply struct {
+	Results []SetInvoiceStatusResult `json:"results"` // In the order of the status changes
+}
+
+// SetInvoiceStatusResult is the outcome of one of the status changes of a
+// SetInvoiceStatuses command. The error code and context are those an
+// ErrorReply would have for the status change on its own.
+type SetInvoiceStatusResult struct {
+	Token        string         `json:"token"`
+	Invoice      *InvoiceRecord `json:"invoice,omitempty"`      // Set if the status change was applied
+	ErrorCode    int64          `json:"errorcode,omitempty"`    // Set if the status change failed
+	ErrorContext []string       `json:"errorcontext,omitempty"` // Context of the error, if any
+}
+
+// LeadReviewInvoice is used by a domain lead to endorse or request changes
+// to an unreviewed invoice from a contractor in their domain.
+type LeadReviewInvoice struct {
+	Token     string            `json:"token"`
+	Action    LeadReviewActionT `json:"action"`
+	Reason    string            `json:"reason"`    // Required when requesting changes
+	Signature string            `json:"signature"` // Signature of Token+string(LeadReviewAction)
+	PublicKey string            `json:"publickey"` // Public key of domain lead
+}
+
+// LeadReviewInvoiceReply is used to reply to a LeadReviewInvoice command.
+type LeadReviewInvoiceReply struct {
+	Invoice InvoiceRecord `json:"invoice"`
+}
+
+// InvoiceComment is a comment on an invoice, or on one of its line items.
+// Replies to a comment are comments whose ParentID is the ID of the comment
+// being replied to.
+type InvoiceComment struct {
+	CommentID uint64 `json:"commentid"` // Sequential ID of the comment within the invoice, starting at 1
+	ParentID  uint64 `json:"parentid"`  // ID of the comment being replied to, or 0
+	LineItem  uint   `json:"lineitem"`  // Line item being commented on, starting at 1, or 0 for the whole invoice
+	UserID    string `json:"userid"`    // ID of the author
+	Username  string `json:"username"`  // Username of the author
+	PublicKey string `json:"publickey"` // Public key of the author
+	Signature string `json:"signature"` // Signature of Token+string(ParentID)+string(LineItem)+Comment
+	Comment   string `json:"comment"`
+	Timestamp int64  `json:"timestamp"` // Time the comment was made
+}
+
+// InvoiceComments retrieves all comments on an invoice.
+type InvoiceComments struct {
+	Token string `json:"token"`
+}
+
+// InvoiceCommentsReply is used to reply with the comments on an invoice,
+// oldest first.
+type InvoiceCommentsReply struct {
+	Comments []InvoiceComment `json:"comments"`
+}
+
+// NewInvoiceComment is used to comment on an invoice or to reply to an
+// existing comment. Replies are made on the line item of the parent
+// comment.
+type NewInvoiceComment struct {
+	Token     string `json:"token"`
+	ParentID  uint64 `json:"parentid"` // ID of the comment being replied to, or 0
+	LineItem  uint   `json:"lineitem"` // Line item being commented on, starting at 1, or 0 for the whole invoice
+	Comment   string `json:"comment"`
+	Signature string `json:"signature"` // Signature of Token+string(ParentID)+string(LineItem)+Comment
+	PublicKey string `json:"publickey"` // Public key of the author
+}
+
+// NewInvoiceCommentReply is used to reply to a NewInvoiceComment command.
+type NewInvoiceCommentReply struct {
+	Comment InvoiceComment `json:"comment"`
+}
+
+// InvoiceDraft is a user's invoice for a month which hasn't been submitted
+// yet. Line items are added to it over the month, and it's submitted by
+// signing its file and sending it with the SubmitInvoice command, after
+// which the draft is deleted.
+type InvoiceDraft struct {
+	Month     uint16     `json:"month"`
+	Year      uint16     `json:"year"`
+	LineItems [][]string `json:"lineitems"` // Values of each line item, in the order of the invoice policy fields
+	File      File       `json:"file"`      // Invoice file created from the line items
+	Timestamp int64      `json:"timestamp"` // Last time the draft was changed, or 0 if it has no line items yet
+}
+
+// InvoiceDraftDetails retrieves the user's invoice draft for a month.
+type InvoiceDraftDetails struct {
+	Month uint16 `json:"month"`
+	Year  uint16 `json:"year"`
+}
+
+// InvoiceDraftDetailsReply is used to reply with the user's invoice draft.
+type InvoiceDraftDetailsReply struct {
+	Draft InvoiceDraft `json:"draft"`
+}
+
+// NewDraftLineItem adds a line item to the user's invoice draft for a
+// month, creating the draft if it doesn't exist yet.
+type NewDraftLineItem struct {
+	Month    uint16   `json:"month"`
+	Year     uint16   `json:"year"`
+	LineItem []string `json:"lineitem"` // Values in the order of the invoice policy fields
+}
+
+// NewDraftLineItemReply is used to reply to a NewDraftLineItem command.
+type NewDraftLineItemReply struct {
+	Draft InvoiceDraft `json:"draft"`
+}
+
+// EditDraftLineItem replaces a line item of the user's invoice draft for a
+// month.
+type EditDraftLineItem struct {
+	Month    uint16   `json:"month"`
+	Year     uint16   `json:"year"`
+	Index    uint     `json:"index"`    // Line item to replace, starting at 1
+	LineItem []string `json:"lineitem"` // Values in the order of the invoice policy fields
+}
+
+// EditDraftLineItemReply is used to reply to an EditDraftLineItem command.
+type EditDraftLineItemReply struct {
+	Draft InvoiceDraft `json:"draft"`
+}
+
+// DeleteDraftLineItem removes a line item from the user's invoice draft
+// for a month.
+type DeleteDraftLineItem struct {
+	Month uint16 `json:"month"`
+	Year  uint16 `json:"year"`
+	Index uint   `json:"index"` // Line item to remove, starting at 1
+}
+
+// DeleteDraftLineItemReply is used to reply to a DeleteDraftLineItem
+// command.
+type DeleteDraftLineItemReply struct {
+	Draft InvoiceDraft `json:"draft"`
+}
+
+// TeamInvoices retrieves the invoices of the contractors in the domain
+// lead's contract domain.
+//
+// Note: This call requires the domain lead role.
+type TeamInvoices struct {
+	Status InvoiceStatusT `json:"status"`
+	Month  uint16         `json:"month"`
+	Year   uint16         `json:"year"`
+	Page   uint16         `json:"page"`
+}
+
+// TeamInvoicesReply is used to reply with a list of the team's invoices.
+type TeamInvoicesReply struct {
+	Invoices     []InvoiceRecord `json:"invoices"`
+	TotalMatches uint64          `json:"totalmatches"`
+}
+
+// SpendingReport retrieves the spending of the approved and paid invoices
+// for a given month, and for the year up to and including that month.
+//
+// Note: This call requires admin privileges.
+type SpendingReport struct {
+	Month uint16 `json:"month"`
+	Year  uint16 `json:"year"`
+}
+
+// SpendingReportReply is used to reply to the SpendingReport command.
+type SpendingReportReply struct {
+	Month      SpendingSummary `json:"month"`      // Spending of the month
+	YearToDate SpendingSummary `json:"yeartodate"` // Spending from January through the month
+}
+
+// Proposals retrieves the proposals which invoices can be billed against,
+// along with their budgets.
+type Proposals struct{}
+
+// ProposalsReply is used to reply to the Proposals command.
+type ProposalsReply struct {
+	Proposals []Proposal `json:"proposals"`
+}
+
+// SetProposalBudget sets the budget of a proposal.
+//
+// Note: This call requires admin privileges.
+type SetProposalBudget struct {
+	Token     string `json:"token"`
+	BudgetUSD uint64 `json:"budgetusd"`
+}
+
+// SetProposalBudgetReply is used to reply to the SetProposalBudget command.
+type SetProposalBudgetReply struct {
+	Proposal Proposal `json:"proposal"`
+}
+
+// ProposalReport retrieves the amounts billed and paid against each
+// proposal, per month. If a token is provided, only that proposal is
+// included.
+type ProposalReport struct {
+	Token string `json:"token,omitempty"`
+}
+
+// ProposalReportReply is used to reply to the ProposalReport command.
+type ProposalReportReply struct {
+	Proposals []ProposalSpending `json:"proposals"`
+}
+
 // UpdateInvoicePayment adds or updates a payment to an invoice.
 type UpdateInvoicePayment struct {
 	Token   string `json:"token"`
@@ -283,6 +610,56 @@ type InvoicesReply struct {
 	TotalMatches uint64          `json:"totalmatches"`
 }
 
+// SearchInvoices searches the invoices by any combination of criteria. All
+// criteria are optional, and the invoice period range is inclusive. Users
+// who can't view all invoices can only search their own.
+//
+// Results are returned in pages of at most Limit invoices, up to the
+// ListPageSize policy. The next page is retrieved by passing the NextCursor
+// of the reply as the Cursor, with the same criteria and sort order.
+type SearchInvoices struct {
+	Statuses      []InvoiceStatusT `json:"statuses,omitempty"`      // Any of these statuses
+	UserID        string           `json:"userid,omitempty"`        // ID of the user who submitted the invoice
+	FromMonth     uint16           `json:"frommonth,omitempty"`     // First month of the invoice period range
+	FromYear      uint16           `json:"fromyear,omitempty"`      // Year of FromMonth
+	ToMonth       uint16           `json:"tomonth,omitempty"`       // Last month of the invoice period range
+	ToYear        uint16           `json:"toyear,omitempty"`        // Year of ToMonth
+	SubmittedFrom int64            `json:"submittedfrom,omitempty"` // Submitted at or after this Unix timestamp
+	SubmittedTo   int64            `json:"submittedto,omitempty"`   // Submitted at or before this Unix timestamp
+	Proposal      string           `json:"proposal,omitempty"`      // Token of a proposal billed against by a line item
+	Type          string           `json:"type,omitempty"`          // Type of work of a line item, case-insensitive
+	MinTotalCost  uint64           `json:"mintotalcost,omitempty"`  // Minimum total cost in USD
+	MaxTotalCost  uint64           `json:"maxtotalcost,omitempty"`  // Maximum total cost in USD
+	Text          string           `json:"text,omitempty"`          // Text in a line item description, case-insensitive
+	Sort          InvoiceSortT     `json:"sort"`                    // Sort order
+	Cursor        string           `json:"cursor,omitempty"`        // Cursor of the page to return
+	Limit         uint             `json:"limit,omitempty"`         // Maximum number of invoices to return
+}
+
+// SearchInvoicesReply is used to reply with a page of the invoices which
+// match the search criteria.
+type SearchInvoicesReply struct {
+	Invoices     []InvoiceRecord `json:"invoices"`
+	TotalMatches uint64          `json:"totalmatches"`         // Number of invoices matching the criteria
+	NextCursor   string          `json:"nextcursor,omitempty"` // Cursor of the next page, empty on the last page
+}
+
+// MissingInvoices retrieves all active contractors who have not submitted
+// an invoice for a given month & year.
+//
+// Note: This call requires admin privileges.
+type MissingInvoices struct {
+	Month uint16 `json:"month"`
+	Year  uint16 `json:"year"`
+}
+
+// MissingInvoicesReply is used to reply with a list of contractors who
+// have not submitted an invoice.
+type MissingInvoicesReply struct {
+	Deadline int64          `json:"deadline"` // Unix timestamp of the submission deadline
+	Users    []AbridgedUser `json:"users"`
+}
+
 // ReviewInvoices retrieves all unreviewed invoices and returns each of their
 // line items along with their total costs in USD.
 //
@@ -299,22 +676,38 @@ type ReviewInvoicesReply struct {
 
 // InvoiceReview represents a submitted invoice which needs to be reviewed.
 type InvoiceReview struct {
-	UserID       string                  `json:"userid"`
-	Username     string                  `json:"username"`
-	Token        string                  `json:"token"`
-	LineItems    []InvoiceReviewLineItem `json:"lineitems"`
-	TotalHours   uint64                  `json:"totalhours"`
-	TotalCostUSD uint64                  `json:"totalcostusd"`
+	UserID           string                  `json:"userid"`
+	Username         string                  `json:"username"`
+	Token            string                  `json:"token"`
+	LineItems        []InvoiceReviewLineItem `json:"lineitems"`
+	TotalHours       uint64                  `json:"totalhours"`
+	TotalLaborUSD    uint64                  `json:"totallaborusd"`            // Total cost of the labor line items
+	TotalExpensesUSD uint64                  `json:"totalexpensesusd"`         // Total cost of the expense line items
+	TotalCostUSD     uint64                  `json:"totalcostusd"`             // Total cost of all line items
+	LeadReviews      []InvoiceLeadReview     `json:"leadreviews,omitempty"`    // Reviews of this version by domain leads
+	BudgetWarnings   []ProposalBudgetWarning `json:"budgetwarnings,omitempty"` // Proposals whose budget this invoice exceeds
 }
 
-// InvoiceReviewLineItem is a unit of work within a submitted invoice.
+// ProposalBudgetWarning is returned when the approved and paid invoices
+// billed against a proposal, together with the invoice under review, exceed
+// the proposal's budget.
+type ProposalBudgetWarning struct {
+	Token     string `json:"token"`
+	Name      string `json:"name"`
+	BudgetUSD uint64 `json:"budgetusd"`
+	BilledUSD uint64 `json:"billedusd"` // Committed spending including this invoice
+}
+
+// InvoiceReviewLineItem is a unit of work, or an expense, within a
+// submitted invoice. The subtype of an expense is its category.
 type InvoiceReviewLineItem struct {
-	Type        string `json:"type"`
-	Subtype     string `json:"subtype"`
-	Description string `json:"description"`
-	Proposal    string `json:"proposal"`
-	Hours       uint64 `json:"hours"`
-	TotalCost   uint64 `json:"totalcost"`
+	Kind        LineItemKindT `json:"kind"`
+	Type        string        `json:"type"`
+	Subtype     string        `json:"subtype"`
+	Description string        `json:"description"`
+	Proposal    string        `json:"proposal"`
+	Hours       uint64        `json:"hours"`
+	TotalCost   uint64        `json:"totalcost"`
 }
 
 // PayInvoices retrieves all approved invoices and returns them
@@ -371,6 +764,99 @@ type UserInvoicesReply struct {
 	Invoices []InvoiceRecord `json:"invoices"`
 }
 
+// UserEarnings retrieves the earnings statement of a user for the invoices
+// paid in a given year. If no user ID is provided, the statement of the
+// logged in user is returned.
+//
+// Note: Retrieving the statement of another user requires admin privileges.
+type UserEarnings struct {
+	UserID string `json:"userid,omitempty"`
+	Year   uint16 `json:"year"`
+}
+
+// UserEarningsReply is used to reply to the UserEarnings command. The
+// signature is of the JSON encoding of the statement, made with the key
+// returned as the signingpublickey of the version reply.
+type UserEarningsReply struct {
+	Statement EarningsStatement `json:"statement"`
+	PublicKey string            `json:"publickey"` // Key the statement was signed with
+	Signature string            `json:"signature"` // Signature of the JSON encoded statement
+}
+
+// SetTOTP is used to start enrolling in two-factor authentication. If it's
+// already enabled, a valid code is required to replace the existing secret.
+type SetTOTP struct {
+	Code string `json:"code"`
+}
+
+// SetTOTPReply returns the new TOTP secret, which must be verified with
+// VerifyTOTP before two-factor authentication is enabled.
+type SetTOTPReply struct {
+	Secret string `json:"secret"` // Base32 encoded secret
+	URI    string `json:"uri"`    // otpauth:// URI, suitable for a QR code
+}
+
+// VerifyTOTP is used to verify a newly set TOTP secret and enable
+// two-factor authentication.
+type VerifyTOTP struct {
+	Code string `json:"code"`
+}
+
+// VerifyTOTPReply returns the recovery codes, each of which may be used
+// once in place of a two-factor authentication code.
+type VerifyTOTPReply struct {
+	RecoveryCodes []string `json:"recoverycodes"`
+}
+
+// NewAPIToken is used to create a long-lived API token for scripted access.
+// The token is sent in the Authorization header as "Bearer <token>" and
+// only grants access to the routes covered by its scopes.
+type NewAPIToken struct {
+	Name   string         `json:"name"`
+	Scopes APITokenScopeT `json:"scopes"` // Sum of the granted scopes
+	Expiry int64          `json:"expiry"` // Unix timestamp when the token expires
+}
+
+// NewAPITokenReply returns the new API token; it's not retrievable again.
+type NewAPITokenReply struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// APITokens is used to list the user's API tokens.
+type APITokens struct{}
+
+// APITokensReply returns the user's API tokens.
+type APITokensReply struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// RevokeAPIToken is used to revoke one of the user's API tokens.
+type RevokeAPIToken struct {
+	ID string `json:"id"`
+}
+
+// RevokeAPITokenReply is used to reply to the RevokeAPIToken command.
+type RevokeAPITokenReply struct{}
+
+// UserSessions is used to request the user's active login sessions.
+type UserSessions struct{}
+
+// UserSessionsReply returns the user's active login sessions.
+type UserSessionsReply struct {
+	Sessions []UserSession `json:"sessions"`
+}
+
+// RevokeSessions is used to revoke one of the user's login sessions, or all
+// of them except the current one.
+type RevokeSessions struct {
+	ID  string `json:"id,omitempty"`  // ID of the session to revoke
+	All bool   `json:"all,omitempty"` // Revoke all sessions except the current one
+}
+
+// RevokeSessionsReply is used to reply to the RevokeSessions command.
+type RevokeSessionsReply struct{}
+
 // ChangePassword is used to perform a password change while the user
 // is logged in.
 type ChangePassword struct {
@@ -408,6 +894,10 @@ type PolicyReply struct {
 	MaxUsernameLength      uint          `json:"maxusernamelength"`
 	UsernameSupportedChars []string      `json:"usernamesupportedchars"`
 	ListPageSize           uint          `json:"listpagesize"`
+	MaxCommentLength       uint          `json:"maxcommentlength"`
+	MaxAttachments         uint          `json:"maxattachments"`
+	MaxAttachmentSize      uint          `json:"maxattachmentsize"`
+	MaxBatchStatusChanges  uint          `json:"maxbatchstatuschanges"`
 	ValidMIMETypes         []string      `json:"validmimetypes"`
 	Invoice                InvoicePolicy `json:"invoice"`
 }
@@ -417,6 +907,37 @@ type InvoicePolicy struct {
 	FieldDelimiterChar rune                 `json:"fielddelimiterchar"`
 	CommentChar        rune                 `json:"commentchar"`
 	Fields             []InvoicePolicyField `json:"fields"`
+	ExpenseType        string               `json:"expensetype"`       // Type of work which marks a line item as an expense
+	ExpenseCategories  []string             `json:"expensecategories"` // Valid subtypes of expense line items
+
+	ApprovalRules []InvoiceApprovalRule `json:"approvalrules"`
+	Workflow      InvoiceWorkflow       `json:"workflow"`
+}
+
+// InvoiceWorkflow describes the invoice statuses used by the server and the
+// status changes which can be made between them.
+type InvoiceWorkflow struct {
+	Statuses      []InvoiceStatusT          `json:"statuses"`
+	Transitions   []InvoiceStatusTransition `json:"transitions"`
+	PayableStatus InvoiceStatusT            `json:"payablestatus"` // Status of invoices which are ready to be paid
+}
+
+// InvoiceStatusTransition is a status change which can be made to an
+// invoice. If Role is 0, the change is made by the contractor who submitted
+// the invoice rather than by an admin.
+type InvoiceStatusTransition struct {
+	From           InvoiceStatusT `json:"from"`
+	To             InvoiceStatusT `json:"to"`
+	Role           UserRoleT      `json:"role"`           // Role required to make the change
+	ReasonRequired bool           `json:"reasonrequired"` // Whether a reason must be provided
+}
+
+// InvoiceApprovalRule requires invoices whose total cost is at least
+// MinTotalCost to be approved by a number of distinct admins before they
+// are marked approved or paid.
+type InvoiceApprovalRule struct {
+	MinTotalCost uint64 `json:"mintotalcost"` // Minimum total cost (in USD)
+	Approvals    uint   `json:"approvals"`    // Number of distinct admins that must approve
 }
 
 type InvoicePolicyField struct {
@@ -444,7 +965,8 @@ type UserDetails struct {
 
 // UserDetailsReply returns a user's details.
 type UserDetailsReply struct {
-	User User `json:"user"`
+	User            User             `json:"user"`
+	ContractChanges []ContractChange `json:"contractchanges,omitempty"` // Only returned to admins and users with a role that grants access to all users
 }
 
 // ManageUser performs the given action on a user given their id, email or username.
@@ -452,8 +974,10 @@ type ManageUser struct {
 	UserID   string            `json:"userid"`
 	Email    string            `json:"email"`
 	Username string            `json:"username"`
-	Action   UserManageActionT `json:"action"` // Action
-	Reason   string            `json:"reason"` // Admin reason for action
+	Action   UserManageActionT `json:"action"`  // Action
+	Reason   string            `json:"reason"`  // Admin reason for action
+	Roles    UserRoleT         `json:"roles"`   // Sum of the user's new roles; only used for UserManageSetRoles
+	EndDate  int64             `json:"enddate"` // Unix timestamp of the contractor's last day of work; only used for UserManageDeactivate, defaults to now
 }
 
 // ManageUserReply is the reply for the ManageUser command.
@@ -483,10 +1007,60 @@ type EditUserExtendedPublicKeyReply struct {
 	VerificationToken string `json:"verificationtoken"`
 }
 
+// ChangeEmail allows a user to change his email address. It must be called
+// twice: first with only the new email address, which sends a verification
+// token to both the current and the new email addresses, and then with both
+// verification tokens.
+type ChangeEmail struct {
+	NewEmail             string `json:"newemail"`
+	OldVerificationToken string `json:"oldverificationtoken"` // Token sent to the current email address
+	NewVerificationToken string `json:"newverificationtoken"` // Token sent to the new email address
+}
+
+// ChangeEmailReply is the reply for the ChangeEmail command. The verification
+// tokens are only returned if the server has no email server set up.
+type ChangeEmailReply struct {
+	OldVerificationToken string `json:"oldverificationtoken,omitempty"`
+	NewVerificationToken string `json:"newverificationtoken,omitempty"`
+}
+
+// EditUserContract allows an admin to change the contract of a user given
+// their id, email or username. The whole contract is replaced.
+type EditUserContract struct {
+	UserID   string   `json:"userid"`
+	Email    string   `json:"email"`
+	Username string   `json:"username"`
+	Contract Contract `json:"contract"`
+	Reason   string   `json:"reason"` // Admin reason for the change
+}
+
+// EditUserContractReply is the reply for the EditUserContract command.
+type EditUserContractReply struct{}
+
+// Contract contains the terms agreed with a contractor. Zero values mean
+// that the term isn't set.
+type Contract struct {
+	StartDate     int64           `json:"startdate"`     // Unix timestamp of the first day of the contract
+	EndDate       int64           `json:"enddate"`       // Unix timestamp of the last day of the contract
+	Domain        ContractDomainT `json:"domain"`        // Domain of work
+	HourlyRateCap uint64          `json:"hourlyratecap"` // Maximum hourly rate (in USD)
+	Documents     []string        `json:"documents"`     // References to the contract documents
+}
+
+// ContractChange is a change made by an admin to a user's contract.
+type ContractChange struct {
+	AdminUserID string   `json:"adminuserid"` // ID of the admin who made the change
+	Contract    Contract `json:"contract"`    // Contract after the change
+	Reason      string   `json:"reason"`      // Admin reason for the change
+	Timestamp   int64    `json:"timestamp"`   // Unix timestamp of the change
+}
+
 // Users is used to request a list of users given a filter.
 type Users struct {
-	Username string `json:"username"` // String which should match or partially match a username
-	Page     uint16 `json:"page"`     // The page number being requested
+	Username           string          `json:"username"`           // String which should match or partially match a username
+	Domain             ContractDomainT `json:"domain"`             // Only return users whose contract is in this domain
+	ContractEndsBefore int64           `json:"contractendsbefore"` // Only return users whose contract ends before this Unix timestamp
+	Page               uint16          `json:"page"`               // The page number being requested
 }
 
 // UsersReply is a reply to the Users command, replying with a list of users.
@@ -512,6 +1086,7 @@ type User struct {
 	Location                                  string          `json:"location"`
 	ExtendedPublicKey                         string          `json:"xpublickey"`
 	Admin                                     bool            `json:"isadmin"`
+	Roles                                     UserRoleT       `json:"roles"`
 	RegisterVerificationToken                 []byte          `json:"newuserverificationtoken"`
 	RegisterVerificationExpiry                int64           `json:"newuserverificationexpiry"`
 	UpdateIdentityVerificationToken           []byte          `json:"updateidentityverificationtoken"`
@@ -523,13 +1098,115 @@ type User struct {
 	LastLogin                                 int64           `json:"lastlogin"`
 	FailedLoginAttempts                       uint64          `json:"failedloginattempts"`
 	Locked                                    bool            `json:"islocked"`
+	Deactivated                               bool            `json:"isdeactivated"`
+	EndDate                                   int64           `json:"enddate"`            // Last day of work of a deactivated contractor
 	EmailNotifications                        uint64          `json:"emailnotifications"` // Notify the user via emails
+	TOTPEnabled                               bool            `json:"totpenabled"`
+	Contract                                  Contract        `json:"contract"`
 	Identities                                []UserIdentity  `json:"identities"`
 	Invoices                                  []InvoiceRecord `json:"invoices"`
 }
 
+// APIToken contains the details of an API token, excluding the token
+// itself.
+type APIToken struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Scopes    APITokenScopeT `json:"scopes"`
+	Timestamp int64          `json:"timestamp"` // Unix timestamp of creation
+	Expiry    int64          `json:"expiry"`    // Unix timestamp of expiry
+	LastUsed  int64          `json:"lastused"`  // Unix timestamp of last use; 0 if never used
+}
+
+// UserSession is a login session of a user.
+type UserSession struct {
+	ID        string `json:"id"`
+	IPAddress string `json:"ipaddress"` // Address the session was last used from
+	UserAgent string `json:"useragent"` // User agent the session was last used with
+	Timestamp int64  `json:"timestamp"` // Unix timestamp of creation
+	LastSeen  int64  `json:"lastseen"`  // Unix timestamp of last use
+	Expiry    int64  `json:"expiry"`    // Unix timestamp of expiry
+	Current   bool   `json:"current"`   // Whether this is the session making the request
+}
+
 // UserIdentity represents a user's unique identity.
 type UserIdentity struct {
 	PublicKey string `json:"publickey"`
 	Active    bool   `json:"isactive"`
 }
+
+// Proposal is a Politeia proposal which invoices can be billed against.
+// A budget of 0 means no budget has been set.
+type Proposal struct {
+	Token     string `json:"token"`     // Censorship token of the proposal
+	Name      string `json:"name"`      // Name from the server configuration
+	BudgetUSD uint64 `json:"budgetusd"` // Budget in USD
+	Timestamp int64  `json:"timestamp"` // Unix timestamp of the last budget update
+}
+
+// ProposalSpending contains the amounts billed and paid against a proposal.
+// Rejected invoices aren't included.
+type ProposalSpending struct {
+	Proposal   Proposal                `json:"proposal"`
+	BilledUSD  uint64                  `json:"billedusd"`  // Total of the line items of all invoices
+	PaidUSD    uint64                  `json:"paidusd"`    // Total of the line items of paid invoices
+	OverBudget bool                    `json:"overbudget"` // Whether the billed amount exceeds the budget
+	Months     []ProposalMonthSpending `json:"months"`
+}
+
+// ProposalMonthSpending contains the amounts billed and paid against a
+// proposal for the invoices of a month.
+type ProposalMonthSpending struct {
+	Month     uint16 `json:"month"`
+	Year      uint16 `json:"year"`
+	BilledUSD uint64 `json:"billedusd"`
+	PaidUSD   uint64 `json:"paidusd"`
+}
+
+// SpendingSummary contains the spending of a set of invoices, in total and
+// grouped in several ways. Amounts in DCR are derived from the payments made
+// for each invoice, so invoices which haven't been paid count as 0 DCR.
+type SpendingSummary struct {
+	Invoices     uint64          `json:"invoices"` // Number of invoices
+	TotalHours   uint64          `json:"totalhours"`
+	TotalCostUSD uint64          `json:"totalcostusd"`
+	TotalCostDCR float64         `json:"totalcostdcr"`
+	ByType       []SpendingGroup `json:"bytype"`
+	BySubtype    []SpendingGroup `json:"bysubtype"`
+	ByContractor []SpendingGroup `json:"bycontractor"`
+	ByDomain     []SpendingGroup `json:"bydomain"`
+	ByProposal   []SpendingGroup `json:"byproposal"`
+}
+
+// SpendingGroup contains the spending of the line items which share a type
+// of work, subtype, contractor, contract domain or proposal.
+type SpendingGroup struct {
+	Name         string  `json:"name"`           // Type, subtype, username, domain or proposal token; empty if none
+	Type         string  `json:"type,omitempty"` // Type of work, only set when grouping by subtype
+	TotalHours   uint64  `json:"totalhours"`
+	TotalCostUSD uint64  `json:"totalcostusd"`
+	TotalCostDCR float64 `json:"totalcostdcr"`
+}
+
+// EarningsStatement lists the invoices of a user which were paid in a year.
+type EarningsStatement struct {
+	UserID       string            `json:"userid"`
+	Username     string            `json:"username"`
+	Year         uint16            `json:"year"`      // Year the invoices were paid in
+	Timestamp    int64             `json:"timestamp"` // Unix timestamp of the statement's creation
+	TotalCostUSD uint64            `json:"totalcostusd"`
+	TotalCostDCR float64           `json:"totalcostdcr"`
+	Invoices     []EarningsInvoice `json:"invoices"`
+}
+
+// EarningsInvoice is a paid invoice within an earnings statement.
+type EarningsInvoice struct {
+	Token        string   `json:"token"`
+	Month        uint16   `json:"month"`    // Month the invoice was submitted for
+	Year         uint16   `json:"year"`     // Year the invoice was submitted for
+	DatePaid     int64    `json:"datepaid"` // Unix timestamp of the payment
+	TotalCostUSD uint64   `json:"totalcostusd"`
+	TotalCostDCR float64  `json:"totalcostdcr"`
+	USDDCRRate   float64  `json:"usddcrrate"` // Rate the invoice was paid at
+	TxIDs        []string `json:"txids"`
+}
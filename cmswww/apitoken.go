@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/politeia/util"
+	"github.com/gorilla/csrf"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+type contextKey int
+
+const (
+	// apiTokenUserKey is the request context key for the user who was
+	// authenticated with an API token.
+	apiTokenUserKey contextKey = iota
+)
+
+var (
+	// apiTokenRouteScopes maps the routes which can be accessed with an
+	// API token to the scope that is required. Routes not listed here can
+	// only be accessed with a cookie session.
+	apiTokenRouteScopes = map[string]v1.APITokenScopeT{
+		// Clients use the version route to look up the authenticated user,
+		// so any scope grants access to it.
+		v1.RouteRoot: allAPITokenScopes,
+
+		v1.RoutePolicy:               v1.APITokenScopeRead,
+		v1.RouteInvoiceDetails:       v1.APITokenScopeRead,
+		v1.RouteInvoicePDF:           v1.APITokenScopeRead,
+		v1.RouteUserInvoices:         v1.APITokenScopeRead,
+		v1.RouteUserEarnings:         v1.APITokenScopeRead,
+		v1.RouteUserDetails:          v1.APITokenScopeRead,
+		v1.RouteInvoices:             v1.APITokenScopeRead,
+		v1.RouteMissingInvoices:      v1.APITokenScopeRead,
+		v1.RouteSpendingReport:       v1.APITokenScopeRead,
+		v1.RouteSearchInvoices:       v1.APITokenScopeRead,
+		v1.RouteUsers:                v1.APITokenScopeRead,
+		v1.RouteRate:                 v1.APITokenScopeRead,
+		v1.RouteTeamInvoices:         v1.APITokenScopeRead,
+		v1.RouteInvoiceComments:      v1.APITokenScopeRead,
+		v1.RouteInvoiceDraft:         v1.APITokenScopeRead,
+		v1.RouteProposals:            v1.APITokenScopeRead,
+		v1.RouteProposalReport:       v1.APITokenScopeRead,
+		v1.RouteSubmitInvoice:        v1.APITokenScopeSubmitInvoice,
+		v1.RouteEditInvoice:          v1.APITokenScopeSubmitInvoice,
+		v1.RouteWithdrawInvoice:      v1.APITokenScopeSubmitInvoice,
+		v1.RouteNewInvoiceComment:    v1.APITokenScopeSubmitInvoice,
+		v1.RouteNewDraftLineItem:     v1.APITokenScopeSubmitInvoice,
+		v1.RouteEditDraftLineItem:    v1.APITokenScopeSubmitInvoice,
+		v1.RouteDeleteDraftLineItem:  v1.APITokenScopeSubmitInvoice,
+		v1.RouteReviewInvoices:       v1.APITokenScopeAdminReview,
+		v1.RouteSetInvoiceStatus:     v1.APITokenScopeAdminReview,
+		v1.RouteSetInvoiceStatuses:   v1.APITokenScopeAdminReview,
+		v1.RouteSetProposalBudget:    v1.APITokenScopeAdminReview,
+		v1.RoutePayInvoices:          v1.APITokenScopeAdminPay,
+		v1.RoutePayInvoice:           v1.APITokenScopeAdminPay,
+		v1.RouteUpdateInvoicePayment: v1.APITokenScopeAdminPay,
+		v1.RouteInviteNewUser:        v1.APITokenScopeAdminUsers,
+		v1.RouteManageUser:           v1.APITokenScopeAdminUsers,
+		v1.RouteEditUserContract:     v1.APITokenScopeAdminUsers,
+	}
+
+	// apiTokenScopePermissions maps the privileged API token scopes to the
+	// permission the user must have to grant them.
+	apiTokenScopePermissions = map[v1.APITokenScopeT]permission{
+		v1.APITokenScopeAdminReview: permissionReview,
+		v1.APITokenScopeAdminPay:    permissionPay,
+		v1.APITokenScopeAdminUsers:  permissionManageUsers,
+	}
+
+	// allAPITokenScopes is the sum of all valid API token scopes.
+	allAPITokenScopes = v1.APITokenScopeRead | v1.APITokenScopeSubmitInvoice |
+		v1.APITokenAdminScopes
+)
+
+// apiTokenFromRequest returns the API token from the Authorization header,
+// if one was provided.
+func apiTokenFromRequest(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	header := r.Header.Get(v1.Authorization)
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(header, prefix))
+	return token, token != ""
+}
+
+// hashAPIToken returns the hash of the API token that is stored in the
+// database.
+func hashAPIToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// skipCSRFForAPITokens disables the CSRF check for requests that are
+// authenticated with an API token, since they don't rely on cookies. The
+// token itself is validated when the route is handled.
+func skipCSRFForAPITokens(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := apiTokenFromRequest(r); ok {
+			r = csrf.UnsafeSkipCheck(r)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// authenticateAPIToken returns the user who owns the given API token if
+// the token is valid and grants access to the route.
+func (c *cmswww) authenticateAPIToken(token, route string) (*database.User, error) {
+	apiToken, err := c.db.GetAPITokenByHash(hashAPIToken(token))
+	if err != nil {
+		if err == database.ErrAPITokenNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvalidAPIToken,
+			}
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Unix() >= apiToken.Expiry {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidAPIToken,
+		}
+	}
+
+	scope, ok := apiTokenRouteScopes[route]
+	if !ok || apiToken.Scopes&scope == 0 {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusAPITokenScopeNotGranted,
+		}
+	}
+
+	user, err := c.db.GetUserById(apiToken.UserID)
+	if err != nil {
+		if err == database.ErrUserNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvalidAPIToken,
+			}
+		}
+		return nil, err
+	}
+
+	if IsUserLocked(user) {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusUserLocked,
+		}
+	}
+
+	apiToken.LastUsed = now.Unix()
+	err = c.db.UpdateAPIToken(apiToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// withAPIToken authenticates requests that provide an API token before
+// calling the next function; requests without a token are passed through
+// unchanged.
+func (c *cmswww) withAPIToken(route string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := apiTokenFromRequest(r)
+		if !ok {
+			f(w, r)
+			return
+		}
+
+		user, err := c.authenticateAPIToken(token, route)
+		if err != nil {
+			if userErr, ok := err.(v1.UserError); ok {
+				httpStatus := http.StatusUnauthorized
+				if userErr.ErrorCode == v1.ErrorStatusAPITokenScopeNotGranted {
+					httpStatus = http.StatusForbidden
+				}
+				util.RespondWithJSON(w, httpStatus, v1.ErrorReply{
+					ErrorCode: int64(userErr.ErrorCode),
+				})
+				return
+			}
+
+			RespondWithError(w, r, 0, "withAPIToken: %v", err)
+			return
+		}
+
+		f(w, r.WithContext(context.WithValue(r.Context(), apiTokenUserKey,
+			user)))
+	}
+}
+
+func convertDatabaseAPITokenToAPIToken(apiToken *database.APIToken) v1.APIToken {
+	return v1.APIToken{
+		ID:        strconv.FormatUint(apiToken.ID, 10),
+		Name:      apiToken.Name,
+		Scopes:    apiToken.Scopes,
+		Timestamp: apiToken.Timestamp,
+		Expiry:    apiToken.Expiry,
+		LastUsed:  apiToken.LastUsed,
+	}
+}
+
+// HandleNewAPIToken creates a new API token for the user.
+func (c *cmswww) HandleNewAPIToken(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	nat := req.(*v1.NewAPIToken)
+
+	// Validate the name.
+	nat.Name = strings.TrimSpace(nat.Name)
+	if nat.Name == "" || len(nat.Name) > v1.PolicyMaxAPITokenNameLength {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid name"},
+		}
+	}
+
+	// Validate the scopes.
+	if nat.Scopes == 0 || nat.Scopes&^allAPITokenScopes != 0 {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid scopes"},
+		}
+	}
+
+	// Privileged scopes can only be granted by users who have the
+	// corresponding permission.
+	for scope, perm := range apiTokenScopePermissions {
+		if nat.Scopes&scope != 0 && !hasPermission(user, perm) {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusRoleRequired,
+				ErrorContext: []string{v1.APITokenScope[scope]},
+			}
+		}
+	}
+
+	// Validate the expiry.
+	now := time.Now()
+	if nat.Expiry <= now.Unix() ||
+		nat.Expiry > now.Add(v1.PolicyMaxAPITokenLifetime).Unix() {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid expiry"},
+		}
+	}
+
+	tokenBytes, err := util.Random(v1.APITokenSize)
+	if err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	apiToken := database.APIToken{
+		UserID:    user.ID,
+		Name:      nat.Name,
+		TokenHash: hashAPIToken(token),
+		Scopes:    nat.Scopes,
+		Expiry:    nat.Expiry,
+	}
+	err = c.db.CreateAPIToken(&apiToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.NewAPITokenReply{
+		ID:    strconv.FormatUint(apiToken.ID, 10),
+		Token: token,
+	}, nil
+}
+
+// HandleAPITokens returns the user's API tokens.
+func (c *cmswww) HandleAPITokens(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	apiTokens, err := c.db.GetAPITokensByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	atr := v1.APITokensReply{
+		Tokens: make([]v1.APIToken, 0, len(apiTokens)),
+	}
+	for _, apiToken := range apiTokens {
+		atr.Tokens = append(atr.Tokens,
+			convertDatabaseAPITokenToAPIToken(&apiToken))
+	}
+
+	return &atr, nil
+}
+
+// HandleRevokeAPIToken revokes one of the user's API tokens.
+func (c *cmswww) HandleRevokeAPIToken(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	rat := req.(*v1.RevokeAPIToken)
+
+	id, err := strconv.ParseUint(rat.ID, 10, 64)
+	if err != nil {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusAPITokenNotFound,
+		}
+	}
+
+	// Ensure the token belongs to the user.
+	apiTokens, err := c.db.GetAPITokensByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, apiToken := range apiTokens {
+		if apiToken.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusAPITokenNotFound,
+		}
+	}
+
+	err = c.db.DeleteAPIToken(id)
+	if err != nil {
+		if err == database.ErrAPITokenNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusAPITokenNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	return &v1.RevokeAPITokenReply{}, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// approvalRules are the rules which determine how many distinct admins must
+// approve an invoice status change, sorted by minimum total cost.
+type approvalRules []v1.InvoiceApprovalRule
+
+// pendingApproval holds the approvals which have been collected for an
+// invoice that is awaiting additional approval.
+type pendingApproval struct {
+	status         v1.InvoiceStatusT // Status that is being approved
+	previousStatus v1.InvoiceStatusT // Status before approvals were collected
+	approvals      []database.InvoiceChange
+}
+
+// parseApprovalRules parses approval rules of the form
+// <minimum total cost in USD>:<approvals>.
+func parseApprovalRules(rules []string) (approvalRules, error) {
+	parsed := make(approvalRules, 0, len(rules))
+	for _, rule := range rules {
+		pair := strings.Split(rule, ":")
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid approval rule %q, format "+
+				"must be <minimum total cost>:<approvals>", rule)
+		}
+
+		minTotalCost, err := strconv.ParseUint(pair[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum total cost in "+
+				"approval rule %q: %v", rule, err)
+		}
+
+		approvals, err := strconv.ParseUint(pair[1], 10, 32)
+		if err != nil || approvals == 0 {
+			return nil, fmt.Errorf("invalid number of approvals in "+
+				"approval rule %q", rule)
+		}
+
+		parsed = append(parsed, v1.InvoiceApprovalRule{
+			MinTotalCost: minTotalCost,
+			Approvals:    uint(approvals),
+		})
+	}
+
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].MinTotalCost < parsed[j].MinTotalCost
+	})
+	return parsed, nil
+}
+
+// requiredApprovals returns the number of distinct admins that must approve
+// a status change for an invoice with the given total cost.
+func (r approvalRules) requiredApprovals(totalCost uint64) uint {
+	required := uint(1)
+	for _, rule := range r {
+		if totalCost >= rule.MinTotalCost && rule.Approvals > required {
+			required = rule.Approvals
+		}
+	}
+
+	return required
+}
+
+// requiresApproval returns whether changing an invoice from the old status
+// to the new one is subject to the approval rules. Releasing a held invoice
+// or resolving a dispute returns the invoice to a status which was already
+// approved; parseInvoiceWorkflow rejects workflows in which invoices can be
+// held or disputed before they're approved.
+func requiresApproval(oldStatus, newStatus v1.InvoiceStatusT) bool {
+	if oldStatus == v1.InvoiceStatusOnHold ||
+		oldStatus == v1.InvoiceStatusDisputed {
+		return false
+	}
+	return newStatus == v1.InvoiceStatusApproved ||
+		newStatus == v1.InvoiceStatusPaid
+}
+
+// getRequiredApprovals returns the number of distinct admins that must
+// approve a status change for the invoice.
+func (c *cmswww) getRequiredApprovals(dbInvoice *database.Invoice) (uint, error) {
+	if len(c.cfg.InvoiceApprovalRules) == 0 {
+		return 1, nil
+	}
+
+	err := c.fetchInvoiceFileIfNecessary(dbInvoice)
+	if err != nil {
+		return 0, err
+	}
+
+	var invoicePayment v1.InvoicePayment
+	err = c.deriveTotalCostFromInvoice(dbInvoice, &invoicePayment)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.cfg.InvoiceApprovalRules.requiredApprovals(
+		invoicePayment.TotalCostUSD), nil
+}
+
+// getPendingApproval returns the approvals which have been collected for an
+// invoice that is awaiting additional approval.
+func (c *cmswww) getPendingApproval(dbInvoice *database.Invoice) (*pendingApproval, error) {
+	changes, err := c.db.GetInvoiceChanges(dbInvoice.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	pa := newPendingApproval(changes)
+	if pa == nil {
+		return nil, fmt.Errorf("no approvals found for invoice %v",
+			dbInvoice.Token)
+	}
+	return pa, nil
+}
+
+// newPendingApproval returns the pending approval described by the status
+// changes of an invoice, oldest first, or nil if the invoice isn't awaiting
+// approval. The approvals are the most recent changes that moved the invoice
+// into the awaiting approval state for the same status; earlier approvals
+// for a different status don't count toward it.
+func newPendingApproval(changes []database.InvoiceChange) *pendingApproval {
+	i := len(changes) - 1
+	if i < 0 || changes[i].NewStatus != v1.InvoiceStatusAwaitingApproval {
+		return nil
+	}
+
+	pa := pendingApproval{
+		status:         changes[i].ApprovalFor,
+		previousStatus: v1.InvoiceStatusNotReviewed,
+	}
+	for ; i >= 0; i-- {
+		if changes[i].NewStatus != v1.InvoiceStatusAwaitingApproval ||
+			changes[i].ApprovalFor != pa.status {
+			break
+		}
+		pa.approvals = append([]database.InvoiceChange{changes[i]},
+			pa.approvals...)
+	}
+
+	// The invoice's status before approvals were collected is that of the
+	// last change which didn't move it into the awaiting approval state.
+	for ; i >= 0; i-- {
+		if changes[i].NewStatus != v1.InvoiceStatusAwaitingApproval {
+			pa.previousStatus = changes[i].NewStatus
+			break
+		}
+	}
+
+	return &pa
+}
+
+// approvalsFor returns the number of approvals collected for the status.
+func (pa *pendingApproval) approvalsFor(status v1.InvoiceStatusT) uint {
+	approvals := uint(0)
+	for _, approval := range pa.approvals {
+		if approval.ApprovalFor == status {
+			approvals++
+		}
+	}
+	return approvals
+}
+
+// hasApproved returns whether the user has already approved the pending
+// status change.
+func (c *cmswww) hasApproved(pa *pendingApproval, user *database.User) (bool, error) {
+	for _, approval := range pa.approvals {
+		userID, err := c.db.GetUserIdByPublicKey(approval.AdminPublicKey)
+		if err != nil {
+			return false, err
+		}
+		if userID == user.ID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// setInvoiceApprovals populates the approval fields of an invoice that is
+// awaiting additional approval.
+func (c *cmswww) setInvoiceApprovals(
+	invoice *v1.InvoiceRecord,
+	dbInvoice *database.Invoice,
+) error {
+	if dbInvoice.Status != v1.InvoiceStatusAwaitingApproval {
+		return nil
+	}
+
+	pa, err := c.getPendingApproval(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	invoice.ApprovalsRequired, err = c.getRequiredApprovals(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	invoice.ApprovalFor = pa.status
+	invoice.Approvals = make([]v1.InvoiceApproval, 0, len(pa.approvals))
+	for _, approval := range pa.approvals {
+		invoice.Approvals = append(invoice.Approvals, v1.InvoiceApproval{
+			PublicKey: approval.AdminPublicKey,
+			Signature: approval.AdminSignature,
+			Timestamp: approval.Timestamp,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+func TestParseApprovalRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		want  approvalRules
+		ok    bool
+	}{
+		{
+			name: "no rules",
+			want: approvalRules{},
+			ok:   true,
+		},
+		{
+			name:  "sorted by minimum total cost",
+			rules: []string{"20000:3", "5000:2"},
+			want: approvalRules{
+				{MinTotalCost: 5000, Approvals: 2},
+				{MinTotalCost: 20000, Approvals: 3},
+			},
+			ok: true,
+		},
+		{
+			name:  "missing approvals",
+			rules: []string{"5000"},
+		},
+		{
+			name:  "too many fields",
+			rules: []string{"5000:2:1"},
+		},
+		{
+			name:  "invalid minimum total cost",
+			rules: []string{"-5000:2"},
+		},
+		{
+			name:  "invalid approvals",
+			rules: []string{"5000:two"},
+		},
+		{
+			name:  "no approvals",
+			rules: []string{"5000:0"},
+		},
+	}
+
+	for _, test := range tests {
+		rules, err := parseApprovalRules(test.rules)
+		if (err == nil) != test.ok {
+			t.Errorf("%v: got error %v, want ok %v", test.name, err,
+				test.ok)
+			continue
+		}
+		if test.ok && !reflect.DeepEqual(rules, test.want) {
+			t.Errorf("%v: got %v, want %v", test.name, rules, test.want)
+		}
+	}
+}
+
+func TestRequiredApprovals(t *testing.T) {
+	rules, err := parseApprovalRules([]string{"5000:2", "20000:3",
+		"10000:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rules     approvalRules
+		totalCost uint64
+		approvals uint
+	}{
+		{nil, 100000, 1},
+		{rules, 0, 1},
+		{rules, 4999, 1},
+		{rules, 5000, 2},
+		{rules, 19999, 2},
+		{rules, 20000, 3},
+		{rules, 100000, 3},
+		{approvalRules{{MinTotalCost: 0, Approvals: 1}}, 100, 1},
+		{approvalRules{{MinTotalCost: 0, Approvals: 4},
+			{MinTotalCost: 1000, Approvals: 2}}, 5000, 4},
+	}
+
+	for _, test := range tests {
+		approvals := test.rules.requiredApprovals(test.totalCost)
+		if approvals != test.approvals {
+			t.Errorf("rules %v, total cost %v: got %v approvals, want %v",
+				test.rules, test.totalCost, approvals, test.approvals)
+		}
+	}
+}
+
+func TestNewPendingApproval(t *testing.T) {
+	change := func(status, approvalFor v1.InvoiceStatusT, publicKey string) database.InvoiceChange {
+		return database.InvoiceChange{
+			AdminPublicKey: publicKey,
+			NewStatus:      status,
+			ApprovalFor:    approvalFor,
+		}
+	}
+	awaiting := v1.InvoiceStatusAwaitingApproval
+
+	tests := []struct {
+		name           string
+		changes        []database.InvoiceChange
+		pending        bool
+		status         v1.InvoiceStatusT
+		previousStatus v1.InvoiceStatusT
+		approvals      []string // Public keys of the approvals
+	}{
+		{
+			name: "no changes",
+		},
+		{
+			name: "not awaiting approval",
+			changes: []database.InvoiceChange{
+				change(awaiting, v1.InvoiceStatusApproved, "a"),
+				change(v1.InvoiceStatusApproved, 0, "b"),
+			},
+		},
+		{
+			name: "first approval of a new invoice",
+			changes: []database.InvoiceChange{
+				change(awaiting, v1.InvoiceStatusApproved, "a"),
+			},
+			pending:        true,
+			status:         v1.InvoiceStatusApproved,
+			previousStatus: v1.InvoiceStatusNotReviewed,
+			approvals:      []string{"a"},
+		},
+		{
+			name: "trailing approvals",
+			changes: []database.InvoiceChange{
+				change(awaiting, v1.InvoiceStatusApproved, "x"),
+				change(v1.InvoiceStatusRejected, 0, "y"),
+				change(awaiting, v1.InvoiceStatusApproved, "a"),
+				change(awaiting, v1.InvoiceStatusApproved, "b"),
+			},
+			pending:        true,
+			status:         v1.InvoiceStatusApproved,
+			previousStatus: v1.InvoiceStatusRejected,
+			approvals:      []string{"a", "b"},
+		},
+		{
+			name: "approvals for another status",
+			changes: []database.InvoiceChange{
+				change(v1.InvoiceStatusApproved, 0, "x"),
+				change(awaiting, v1.InvoiceStatusPaid, "a"),
+				change(awaiting, v1.InvoiceStatusPaid, "b"),
+				change(awaiting, v1.InvoiceStatusRejected, "c"),
+			},
+			pending:        true,
+			status:         v1.InvoiceStatusRejected,
+			previousStatus: v1.InvoiceStatusApproved,
+			approvals:      []string{"c"},
+		},
+		{
+			name: "interleaved approvals",
+			changes: []database.InvoiceChange{
+				change(v1.InvoiceStatusApproved, 0, "x"),
+				change(awaiting, v1.InvoiceStatusPaid, "a"),
+				change(awaiting, v1.InvoiceStatusRejected, "b"),
+				change(awaiting, v1.InvoiceStatusPaid, "c"),
+			},
+			pending:        true,
+			status:         v1.InvoiceStatusPaid,
+			previousStatus: v1.InvoiceStatusApproved,
+			approvals:      []string{"c"},
+		},
+	}
+
+	for _, test := range tests {
+		pa := newPendingApproval(test.changes)
+		if (pa != nil) != test.pending {
+			t.Errorf("%v: got pending %v, want %v", test.name, pa != nil,
+				test.pending)
+			continue
+		}
+		if pa == nil {
+			continue
+		}
+
+		if pa.status != test.status {
+			t.Errorf("%v: got status %v, want %v", test.name, pa.status,
+				test.status)
+		}
+		if pa.previousStatus != test.previousStatus {
+			t.Errorf("%v: got previous status %v, want %v", test.name,
+				pa.previousStatus, test.previousStatus)
+		}
+		approvals := make([]string, 0, len(pa.approvals))
+		for _, approval := range pa.approvals {
+			approvals = append(approvals, approval.AdminPublicKey)
+		}
+		if !reflect.DeepEqual(approvals, test.approvals) {
+			t.Errorf("%v: got approvals %v, want %v", test.name,
+				approvals, test.approvals)
+		}
+
+		// Only the approvals for the pending status count toward its
+		// quorum.
+		if n := pa.approvalsFor(test.status); n != uint(len(test.approvals)) {
+			t.Errorf("%v: got %v approvals for %v, want %v", test.name,
+				n, v1.InvoiceStatus[test.status], len(test.approvals))
+		}
+		for _, other := range []v1.InvoiceStatusT{v1.InvoiceStatusApproved,
+			v1.InvoiceStatusPaid, v1.InvoiceStatusRejected} {
+			if other != test.status && pa.approvalsFor(other) != 0 {
+				t.Errorf("%v: got approvals for %v", test.name,
+					v1.InvoiceStatus[other])
+			}
+		}
+	}
+}
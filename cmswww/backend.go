@@ -71,7 +71,7 @@ func (c *cmswww) remoteInventory() (*pd.InventoryReply, error) {
 	}
 	inv := pd.Inventory{
 		Challenge:     hex.EncodeToString(challenge),
-		IncludeFiles:  false,
+		IncludeFiles:  true,
 		VettedCount:   0,
 		BranchesCount: 0,
 	}
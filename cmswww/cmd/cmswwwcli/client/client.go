@@ -99,6 +99,9 @@ func (c *Ctx) makeRequest(method, route string, requestJSON interface{}, respons
 	if config.CsrfToken != "" {
 		req.Header.Add(v1.CsrfToken, config.CsrfToken)
 	}
+	if config.APIToken != "" {
+		req.Header.Set(v1.Authorization, "Bearer "+config.APIToken)
+	}
 	r, err := c.client.Do(req)
 	if err != nil {
 		return err
@@ -116,6 +119,20 @@ func (c *Ctx) makeRequest(method, route string, requestJSON interface{}, respons
 	return c.handleResponse(r, responseJSON)
 }
 
+// ErrorReply is the error returned when the server replies with an error
+// code, so that commands can react to specific errors.
+type ErrorReply struct {
+	Status       string
+	ErrorCode    v1.ErrorStatusT
+	ErrorContext []string
+
+	detailedErr string
+}
+
+func (e ErrorReply) Error() string {
+	return fmt.Sprintf("%v, %v", e.Status, e.detailedErr)
+}
+
 func (c *Ctx) handleResponse(r *http.Response, responseJSON interface{}) error {
 	responseBody := util.ConvertBodyToByteArray(r.Body, false)
 
@@ -152,7 +169,12 @@ func (c *Ctx) handleResponse(r *http.Response, responseJSON interface{}) error {
 			detailedErr = strconv.FormatInt(ue.ErrorCode, 10)
 		}
 
-		return fmt.Errorf("%v, %v", r.Status, detailedErr)
+		return ErrorReply{
+			Status:       r.Status,
+			ErrorCode:    v1.ErrorStatusT(ue.ErrorCode),
+			ErrorContext: ue.ErrorContext,
+			detailedErr:  detailedErr,
+		}
 	}
 
 	if responseJSON != nil {
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type APITokensCmd struct{}
+
+func (cmd *APITokensCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	var atr v1.APITokensReply
+	err = Ctx.Get(v1.RouteAPITokens, nil, &atr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		if len(atr.Tokens) == 0 {
+			fmt.Printf("You have no API tokens\n")
+			return nil
+		}
+
+		fmt.Printf("---------------------------\n")
+		for _, token := range atr.Tokens {
+			var scopes []string
+			for scope := v1.APITokenScopeRead; scope <= v1.APITokenScopeAdminUsers; scope <<= 1 {
+				if token.Scopes&scope != 0 {
+					scopes = append(scopes, v1.APITokenScope[scope])
+				}
+			}
+
+			lastUsed := "never"
+			if token.LastUsed != 0 {
+				lastUsed = time.Unix(token.LastUsed, 0).String()
+			}
+
+			fmt.Printf("        ID: %v\n", token.ID)
+			fmt.Printf("      Name: %v\n", token.Name)
+			fmt.Printf("    Scopes: %v\n", strings.Join(scopes, ", "))
+			fmt.Printf("   Created: %v\n", time.Unix(token.Timestamp, 0))
+			fmt.Printf("   Expires: %v\n", time.Unix(token.Expiry, 0))
+			fmt.Printf(" Last used: %v\n", lastUsed)
+			fmt.Printf("---------------------------\n")
+		}
+	}
+
+	return nil
+}
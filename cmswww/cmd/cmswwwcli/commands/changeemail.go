@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type ChangeEmailCmd struct {
+	Args struct {
+		NewEmail string `positional-arg-name:"newemail"`
+	} `positional-args:"true" required:"true"`
+	OldToken string `long:"oldtoken" optional:"true" description:"Verification token sent to your current email address"`
+	NewToken string `long:"newtoken" optional:"true" description:"Verification token sent to your new email address"`
+}
+
+func (cmd *ChangeEmailCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+	oldEmail := config.LoggedInUser.Email
+
+	// The change email command is special.  It must be called twice with
+	// different parameters.  For the 1st call, it should be called with only
+	// the new email address. On success it will send an email containing a
+	// verification token to both the current and the new email addresses.
+	// If the email server has been disabled, the verification tokens are sent
+	// back in the response body and the 2nd call is made automatically. The
+	// 2nd call should be called with both verification tokens.
+	ce := v1.ChangeEmail{
+		NewEmail:             cmd.Args.NewEmail,
+		OldVerificationToken: cmd.OldToken,
+		NewVerificationToken: cmd.NewToken,
+	}
+
+	var cer v1.ChangeEmailReply
+	err = Ctx.Post(v1.RouteChangeEmail, ce, &cer)
+	if err != nil {
+		return err
+	}
+
+	if ce.OldVerificationToken == "" && ce.NewVerificationToken == "" {
+		if cer.OldVerificationToken == "" {
+			if !config.JSONOutput {
+				fmt.Printf("Verification tokens have been sent to %v and "+
+					"%v\n", oldEmail, cmd.Args.NewEmail)
+			}
+			return nil
+		}
+
+		// Automatic 2nd change email call
+		ce.OldVerificationToken = cer.OldVerificationToken
+		ce.NewVerificationToken = cer.NewVerificationToken
+		cer = v1.ChangeEmailReply{}
+		err = Ctx.Post(v1.RouteChangeEmail, ce, &cer)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keep using the identity and invoices stored for the old email address.
+	config.LoggedInUser.Email = cmd.Args.NewEmail
+	err = config.MigrateUserData(oldEmail, cmd.Args.NewEmail)
+	if err != nil {
+		return err
+	}
+
+	// The session cookie may have been updated.
+	ck, err := Ctx.Cookies(config.Host)
+	if err != nil {
+		return err
+	}
+	err = config.SaveCookies(ck)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Your email address has been changed to %v\n",
+			cmd.Args.NewEmail)
+	}
+	return nil
+}
@@ -13,34 +13,62 @@ type Options struct {
 	Host       func(string) error `long:"host" description:"cmswww host"`
 	JSONOutput func()             `long:"jsonout" description:"Output only the last command's JSON output; use this option when writing scripts"`
 	Verbose    func()             `short:"v" long:"verbose" description:"Print request and response details"`
+	APIToken   func(string)       `long:"apitoken" description:"Authenticate with an API token instead of the login session; it can also be set with the CMSWWWCLI_APITOKEN environment variable"`
 
 	// cli commands
-	Login                   LoginCmd                   `command:"login" description:"Login to the contractor mgmt system.\n\n           Parameters: <email> <password>\n  --------------------------------------"`
+	Login                   LoginCmd                   `command:"login" description:"Login to the contractor mgmt system.\n\n           Parameters: <email> [ <password> | --identity ] [ --totp <code> ]\n\n           With --identity, the identity stored for the user is used to sign\n           a login challenge instead of sending a password.\n  --------------------------------------"`
 	Logout                  LogoutCmd                  `command:"logout" description:"Logout of the contractor mgmt system. Parameters: none\n  --------------------------------------"`
 	NewIdentity             NewIdentityCmd             `command:"newidentity" description:"Generate a new identity. Parameters: none\n  --------------------------------------"`
+	SetTOTP                 SetTOTPCmd                 `command:"settotp" description:"Generate a new secret for two-factor authentication.\n\n           Parameters: [ --code <current code> ]\n  --------------------------------------"`
+	VerifyTOTP              VerifyTOTPCmd              `command:"verifytotp" description:"Verify a newly generated two-factor authentication secret and enable two-factor authentication.\n\n           Parameters: <code>\n  --------------------------------------"`
 	VerifyNewIdentity       VerifyIdentityCmd          `command:"verifyidentity" description:"Verify a newly generated identity.\n\n           Parameters: <invoice token>\n  --------------------------------------"`
 	Register                RegisterCmd                `command:"register" description:"Complete registration as a contractor.\n\n           Parameters: <email> <username> <password> <invoice token>\n  --------------------------------------"`
 	Policy                  PolicyCmd                  `command:"policy" description:"Fetch server policy. Parameters: none\n  --------------------------------------"`
 	Version                 VersionCmd                 `command:"version" description:"Fetch server info and CSRF token. Parameters: none\n  --------------------------------------"`
 	InviteNewUser           InviteNewUserCmd           `command:"invite" description:"Send a new contractor invitation.\n\n           Parameters: <email>\n  --------------------------------------"`
-	Users                   UsersCmd                   `command:"users" description:"Fetch a list of users, optionally filtering by username.\n\n           Parameters: [ --username <username> ]\n  --------------------------------------"`
+	Users                   UsersCmd                   `command:"users" description:"Fetch a list of users, optionally filtering by username, contract domain or contract end date.\n\n           Parameters: [ --username <username> ] [ --domain <domain> ] [ --endsbefore <YYYY-MM-DD> ]\n  --------------------------------------"`
 	UserDetails             UserDetailsCmd             `command:"user" description:"Fetch a user's details given the user id.\n\n           Parameters: <user id/email/username>\n  --------------------------------------"`
-	ManageUser              ManageUserCmd              `command:"manageuser" description:"Manage a user by user id.\n\n           Parameters: <user id/email/username> <action> <reason>\n    Available actions: resendinvite, expireidentitytoken, lock, unlock\n  --------------------------------------"`
+	ManageUser              ManageUserCmd              `command:"manageuser" description:"Manage a user by user id.\n\n           Parameters: <user id/email/username> <action> <reason> [ --roles <roles> ] [ --enddate <YYYY-MM-DD> ]\n    Available actions: resendinvite, expireidentitytoken, lock, unlock, resettotp, setroles, deactivate, reactivate\n      Available roles: reviewer, treasurer, auditor, usermanager, domainlead (comma-separated, or none)\n  --------------------------------------"`
+	EditContract            EditContractCmd            `command:"editcontract" description:"Edit the contract of a user; terms which aren't provided are left unchanged.\n\n           Parameters: <user id/email/username> <reason> [ --startdate <YYYY-MM-DD or none> ] [ --enddate <YYYY-MM-DD or none> ] [ --domain <domain> ] [ --ratecap <USD per hour> ] [ --document <reference>... | --nodocuments ]\n    Available domains: none, development, design, marketing, documentation, research, community, operations\n  --------------------------------------"`
 	EditUser                EditUserCmd                `command:"edituser" description:"Edit a user's details.\n\n           Parameters: [ --name <name> ] [ --location <location> ] [ --emailnotifications <email notifications> ]\n  --------------------------------------"`
 	UpdateExtendedPublicKey UpdateExtendedPublicKeyCmd `command:"updatexpublickey" description:"Edit a user's extended public key.\n\n           Parameters: [ --token <verification token> ] [ --xpubkey <xpubkey> ]\n  --------------------------------------"`
+	ChangeEmail             ChangeEmailCmd             `command:"changeemail" description:"Change your email address. Verification tokens are sent to both the current and the new email address.\n\n           Parameters: <new email> [ --oldtoken <token sent to current email> --newtoken <token sent to new email> ]\n  --------------------------------------"`
+	NewAPIToken             NewAPITokenCmd             `command:"newapitoken" description:"Create a long-lived API token for scripted access.\n\n           Parameters: <name> <scope>... [ --days <days until expiry> ]\n     Available scopes: read, submitinvoice, adminreview, adminpay, adminusers\n  --------------------------------------"`
+	APITokens               APITokensCmd               `command:"apitokens" description:"List your API tokens. Parameters: none\n  --------------------------------------"`
+	RevokeAPIToken          RevokeAPITokenCmd          `command:"revokeapitoken" description:"Revoke an API token.\n\n           Parameters: <token id>\n  --------------------------------------"`
+	Sessions                SessionsCmd                `command:"sessions" description:"List your active login sessions. Parameters: none\n  --------------------------------------"`
+	RevokeSessions          RevokeSessionsCmd          `command:"revokesessions" description:"Revoke one of your login sessions, or all of them except the current one.\n\n           Parameters: <session id> | --all\n  --------------------------------------"`
 	ChangePassword          ChangePasswordCmd          `command:"changepassword" description:"Change your password.\n\n           Parameters: <current password> <new password>\n  --------------------------------------"`
 	ResetPassword           ResetPasswordCmd           `command:"resetpassword" description:"Reset your password.\n\n           Parameters: <email> <new password>\n  --------------------------------------"`
-	SubmitInvoice           SubmitInvoiceCmd           `command:"submitinvoice" description:"Submits an invoice for a given month and year.\n\n           Parameters: [ <month> <year> ] [ --invoice <invoice filename> ]\n  --------------------------------------"`
-	EditInvoice             EditInvoiceCmd             `command:"editinvoice" description:"Submits a revision to an existing invoice.\n\n           Parameters: <invoice token> <invoice filename>\n  --------------------------------------"`
+	SubmitInvoice           SubmitInvoiceCmd           `command:"submitinvoice" description:"Submits your invoice draft for a given month and year, or an invoice file.\n\n           Parameters: [ <month> <year> ] [ --invoice <invoice filename> ] [ --attachment <filename> ]...\n  --------------------------------------"`
+	ImportInvoice           ImportInvoiceCmd           `command:"importinvoice" description:"Creates an invoice file for a given month and year from a time-tracking export, which can then be submitted with submitinvoice.\n\n           Parameters: <filename> <month> <year> --format <format> [ --rules <rules filename> ] [ --type <type of work> ] [ --rate <USD per hour> ] [ --rounding <rounding> ] [ --out <invoice filename> ] [ --dropzero ]\n    Available formats: toggl, clockify, harvest, json\n   Available rounding: up, down, nearest\n  --------------------------------------"`
+	EditInvoice             EditInvoiceCmd             `command:"editinvoice" description:"Submits a revision to an existing invoice.\n\n           Parameters: <invoice token> <invoice filename> [ --attachment <filename> ]... [ --deleteattachment <name> ]...\n  --------------------------------------"`
+	WithdrawInvoice         WithdrawInvoiceCmd         `command:"withdrawinvoice" description:"Withdraws one of your invoices before it's approved, so that a new invoice can be submitted for the same month.\n\n           Parameters: <invoice token> [reason]\n  --------------------------------------"`
 	InvoiceDetails          InvoiceDetailsCmd          `command:"invoice" description:"Displays an invoice's details.\n\n           Parameters: <invoice token>\n  --------------------------------------"`
-	Invoices                InvoicesCmd                `command:"invoices" description:"Lists invoices with a particular status for a given month and year.\n\n           Parameters: <month> <year> [ --status <status> ]\n   Available statuses: unreviewed, rejected, approved, paid\n  --------------------------------------"`
-	MyInvoices              MyInvoicesCmd              `command:"myinvoices" description:"Lists a user's invoices with a particular status.\n\n           Parameters: [status]\n   Available statuses: unreviewed, rejected, approved, paid\n  --------------------------------------"`
-	SetInvoiceStatus        SetInvoiceStatusCmd        `command:"setinvoicestatus" description:"Changes an invoice's status.\n\n           Parameters: <invoice token> <status> <reason>\n   Available statuses: rejected (must provide a reason), approved, paid\n  --------------------------------------"`
-	LogWork                 LogWorkCmd                 `command:"logwork" description:"Adds a line item to an invoice.\n\n           Parameters: <month> <year>\n  --------------------------------------"`
+	InvoicePDF              InvoicePDFCmd              `command:"invoicepdf" description:"Saves an invoice as a PDF document signed by the server, along with a payment receipt if it has been paid.\n\n           Parameters: <invoice token> [ --out <filename> ]\n  --------------------------------------"`
+	InvoiceComments         InvoiceCommentsCmd         `command:"invoicecomments" description:"Displays the comment threads of an invoice.\n\n           Parameters: <invoice token>\n  --------------------------------------"`
+	Comment                 CommentCmd                 `command:"comment" description:"Comments on an invoice, one of its line items, or replies to an existing comment.\n\n           Parameters: <invoice token> <comment> [ --parent <comment id> ] [ --lineitem <line item number> ]\n  --------------------------------------"`
+	Invoices                InvoicesCmd                `command:"invoices" description:"Lists invoices with a particular status for a given month and year.\n\n           Parameters: <month> <year> [ --status <status> ]\n   Available statuses: unreviewed, rejected, approved, paid, awaitingapproval, withdrawn, onhold, disputed,\n                       readyforpayment\n  --------------------------------------"`
+	Earnings                EarningsCmd                `command:"earnings" description:"Displays a signed statement of your invoices paid in a given year, or those of another user.\n\n           Parameters: <year> [ --user <user id> ] [ --csv ] [ --save <filename> ]\n  --------------------------------------"`
+	VerifyEarnings          VerifyEarningsCmd          `command:"verifyearnings" description:"Verifies the signature of a saved earnings statement.\n\n           Parameters: <filename>\n  --------------------------------------"`
+	MyInvoices              MyInvoicesCmd              `command:"myinvoices" description:"Lists a user's invoices with a particular status.\n\n           Parameters: [status]\n   Available statuses: unreviewed, rejected, approved, paid, awaitingapproval, withdrawn, onhold, disputed,\n                       readyforpayment\n  --------------------------------------"`
+	SearchInvoices          SearchInvoicesCmd          `command:"searchinvoices" description:"Searches invoices by any combination of criteria, one page at a time. Only your own invoices are searched unless you can view all invoices.\n\n           Parameters: [ --status <status> ]... [ --user <user id> ] [ --from <YYYY-MM> ] [ --to <YYYY-MM> ] [ --submittedfrom <YYYY-MM-DD> ] [ --submittedto <YYYY-MM-DD> ] [ --proposal <token> ] [ --type <type of work> ] [ --mincost <USD> ] [ --maxcost <USD> ] [ --text <text> ] [ --sort <order> ] [ --cursor <cursor> ] [ --limit <number> ]\n   Available statuses: unreviewed, rejected, approved, paid, awaitingapproval, withdrawn, onhold, disputed,\n                       readyforpayment\n       Sort orders: newest, oldest, highestcost, lowestcost\n  --------------------------------------"`
+	SetInvoiceStatus        SetInvoiceStatusCmd        `command:"setinvoicestatus" description:"Changes an invoice's status.\n\n           Parameters: <invoice token> <status> <reason>\n                   or: --batch <JSON file of status changes>\n   Available statuses: rejected (must provide a reason), approved, paid,\n                       onhold (must provide a reason), disputed (must provide a reason), readyforpayment\n   The server's workflow determines which status changes are allowed, see the invoice command\n   Approving or paying an invoice may require approval from multiple admins\n  --------------------------------------"`
+	LogWork                 LogWorkCmd                 `command:"logwork" description:"Adds a line item to your invoice draft for the month, which is stored on the server.\n\n           Parameters: <month> <year> [ --expense ]\n  --------------------------------------"`
+	InvoiceDraft            InvoiceDraftCmd            `command:"draft" description:"Displays your invoice draft for the month.\n\n           Parameters: <month> <year>\n  --------------------------------------"`
+	EditWork                EditWorkCmd                `command:"editwork" description:"Replaces a line item of your invoice draft for the month.\n\n           Parameters: <month> <year> <line item number> [ --expense ]\n  --------------------------------------"`
+	RemoveWork              RemoveWorkCmd              `command:"removework" description:"Removes a line item from your invoice draft for the month.\n\n           Parameters: <month> <year> <line item number>\n  --------------------------------------"`
+	TeamInvoices            TeamInvoicesCmd            `command:"teaminvoices" description:"Lists the invoices of the contractors in your contract domain, along with their domain lead reviews.\n\n           Parameters: [ <month> <year> ] [ --status <status> ]\n   Available statuses: unreviewed, rejected, approved, paid, awaitingapproval, withdrawn, onhold, disputed,\n                       readyforpayment\n  --------------------------------------"`
+	LeadReview              LeadReviewCmd              `command:"leadreview" description:"Records a domain lead review of an unreviewed invoice from a contractor in your contract domain.\n\n           Parameters: <invoice token> <action> [reason]\n    Available actions: endorse, requestchanges (must provide a reason)\n  --------------------------------------"`
+	MissingInvoices         MissingInvoicesCmd         `command:"missinginvoices" description:"Lists active contractors who have not submitted an invoice for a given month and year.\n\n           Parameters: <month> <year>\n  --------------------------------------"`
 	ReviewInvoices          ReviewInvoicesCmd          `command:"reviewinvoices" description:"Generates a list of submitted invoices that are ready for initial review.\n\n           Parameters: <month> <year>\n  --------------------------------------"`
+	Report                  ReportCmd                  `command:"report" description:"Summarizes the spending of approved and paid invoices for a given month and for the year to date, grouped by type of work, subtype, contractor, contract domain and proposal.\n\n           Parameters: <month> <year> [ --csv ]\n  --------------------------------------"`
 	PayInvoices             PayInvoicesCmd             `command:"payinvoices" description:"Generates a list of unpaid invoices that are ready for payment.\n\n           Parameters: <month> <year> <USD/DCR rate>\n  --------------------------------------"`
 	PayInvoice              PayInvoiceCmd              `command:"payinvoice" description:"Generates payment information for a single invoice.\n\n           Parameters: <invoice token> <cost in USD> <USD/DCR rate>\n  --------------------------------------"`
 	UpdateInvoicePayment    UpdateInvoicePaymentCmd    `command:"updateinvoicepayment" description:"Updates a generated invoice payment with the transaction information.\n\n           Parameters: <invoice token> <address> <amount in atoms> <transaction id>\n  --------------------------------------"`
+	Proposals               ProposalsCmd               `command:"proposals" description:"Lists the Politeia proposals which invoices can be billed against, along with their budgets. Parameters: none\n  --------------------------------------"`
+	ProposalReport          ProposalReportCmd          `command:"proposalreport" description:"Displays the amounts billed and paid against each proposal per month, along with their budgets.\n\n           Parameters: [proposal token]\n  --------------------------------------"`
+	SetProposalBudget       SetProposalBudgetCmd       `command:"setproposalbudget" description:"Sets the budget of a Politeia proposal.\n\n           Parameters: <proposal token> <budget in USD>\n  --------------------------------------"`
 	GetRate                 GetRateCmd                 `command:"getrate" description:"Calculates the rate for the given month and year.\n\n           Parameters: <month> <year>\n  --------------------------------------"`
 }
 
@@ -69,4 +97,8 @@ func SetupOptsFunctions() {
 	Opts.Verbose = func() {
 		config.Verbose = true
 	}
+
+	Opts.APIToken = func(token string) {
+		config.APIToken = token
+	}
 }
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type CommentCmd struct {
+	Args struct {
+		Token   string `positional-arg-name:"token"`
+		Comment string `positional-arg-name:"comment"`
+	} `positional-args:"true" required:"true"`
+	ParentID uint64 `long:"parent" optional:"true" description:"ID of the comment to reply to"`
+	LineItem uint   `long:"lineitem" optional:"true" description:"Line item to comment on, starting at 1"`
+}
+
+func (cmd *CommentCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	id := config.LoggedInUserIdentity
+	if id == nil {
+		return ErrNotLoggedIn
+	}
+
+	// Replies are made on the line item of the comment being replied to.
+	lineItem := cmd.LineItem
+	if cmd.ParentID != 0 && lineItem == 0 {
+		ic := v1.InvoiceComments{
+			Token: cmd.Args.Token,
+		}
+
+		var icr v1.InvoiceCommentsReply
+		err = Ctx.Get(v1.RouteInvoiceComments, ic, &icr)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range icr.Comments {
+			if comment.CommentID == cmd.ParentID {
+				lineItem = comment.LineItem
+				break
+			}
+		}
+	}
+
+	msg := cmd.Args.Token + strconv.FormatUint(cmd.ParentID, 10) +
+		strconv.FormatUint(uint64(lineItem), 10) + cmd.Args.Comment
+	signature := id.SignMessage([]byte(msg))
+
+	nic := v1.NewInvoiceComment{
+		Token:     cmd.Args.Token,
+		ParentID:  cmd.ParentID,
+		LineItem:  lineItem,
+		Comment:   cmd.Args.Comment,
+		PublicKey: hex.EncodeToString(id.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}
+
+	var nicr v1.NewInvoiceCommentReply
+	err = Ctx.Post(v1.RouteNewInvoiceComment, nic, &nicr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Comment #%v added\n", nicr.Comment.CommentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/politeia/politeiad/api/v1/identity"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type EarningsCmd struct {
+	Args struct {
+		Year uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" required:"true"`
+	UserID string `long:"user" optional:"true" description:"User ID of another user"`
+	CSV    bool   `long:"csv" optional:"true" description:"Print the statement as CSV"`
+	Save   string `long:"save" optional:"true" description:"Save the signed statement as JSON to the given file"`
+}
+
+func (cmd *EarningsCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	ue := v1.UserEarnings{
+		UserID: cmd.UserID,
+		Year:   cmd.Args.Year,
+	}
+
+	var uer v1.UserEarningsReply
+	err = Ctx.Get(v1.RouteUserEarnings, ue, &uer)
+	if err != nil {
+		return err
+	}
+
+	err = verifyEarningsStatement(&uer)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Save != "" {
+		data, err := json.MarshalIndent(uer, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(cmd.Save, data, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.JSONOutput {
+		return nil
+	}
+
+	if cmd.CSV {
+		return writeEarningsStatementCSV(&uer.Statement)
+	}
+
+	printEarningsStatement(&uer.Statement)
+	if cmd.Save != "" {
+		fmt.Printf("\nThe signed statement has been saved to %v\n", cmd.Save)
+	}
+	return nil
+}
+
+type VerifyEarningsCmd struct {
+	Args struct {
+		Filename string `positional-arg-name:"filename"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *VerifyEarningsCmd) Execute(args []string) error {
+	data, err := ioutil.ReadFile(cmd.Args.Filename)
+	if err != nil {
+		return err
+	}
+
+	var uer v1.UserEarningsReply
+	err = json.Unmarshal(data, &uer)
+	if err != nil {
+		return err
+	}
+
+	err = verifyEarningsStatement(&uer)
+	if err != nil {
+		return err
+	}
+
+	// The statement can be verified offline; when the server is reachable,
+	// the signing key is compared with the server's as well.
+	err = InitialVersionRequest()
+	if err == nil && config.ServerSigningPublicKey != uer.PublicKey {
+		return fmt.Errorf("the statement was not signed by the key of %v",
+			config.Host)
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("The statement was signed by %v\n", uer.PublicKey)
+		printEarningsStatement(&uer.Statement)
+	}
+	return nil
+}
+
+// verifyEarningsStatement verifies the signature of an earnings statement.
+func verifyEarningsStatement(uer *v1.UserEarningsReply) error {
+	pk, err := hex.DecodeString(uer.PublicKey)
+	if err != nil {
+		return err
+	}
+	pi, err := identity.PublicIdentityFromBytes(pk)
+	if err != nil {
+		return err
+	}
+
+	sig, err := identity.SignatureFromString(uer.Signature)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(uer.Statement)
+	if err != nil {
+		return err
+	}
+	if !pi.VerifyMessage(data, *sig) {
+		return fmt.Errorf("the earnings statement signature is invalid")
+	}
+
+	return nil
+}
+
+func printEarningsStatement(statement *v1.EarningsStatement) {
+	fmt.Printf("Earnings statement for %v (user %v), paid in %v\n",
+		statement.Username, statement.UserID, statement.Year)
+	fmt.Printf("Created at: %v\n", time.Unix(statement.Timestamp, 0).String())
+	if len(statement.Invoices) == 0 {
+		fmt.Printf("No invoices were paid\n")
+		return
+	}
+
+	for _, invoice := range statement.Invoices {
+		date := time.Date(int(invoice.Year), time.Month(invoice.Month),
+			1, 0, 0, 0, 0, time.UTC)
+		fmt.Printf("---------------------------\n")
+		fmt.Printf("       Token: %v\n", invoice.Token)
+		fmt.Printf("         For: %v\n", date.Format("January 2006"))
+		fmt.Printf("        Paid: %v\n",
+			time.Unix(invoice.DatePaid, 0).UTC().Format("2006-01-02"))
+		fmt.Printf("  Total cost: $%v\n", invoice.TotalCostUSD)
+		fmt.Printf("         DCR: %.8f\n", invoice.TotalCostDCR)
+		fmt.Printf("        Rate: $%.2f / DCR\n", invoice.USDDCRRate)
+		for _, txID := range invoice.TxIDs {
+			fmt.Printf("        TxID: %v\n", txID)
+		}
+	}
+	fmt.Printf("---------------------------\n")
+	fmt.Printf("       Total: $%v, %.8f DCR\n", statement.TotalCostUSD,
+		statement.TotalCostDCR)
+}
+
+func writeEarningsStatementCSV(statement *v1.EarningsStatement) error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{"token", "month", "datepaid", "usd", "dcr",
+		"usddcrrate", "txids"})
+	if err != nil {
+		return err
+	}
+
+	for _, invoice := range statement.Invoices {
+		date := time.Date(int(invoice.Year), time.Month(invoice.Month),
+			1, 0, 0, 0, 0, time.UTC)
+		err = w.Write([]string{
+			invoice.Token,
+			date.Format("2006-01"),
+			time.Unix(invoice.DatePaid, 0).UTC().Format("2006-01-02"),
+			strconv.FormatUint(invoice.TotalCostUSD, 10),
+			strconv.FormatFloat(invoice.TotalCostDCR, 'f', 8, 64),
+			strconv.FormatFloat(invoice.USDDCRRate, 'f', 2, 64),
+			strings.Join(invoice.TxIDs, " "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type EditContractCmd struct {
+	Args struct {
+		User   string `positional-arg-name:"user"`
+		Reason string `positional-arg-name:"reason"`
+	} `positional-args:"true" required:"true"`
+	StartDate     *string  `long:"startdate" optional:"true" description:"First day of the contract, in YYYY-MM-DD format, or none"`
+	EndDate       *string  `long:"enddate" optional:"true" description:"Last day of the contract, in YYYY-MM-DD format, or none"`
+	Domain        *string  `long:"domain" optional:"true" description:"Domain of work"`
+	HourlyRateCap *uint64  `long:"ratecap" optional:"true" description:"Maximum hourly rate in USD, or 0 for none"`
+	Documents     []string `long:"document" optional:"true" description:"Reference to a contract document; replaces all of the existing references, can be repeated"`
+	NoDocuments   bool     `long:"nodocuments" optional:"true" description:"Remove all contract document references"`
+}
+
+var (
+	ContractDomainCommands = map[string]v1.ContractDomainT{
+		"none":          v1.ContractDomainInvalid,
+		"development":   v1.ContractDomainDevelopment,
+		"design":        v1.ContractDomainDesign,
+		"marketing":     v1.ContractDomainMarketing,
+		"documentation": v1.ContractDomainDocumentation,
+		"research":      v1.ContractDomainResearch,
+		"community":     v1.ContractDomainCommunity,
+		"operations":    v1.ContractDomainOperations,
+	}
+)
+
+// parseContractDate converts a date in YYYY-MM-DD format, or none, into a
+// Unix timestamp.
+func parseContractDate(dateStr string) (int64, error) {
+	if dateStr == "none" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %v, the format must be "+
+			"YYYY-MM-DD", dateStr)
+	}
+	return t.Unix(), nil
+}
+
+func (cmd *EditContractCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	// The whole contract is replaced, so start from the current one and
+	// only change the terms which were provided.
+	ud := v1.UserDetails{
+		UserID:   cmd.Args.User,
+		Email:    cmd.Args.User,
+		Username: cmd.Args.User,
+	}
+
+	var udr v1.UserDetailsReply
+	err = Ctx.Get(v1.RouteUserDetails, ud, &udr)
+	if err != nil {
+		return err
+	}
+	if udr.User.ID == "" {
+		return fmt.Errorf("user %v not found", cmd.Args.User)
+	}
+
+	contract := udr.User.Contract
+	if cmd.StartDate != nil {
+		contract.StartDate, err = parseContractDate(*cmd.StartDate)
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.EndDate != nil {
+		contract.EndDate, err = parseContractDate(*cmd.EndDate)
+		if err != nil {
+			return err
+		}
+	}
+	if cmd.Domain != nil {
+		domain, ok := ContractDomainCommands[*cmd.Domain]
+		if !ok {
+			return fmt.Errorf("%v is an invalid contract domain", *cmd.Domain)
+		}
+		contract.Domain = domain
+	}
+	if cmd.HourlyRateCap != nil {
+		contract.HourlyRateCap = *cmd.HourlyRateCap
+	}
+	if cmd.NoDocuments {
+		contract.Documents = nil
+	} else if len(cmd.Documents) > 0 {
+		contract.Documents = cmd.Documents
+	}
+
+	euc := v1.EditUserContract{
+		UserID:   udr.User.ID,
+		Contract: contract,
+		Reason:   cmd.Args.Reason,
+	}
+
+	var eucr v1.EditUserContractReply
+	return Ctx.Post(v1.RouteEditUserContract, euc, &eucr)
+}
@@ -25,6 +25,41 @@ type EditInvoiceCmd struct {
 		Token           string `positional-arg-name:"token"`
 		InvoiceFilename string `positional-arg-name:"invoice"`
 	} `positional-args:"true" optional:"true"`
+	Attachments       []string `long:"attachment" optional:"true" description:"Filepath to a receipt or other supporting file, replacing an existing attachment with the same name; can be repeated"`
+	DeleteAttachments []string `long:"deleteattachment" optional:"true" description:"Name of an existing attachment to remove from the invoice; can be repeated"`
+}
+
+// mergeInvoiceAttachments returns the invoice's existing attachments, except
+// for the deleted ones and the ones replaced by a new attachment with the
+// same name, followed by the new attachments. The server replaces all of an
+// invoice's attachments with the ones sent when it's edited.
+func mergeInvoiceAttachments(existing, added []v1.File, deleted []string) ([]v1.File, error) {
+	names := make(map[string]bool, len(added)+len(deleted))
+	for _, attachment := range added {
+		names[attachment.Name] = true
+	}
+	toDelete := make(map[string]bool, len(deleted))
+	for _, name := range deleted {
+		toDelete[name] = true
+	}
+
+	attachments := make([]v1.File, 0, len(existing)+len(added))
+	for _, attachment := range existing {
+		if toDelete[attachment.Name] {
+			delete(toDelete, attachment.Name)
+			continue
+		}
+		if names[attachment.Name] {
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+	for name := range toDelete {
+		return nil, fmt.Errorf("the invoice has no attachment named %v",
+			name)
+	}
+
+	return append(attachments, added...), nil
 }
 
 func (cmd *EditInvoiceCmd) Execute(args []string) error {
@@ -56,10 +91,35 @@ func (cmd *EditInvoiceCmd) Execute(args []string) error {
 		return err
 	}
 
+	added, err := readInvoiceAttachments(cmd.Attachments)
+	if err != nil {
+		return err
+	}
+
+	// Fetch the invoice to keep the attachments which aren't replaced or
+	// deleted.
+	var idr v1.InvoiceDetailsReply
+	err = Ctx.Get(v1.RouteInvoiceDetails, v1.InvoiceDetails{
+		Token: token,
+	}, &idr)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := mergeInvoiceAttachments(idr.Invoice.Attachments,
+		added, cmd.DeleteAttachments)
+	if err != nil {
+		return err
+	}
+
 	h := sha256.New()
 	h.Write(payload)
 	digest := hex.EncodeToString(h.Sum(nil))
-	signature := id.SignMessage([]byte(digest))
+	merkleRoot, err := invoiceMerkleRoot(digest, attachments)
+	if err != nil {
+		return err
+	}
+	signature := id.SignMessage([]byte(merkleRoot))
 
 	ei := v1.EditInvoice{
 		Token: token,
@@ -67,8 +127,9 @@ func (cmd *EditInvoiceCmd) Execute(args []string) error {
 			Digest:  digest,
 			Payload: base64.StdEncoding.EncodeToString(payload),
 		},
-		PublicKey: hex.EncodeToString(id.Public.Key[:]),
-		Signature: hex.EncodeToString(signature[:]),
+		Attachments: attachments,
+		PublicKey:   hex.EncodeToString(id.Public.Key[:]),
+		Signature:   hex.EncodeToString(signature[:]),
 	}
 
 	var eir v1.EditInvoiceReply
@@ -77,9 +138,10 @@ func (cmd *EditInvoiceCmd) Execute(args []string) error {
 		return err
 	}
 
-	if eir.Invoice.CensorshipRecord.Merkle != digest {
-		return fmt.Errorf("Digest returned from server did not match client's"+
-			" digest: %v %v", digest, eir.Invoice.CensorshipRecord.Merkle)
+	if eir.Invoice.CensorshipRecord.Merkle != merkleRoot {
+		return fmt.Errorf("Merkle root returned from server did not match "+
+			"client's merkle root: %v %v", merkleRoot,
+			eir.Invoice.CensorshipRecord.Merkle)
 	}
 
 	// Store the revision record in case the submitter ever needs it.
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type EditWorkCmd struct {
+	Args struct {
+		Month    string `positional-arg-name:"month"`
+		Year     uint16 `positional-arg-name:"year"`
+		LineItem uint   `positional-arg-name:"lineitem"`
+	} `positional-args:"true" required:"true"`
+	Expense bool `long:"expense" optional:"true" description:"Replace the line item with an expense instead of labor"`
+}
+
+func (cmd *EditWorkCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	month, err = ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+	year = cmd.Args.Year
+
+	policy, err = fetchPolicy()
+	if err != nil {
+		return err
+	}
+
+	var invoiceValues []string
+	if cmd.Expense {
+		invoiceValues, err = promptForExpenseValues()
+	} else {
+		invoiceValues, err = promptForFieldValues()
+	}
+	if err != nil {
+		return err
+	}
+
+	edli := v1.EditDraftLineItem{
+		Month:    month,
+		Year:     year,
+		Index:    cmd.Args.LineItem,
+		LineItem: invoiceValues,
+	}
+
+	var edlir v1.EditDraftLineItemReply
+	err = Ctx.Post(v1.RouteEditDraftLineItem, edli, &edlir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Line item %v updated successfully.\n", cmd.Args.LineItem)
+	}
+	return nil
+}
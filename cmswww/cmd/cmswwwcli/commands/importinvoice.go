@@ -0,0 +1,626 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type ImportInvoiceCmd struct {
+	Args struct {
+		Filename string `positional-arg-name:"filename"`
+		Month    string `positional-arg-name:"month"`
+		Year     uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" required:"true"`
+	Format   string  `long:"format" required:"true" description:"Format of the export: toggl, clockify, harvest or json"`
+	Rules    string  `long:"rules" optional:"true" description:"JSON file with the rules which map time entries to invoice line items"`
+	Type     string  `long:"type" optional:"true" description:"Type of work of time entries which don't match a rule"`
+	Rate     float64 `long:"rate" optional:"true" description:"Hourly rate in USD, overriding the rate in the rules file"`
+	Rounding string  `long:"rounding" optional:"true" description:"How hours are rounded, overriding the rules file: up, down or nearest"`
+	Out      string  `long:"out" optional:"true" description:"Filepath to write the invoice CSV to"`
+	DropZero bool    `long:"dropzero" optional:"true" description:"Leave out line items whose hours round to 0 instead of failing"`
+}
+
+// timeEntry is a single entry of a time-tracking export.
+type timeEntry struct {
+	Date        string   `json:"date"` // YYYY-MM-DD
+	Client      string   `json:"client"`
+	Project     string   `json:"project"`
+	Task        string   `json:"task"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Hours       float64  `json:"hours"`
+}
+
+// timeExportFormat describes the columns of a CSV time-tracking export. The
+// column names are matched case-insensitively, and the first one which is
+// present in the export is used.
+type timeExportFormat struct {
+	date        []string
+	client      []string
+	project     []string
+	task        []string
+	description []string
+	tags        []string
+	duration    []string
+	dateLayouts []string
+}
+
+// importRule maps the time entries which match all of its patterns to a
+// type of work, subtype and proposal. The patterns are case-insensitive
+// regular expressions, and an empty pattern matches any entry.
+type importRule struct {
+	Client      string `json:"client"`
+	Project     string `json:"project"`
+	Task        string `json:"task"`
+	Description string `json:"description"`
+	Tag         string `json:"tag"`
+
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	Proposal string `json:"proposal"`
+
+	patterns map[string]*regexp.Regexp
+}
+
+// importRounding determines how the hours of time entries and line items
+// are rounded.
+type importRounding struct {
+	Mode         string `json:"mode"`         // up, down or nearest
+	EntryMinutes uint   `json:"entryminutes"` // Increment each entry is rounded to first, if any
+}
+
+// importRules is the content of a rules file.
+type importRules struct {
+	Rate        float64        `json:"rate"`        // Hourly rate in USD
+	DefaultType string         `json:"defaulttype"` // Type of work of entries which don't match a rule
+	Rounding    importRounding `json:"rounding"`
+	Rules       []importRule   `json:"rules"`
+}
+
+// importedLineItem is a line item made up of the time entries with the same
+// type, subtype, proposal and description.
+type importedLineItem struct {
+	typeOfWork  string
+	subtype     string
+	description string
+	proposal    string
+	hours       float64
+}
+
+var (
+	timeExportFormats = map[string]timeExportFormat{
+		"toggl": {
+			date:        []string{"Start date"},
+			client:      []string{"Client"},
+			project:     []string{"Project"},
+			task:        []string{"Task"},
+			description: []string{"Description"},
+			tags:        []string{"Tags"},
+			duration:    []string{"Duration"},
+			dateLayouts: []string{"2006-01-02"},
+		},
+		"clockify": {
+			date:        []string{"Start Date"},
+			client:      []string{"Client"},
+			project:     []string{"Project"},
+			task:        []string{"Task"},
+			description: []string{"Description"},
+			tags:        []string{"Tags"},
+			duration:    []string{"Duration (decimal)", "Duration (h)"},
+			dateLayouts: []string{"01/02/2006", "2006-01-02", "02.01.2006"},
+		},
+		"harvest": {
+			date:        []string{"Date", "Spent Date"},
+			client:      []string{"Client"},
+			project:     []string{"Project"},
+			task:        []string{"Task"},
+			description: []string{"Notes"},
+			tags:        []string{},
+			duration:    []string{"Hours"},
+			dateLayouts: []string{"2006-01-02", "01/02/2006"},
+		},
+	}
+
+	importRoundingModes = map[string]func(float64) float64{
+		"up":      math.Ceil,
+		"down":    math.Floor,
+		"nearest": math.Round,
+	}
+)
+
+// parseExportDuration parses a duration given in decimal hours or in the
+// H:MM[:SS] format, and returns it in hours.
+func parseExportDuration(durationStr string) (float64, error) {
+	parts := strings.Split(durationStr, ":")
+	if len(parts) == 1 {
+		return strconv.ParseFloat(durationStr, 64)
+	}
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration %v", durationStr)
+	}
+
+	var hours float64
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %v", durationStr)
+		}
+		hours += float64(n) / math.Pow(60, float64(i))
+	}
+	return hours, nil
+}
+
+// roundHours rounds the hours to a multiple of the increment. Durations
+// are first cut to a microhour so that float errors, such as 0.1+0.2, don't
+// change which way they're rounded.
+func roundHours(round func(float64) float64, hours, increment float64) float64 {
+	units := math.Round(hours/increment*1e6) / 1e6
+	return round(units) * increment
+}
+
+// readCSVTimeEntries reads the time entries of a CSV export in the given
+// format, converting their dates to the YYYY-MM-DD format.
+func readCSVTimeEntries(data []byte, format timeExportFormat) ([]timeEntry, error) {
+	csvReader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data,
+		[]byte("\xef\xbb\xbf"))))
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the export is empty")
+	}
+
+	// Find the columns by the names in the header.
+	header := records[0]
+	column := func(names []string) int {
+		for _, name := range names {
+			for idx, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return idx
+				}
+			}
+		}
+		return -1
+	}
+	value := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	dateIdx := column(format.date)
+	durationIdx := column(format.duration)
+	if dateIdx < 0 || durationIdx < 0 {
+		return nil, fmt.Errorf("the export must have the %v and %v columns",
+			format.date[0], format.duration[0])
+	}
+	clientIdx := column(format.client)
+	projectIdx := column(format.project)
+	taskIdx := column(format.task)
+	descriptionIdx := column(format.description)
+	tagsIdx := column(format.tags)
+
+	entries := make([]timeEntry, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+
+		dateStr := value(record, dateIdx)
+		var date time.Time
+		for _, layout := range format.dateLayouts {
+			date, err = time.Parse(layout, dateStr)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid date %v", line, dateStr)
+		}
+
+		hours, err := parseExportDuration(value(record, durationIdx))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+
+		entry := timeEntry{
+			Date:        date.Format("2006-01-02"),
+			Client:      value(record, clientIdx),
+			Project:     value(record, projectIdx),
+			Task:        value(record, taskIdx),
+			Description: value(record, descriptionIdx),
+			Hours:       hours,
+		}
+		for _, tag := range strings.Split(value(record, tagsIdx), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// readTimeEntries reads the time entries of an export in the given format.
+func readTimeEntries(filename, formatStr string) ([]timeEntry, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	formatStr = strings.ToLower(formatStr)
+	if formatStr == "json" {
+		var entries []timeEntry
+		err = json.Unmarshal(data, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse export %v: %v",
+				filename, err)
+		}
+		return entries, nil
+	}
+
+	format, ok := timeExportFormats[formatStr]
+	if !ok {
+		return nil, fmt.Errorf("Invalid format: %v", formatStr)
+	}
+	entries, err := readCSVTimeEntries(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse export %v: %v", filename,
+			err)
+	}
+	return entries, nil
+}
+
+// readImportRules reads the rules file, if any, and compiles the patterns
+// of its rules.
+func readImportRules(filename string) (*importRules, error) {
+	rules := importRules{
+		Rounding: importRounding{
+			Mode: "nearest",
+		},
+	}
+	if filename == "" {
+		return &rules, nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse rules file %v: %v", filename,
+			err)
+	}
+
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if rule.Type == "" {
+			return nil, fmt.Errorf("Rule %v has no type of work", i+1)
+		}
+
+		rule.patterns = make(map[string]*regexp.Regexp)
+		for name, pattern := range map[string]string{
+			"client":      rule.Client,
+			"project":     rule.Project,
+			"task":        rule.Task,
+			"description": rule.Description,
+			"tag":         rule.Tag,
+		} {
+			if pattern == "" {
+				continue
+			}
+			rule.patterns[name], err = regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Rule %v has an invalid %v "+
+					"pattern: %v", i+1, name, err)
+			}
+		}
+	}
+
+	return &rules, nil
+}
+
+// matches returns whether the time entry matches all of the rule's
+// patterns.
+func (r *importRule) matches(entry *timeEntry) bool {
+	for name, pattern := range r.patterns {
+		switch name {
+		case "client":
+			if !pattern.MatchString(entry.Client) {
+				return false
+			}
+		case "project":
+			if !pattern.MatchString(entry.Project) {
+				return false
+			}
+		case "task":
+			if !pattern.MatchString(entry.Task) {
+				return false
+			}
+		case "description":
+			if !pattern.MatchString(entry.Description) {
+				return false
+			}
+		case "tag":
+			var matched bool
+			for _, tag := range entry.Tags {
+				if pattern.MatchString(tag) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// createImportedLineItems maps the time entries of the month to line items
+// with the first rule each entry matches, merging the entries which end up
+// with the same type, subtype, proposal and description. It returns the line
+// items along with the number of entries outside of the month.
+func createImportedLineItems(
+	entries []timeEntry,
+	rules *importRules,
+	month, year uint16,
+) ([]*importedLineItem, int, error) {
+	round := importRoundingModes[rules.Rounding.Mode]
+	monthStr := config.GetInvoiceMonthStr(month, year)
+
+	lineItems := make([]*importedLineItem, 0)
+	lineItemsByKey := make(map[string]*importedLineItem)
+	var skipped int
+	for i := range entries {
+		entry := &entries[i]
+		if !strings.HasPrefix(entry.Date, monthStr) {
+			skipped++
+			continue
+		}
+
+		lineItem := importedLineItem{
+			typeOfWork:  rules.DefaultType,
+			description: entry.Description,
+		}
+		if lineItem.description == "" {
+			lineItem.description = entry.Task
+		}
+		if lineItem.description == "" {
+			lineItem.description = entry.Project
+		}
+		for _, rule := range rules.Rules {
+			if rule.matches(entry) {
+				lineItem.typeOfWork = rule.Type
+				lineItem.subtype = rule.Subtype
+				lineItem.proposal = rule.Proposal
+				break
+			}
+		}
+		if lineItem.typeOfWork == "" {
+			return nil, 0, fmt.Errorf("The entry %q on %v doesn't match a "+
+				"rule; please add a rule for it or pass --type",
+				lineItem.description, entry.Date)
+		}
+		if lineItem.description == "" {
+			return nil, 0, fmt.Errorf("The entry on %v has no description, "+
+				"task or project", entry.Date)
+		}
+
+		// Entries are rounded to the increment before they're merged, like
+		// time-tracking tools do.
+		hours := entry.Hours
+		if rules.Rounding.EntryMinutes != 0 {
+			hours = roundHours(round, hours,
+				float64(rules.Rounding.EntryMinutes)/60)
+		}
+
+		key := strings.Join([]string{lineItem.typeOfWork, lineItem.subtype,
+			lineItem.proposal, lineItem.description}, "\x00")
+		existing, ok := lineItemsByKey[key]
+		if !ok {
+			existing = &lineItem
+			lineItemsByKey[key] = existing
+			lineItems = append(lineItems, existing)
+		}
+		existing.hours += hours
+	}
+
+	sort.SliceStable(lineItems, func(i, j int) bool {
+		return lineItems[i].typeOfWork < lineItems[j].typeOfWork
+	})
+	return lineItems, skipped, nil
+}
+
+// zeroHourLineItems returns the line items whose hours round to 0, which
+// aren't billed.
+func zeroHourLineItems(lineItems []*importedLineItem, rules *importRules) []*importedLineItem {
+	round := importRoundingModes[rules.Rounding.Mode]
+
+	zero := make([]*importedLineItem, 0)
+	for _, lineItem := range lineItems {
+		if roundHours(round, lineItem.hours, 1) == 0 {
+			zero = append(zero, lineItem)
+		}
+	}
+	return zero
+}
+
+// formatZeroHourLineItems returns a line for each of the line items whose
+// hours round to 0, with their unrounded hours.
+func formatZeroHourLineItems(lineItems []*importedLineItem) string {
+	var b strings.Builder
+	for _, lineItem := range lineItems {
+		fmt.Fprintf(&b, "  %v: %v (%.2f hours)\n", lineItem.typeOfWork,
+			lineItem.description, lineItem.hours)
+	}
+	return b.String()
+}
+
+// writeImportedInvoice writes the line items as an invoice file in the
+// format of the invoice policy, which submitinvoice can sign and submit.
+// Line items are billed at the hourly rate for their rounded hours, and
+// those which round to no hours are left out. It returns the number of line
+// items written.
+func writeImportedInvoice(
+	filename string,
+	lineItems []*importedLineItem,
+	rules *importRules,
+	month, year uint16,
+) (int, error) {
+	round := importRoundingModes[rules.Rounding.Mode]
+
+	var buf bytes.Buffer
+	comment := string(policy.Invoice.CommentChar)
+	fmt.Fprintf(&buf, "%v %v\n", comment,
+		config.GetInvoiceMonthStr(month, year))
+
+	fieldNames := make([]string, 0, len(policy.Invoice.Fields))
+	for _, field := range policy.Invoice.Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	fmt.Fprintf(&buf, "%v %v\n", comment, strings.Join(fieldNames, ", "))
+
+	// The values are in the order of the invoice fields: type, subtype,
+	// description, proposal, hours and total cost.
+	records := make([][]string, 0, len(lineItems))
+	for _, lineItem := range lineItems {
+		hours := uint64(roundHours(round, lineItem.hours, 1))
+		if hours == 0 {
+			continue
+		}
+		totalCost := uint64(math.Round(float64(hours) * rules.Rate))
+		records = append(records, []string{lineItem.typeOfWork,
+			lineItem.subtype, lineItem.description, lineItem.proposal,
+			strconv.FormatUint(hours, 10), strconv.FormatUint(totalCost, 10)})
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("No work was found for %v",
+			config.GetInvoiceMonthStr(month, year))
+	}
+
+	csvWriter := csv.NewWriter(&buf)
+	csvWriter.Comma = policy.Invoice.FieldDelimiterChar
+	err := csvWriter.WriteAll(records)
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return 0, err
+	}
+	return len(records), ioutil.WriteFile(filename, buf.Bytes(), 0600)
+}
+
+func (cmd *ImportInvoiceCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	month, err = ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+	year = cmd.Args.Year
+
+	rules, err := readImportRules(cmd.Rules)
+	if err != nil {
+		return err
+	}
+	if cmd.Type != "" {
+		rules.DefaultType = cmd.Type
+	}
+	if cmd.Rate != 0 {
+		rules.Rate = cmd.Rate
+	}
+	if cmd.Rounding != "" {
+		rules.Rounding.Mode = strings.ToLower(cmd.Rounding)
+	}
+	if rules.Rate <= 0 {
+		return fmt.Errorf("You must supply your hourly rate, either with " +
+			"--rate or in the rules file.")
+	}
+	if _, ok := importRoundingModes[rules.Rounding.Mode]; !ok {
+		return fmt.Errorf("Invalid rounding: %v", rules.Rounding.Mode)
+	}
+
+	entries, err := readTimeEntries(cmd.Args.Filename, cmd.Format)
+	if err != nil {
+		return err
+	}
+
+	policy, err = fetchPolicy()
+	if err != nil {
+		return err
+	}
+
+	lineItems, skipped, err := createImportedLineItems(entries, rules,
+		month, year)
+	if err != nil {
+		return err
+	}
+
+	filename := cmd.Out
+	if filename == "" {
+		filename = filepath.Join(config.GetInvoiceDirectory(),
+			config.GetInvoiceMonthStr(month, year)+".csv")
+	}
+	if config.FileExists(filename) {
+		return fmt.Errorf("The file %v already exists. Please remove it or "+
+			"choose another file with --out.", filename)
+	}
+
+	// Line items which round to no hours are only left out when asked to,
+	// so that no work goes unbilled unnoticed.
+	zero := zeroHourLineItems(lineItems, rules)
+	if len(zero) != 0 && !cmd.DropZero {
+		return fmt.Errorf("The following line items round to 0 hours:\n"+
+			"%vChange the rounding, or run the command again with "+
+			"--dropzero to leave them out.", formatZeroHourLineItems(zero))
+	}
+
+	written, err := writeImportedInvoice(filename, lineItems, rules, month,
+		year)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Imported %v line items into %v.\n", written, filename)
+		if len(zero) != 0 {
+			fmt.Printf("Left out %v line items which round to 0 hours:\n%v",
+				len(zero), formatZeroHourLineItems(zero))
+		}
+		if skipped != 0 {
+			fmt.Printf("Skipped %v entries outside of %v.\n", skipped,
+				config.GetInvoiceMonthStr(month, year))
+		}
+		fmt.Printf("Review the file, then submit it with: cmswwwcli "+
+			"submitinvoice --invoice=%v\n", filename)
+	}
+
+	return nil
+}
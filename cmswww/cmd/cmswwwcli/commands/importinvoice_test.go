@@ -0,0 +1,294 @@
+package commands
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseExportDuration(t *testing.T) {
+	tests := []struct {
+		duration string
+		hours    float64
+		ok       bool
+	}{
+		{"1.5", 1.5, true},
+		{"0.25", 0.25, true},
+		{"2", 2, true},
+		{"1:30", 1.5, true},
+		{"0:15", 0.25, true},
+		{"1:30:00", 1.5, true},
+		{"0:00:36", 0.01, true},
+		{"12:45:36", 12.76, true},
+		{"", 0, false},
+		{"1h", 0, false},
+		{"1:xx", 0, false},
+		{"1:-30", 0, false},
+		{"1:00:00:00", 0, false},
+	}
+
+	for _, test := range tests {
+		hours, err := parseExportDuration(test.duration)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, want ok %v", test.duration, err,
+				test.ok)
+			continue
+		}
+		if test.ok && math.Abs(hours-test.hours) > 1e-9 {
+			t.Errorf("%q: got %v hours, want %v", test.duration, hours,
+				test.hours)
+		}
+	}
+}
+
+func TestRoundHours(t *testing.T) {
+	tests := []struct {
+		mode      string
+		hours     float64
+		increment float64
+		rounded   float64
+	}{
+		{"nearest", 1.4, 1, 1},
+		{"nearest", 1.5, 1, 2},
+		{"nearest", 0.4, 1, 0},
+		{"up", 1.01, 1, 2},
+		{"up", 2, 1, 2},
+		{"down", 1.99, 1, 1},
+		{"down", 0.5, 1, 0},
+		{"nearest", 0.1 + 0.2, 0.3, 0.3},
+		{"up", 0.1 + 0.2, 0.3, 0.3},
+		{"down", 0.1 + 0.2, 0.3, 0.3},
+		{"up", 7.0 / 60, 0.25, 0.25},
+		{"nearest", 22.0 / 60, 0.25, 0.25},
+		{"nearest", 23.0 / 60, 0.25, 0.5},
+		{"down", 44.0 / 60, 0.25, 0.5},
+	}
+
+	for _, test := range tests {
+		rounded := roundHours(importRoundingModes[test.mode], test.hours,
+			test.increment)
+		if math.Abs(rounded-test.rounded) > 1e-9 {
+			t.Errorf("%v %v to %v: got %v, want %v", test.mode, test.hours,
+				test.increment, rounded, test.rounded)
+		}
+	}
+}
+
+func TestReadCSVTimeEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		entries []timeEntry
+		ok      bool
+	}{
+		{
+			name:   "toggl with BOM",
+			format: "toggl",
+			data: "\xef\xbb\xbfUser,Client,Project,Task,Description," +
+				"Start date,Duration,Tags\n" +
+				"me,Decred,Politeia,,Review PR,2018-12-03,01:30:00," +
+				"\"review, dev\"\n",
+			entries: []timeEntry{{
+				Date:        "2018-12-03",
+				Client:      "Decred",
+				Project:     "Politeia",
+				Description: "Review PR",
+				Tags:        []string{"review", "dev"},
+				Hours:       1.5,
+			}},
+			ok: true,
+		},
+		{
+			name:   "clockify decimal duration and alternate date layout",
+			format: "clockify",
+			data: "Project,Client,Description,Task,Tags,Start Date," +
+				"Duration (decimal)\n" +
+				"Politeia,Decred,Fix bug,,,12/04/2018,0.75\n",
+			entries: []timeEntry{{
+				Date:        "2018-12-04",
+				Client:      "Decred",
+				Project:     "Politeia",
+				Description: "Fix bug",
+				Hours:       0.75,
+			}},
+			ok: true,
+		},
+		{
+			name:   "harvest with case-insensitive columns",
+			format: "harvest",
+			data: " spent date ,CLIENT,Project,Task,Notes,Hours\n" +
+				"2018-12-05,Decred,dcrd,Development,,2\n",
+			entries: []timeEntry{{
+				Date:    "2018-12-05",
+				Client:  "Decred",
+				Project: "dcrd",
+				Task:    "Development",
+				Hours:   2,
+			}},
+			ok: true,
+		},
+		{
+			name:   "empty export",
+			format: "toggl",
+			data:   "",
+		},
+		{
+			name:   "missing duration column",
+			format: "toggl",
+			data:   "Description,Start date\nReview,2018-12-03\n",
+		},
+		{
+			name:   "invalid date",
+			format: "toggl",
+			data:   "Description,Start date,Duration\nReview,03/12/2018,1:00\n",
+		},
+		{
+			name:   "invalid duration",
+			format: "toggl",
+			data:   "Description,Start date,Duration\nReview,2018-12-03,1h\n",
+		},
+	}
+
+	for _, test := range tests {
+		entries, err := readCSVTimeEntries([]byte(test.data),
+			timeExportFormats[test.format])
+		if (err == nil) != test.ok {
+			t.Errorf("%v: got error %v, want ok %v", test.name, err,
+				test.ok)
+			continue
+		}
+		if test.ok && !reflect.DeepEqual(entries, test.entries) {
+			t.Errorf("%v: got %+v, want %+v", test.name, entries,
+				test.entries)
+		}
+	}
+}
+
+// writeTestImportRules writes the rules to a temporary file and reads them
+// back with readImportRules.
+func writeTestImportRules(t *testing.T, rulesJSON string) *importRules {
+	f, err := ioutil.TempFile("", "importrules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(rulesJSON)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := readImportRules(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestCreateImportedLineItems(t *testing.T) {
+	rules := writeTestImportRules(t, `{
+		"rate": 40,
+		"defaulttype": "Development",
+		"rounding": {"mode": "up", "entryminutes": 15},
+		"rules": [
+			{"project": "^politeia$", "tag": "review", "type": "Review"},
+			{"project": "politeia", "type": "Development",
+			 "subtype": "Politeia", "proposal": "abc"},
+			{"description": "meeting", "type": "Other"}
+		]
+	}`)
+
+	entries := []timeEntry{
+		// Both of the first two rules match; the first one is used.
+		{Date: "2018-12-03", Project: "Politeia", Tags: []string{"Review"},
+			Description: "Review PR", Hours: 0.5},
+		{Date: "2018-12-04", Project: "politeiagui",
+			Description: "Fix bug", Hours: 1},
+		// Merged with the previous entry after rounding up to 15 minutes.
+		{Date: "2018-12-05", Project: "politeiagui",
+			Description: "Fix bug", Hours: 0.1},
+		{Date: "2018-12-06", Description: "Weekly MEETING", Hours: 1},
+		// The task is used when there's no description.
+		{Date: "2018-12-07", Project: "dcrd", Task: "Sync", Hours: 2},
+		// Outside of the month.
+		{Date: "2018-11-30", Project: "Politeia", Description: "Review PR",
+			Hours: 3},
+		{Date: "2019-01-01", Project: "Politeia", Description: "Review PR",
+			Hours: 3},
+	}
+
+	lineItems, skipped, err := createImportedLineItems(entries, rules, 12,
+		2018)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 {
+		t.Errorf("got %v skipped entries, want 2", skipped)
+	}
+
+	want := []importedLineItem{
+		{typeOfWork: "Development", subtype: "Politeia", proposal: "abc",
+			description: "Fix bug", hours: 1.25},
+		{typeOfWork: "Development", description: "Sync", hours: 2},
+		{typeOfWork: "Other", description: "Weekly MEETING", hours: 1},
+		{typeOfWork: "Review", description: "Review PR", hours: 0.5},
+	}
+	got := make([]importedLineItem, 0, len(lineItems))
+	for _, lineItem := range lineItems {
+		got = append(got, *lineItem)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got line items %+v, want %+v", got, want)
+	}
+
+	// Entries which match no rule need a default type.
+	rules.DefaultType = ""
+	_, _, err = createImportedLineItems(entries[4:5], rules, 12, 2018)
+	if err == nil {
+		t.Errorf("expected an error for an entry without a type")
+	}
+
+	// Entries need something to describe their line item.
+	rules.DefaultType = "Development"
+	_, _, err = createImportedLineItems([]timeEntry{{Date: "2018-12-03",
+		Hours: 1}}, rules, 12, 2018)
+	if err == nil {
+		t.Errorf("expected an error for an entry without a description")
+	}
+}
+
+func TestZeroHourLineItems(t *testing.T) {
+	lineItems := []*importedLineItem{
+		{description: "a", hours: 0.4},
+		{description: "b", hours: 0.5},
+		{description: "c", hours: 0},
+	}
+
+	tests := []struct {
+		mode string
+		zero []string
+	}{
+		{"nearest", []string{"a", "c"}},
+		{"down", []string{"a", "b", "c"}},
+		{"up", []string{"c"}},
+	}
+
+	for _, test := range tests {
+		rules := importRules{
+			Rounding: importRounding{
+				Mode: test.mode,
+			},
+		}
+		zero := make([]string, 0)
+		for _, lineItem := range zeroHourLineItems(lineItems, &rules) {
+			zero = append(zero, lineItem.description)
+		}
+		if !reflect.DeepEqual(zero, test.zero) {
+			t.Errorf("%v: got %v, want %v", test.mode, zero, test.zero)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type InvoiceCommentsCmd struct {
+	Args struct {
+		Token string `positional-arg-name:"token"`
+	} `positional-args:"true" required:"true"`
+}
+
+// printInvoiceComment prints a comment followed by its replies, indenting
+// each level of replies.
+func printInvoiceComment(
+	comment v1.InvoiceComment,
+	replies map[uint64][]v1.InvoiceComment,
+	depth int,
+) {
+	indent := strings.Repeat("    ", depth+1)
+	fmt.Printf("%v#%v %v at %v", indent, comment.CommentID, comment.Username,
+		time.Unix(comment.Timestamp, 0))
+	if comment.LineItem != 0 && depth == 0 {
+		fmt.Printf(" on line item %v", comment.LineItem)
+	}
+	fmt.Println()
+	for _, line := range strings.Split(comment.Comment, "\n") {
+		fmt.Printf("%v  %v\n", indent, line)
+	}
+
+	for _, reply := range replies[comment.CommentID] {
+		printInvoiceComment(reply, replies, depth+1)
+	}
+}
+
+func (cmd *InvoiceCommentsCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	ic := v1.InvoiceComments{
+		Token: cmd.Args.Token,
+	}
+
+	var icr v1.InvoiceCommentsReply
+	err = Ctx.Get(v1.RouteInvoiceComments, ic, &icr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Comments: ")
+		if len(icr.Comments) == 0 {
+			fmt.Printf("none\n")
+			return nil
+		}
+		fmt.Println()
+
+		// Group the replies by the comment they reply to; the comments
+		// are returned oldest first, so the replies stay in order.
+		replies := make(map[uint64][]v1.InvoiceComment)
+		for _, comment := range icr.Comments {
+			replies[comment.ParentID] = append(replies[comment.ParentID],
+				comment)
+		}
+		for _, comment := range replies[0] {
+			printInvoiceComment(comment, replies, 0)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
@@ -14,6 +15,41 @@ type InvoiceDetailsCmd struct {
 	} `positional-args:"true" optional:"true"`
 }
 
+// formatStatusTransitions returns the status changes which the workflow
+// allows for an invoice in the given status, as they're passed to the
+// setinvoicestatus and withdrawinvoice commands.
+func formatStatusTransitions(workflow v1.InvoiceWorkflow, status v1.InvoiceStatusT) string {
+	statusNames := make(map[v1.InvoiceStatusT]string, len(invoiceStatuses))
+	for s, name := range v1.InvoiceStatus {
+		statusNames[s] = name
+	}
+	for name, s := range invoiceStatuses {
+		statusNames[s] = name
+	}
+
+	transitions := make([]string, 0)
+	for _, t := range workflow.Transitions {
+		if t.From != status {
+			continue
+		}
+
+		by := "contractor"
+		if t.Role != 0 {
+			by = v1.UserRole[t.Role]
+		}
+		if t.ReasonRequired {
+			by += ", requires a reason"
+		}
+		transitions = append(transitions, fmt.Sprintf("%v (%v)",
+			statusNames[t.To], by))
+	}
+
+	if len(transitions) == 0 {
+		return "none"
+	}
+	return strings.Join(transitions, ", ")
+}
+
 func (cmd *InvoiceDetailsCmd) Execute(args []string) error {
 	err := InitialVersionRequest()
 	if err != nil {
@@ -38,6 +74,33 @@ func (cmd *InvoiceDetailsCmd) Execute(args []string) error {
 		fmt.Printf("    Submitted by: %v\n", idr.Invoice.Username)
 		fmt.Printf("              at: %v\n", time.Unix(idr.Invoice.Timestamp, 0))
 		fmt.Printf("             For: %v\n", date.Format("January 2006"))
+		if idr.Invoice.Late {
+			fmt.Printf("            Late: yes\n")
+		}
+		if idr.Invoice.Status == v1.InvoiceStatusAwaitingApproval {
+			fmt.Printf("       Approvals: %v of %v to mark as %v\n",
+				len(idr.Invoice.Approvals), idr.Invoice.ApprovalsRequired,
+				v1.InvoiceStatus[idr.Invoice.ApprovalFor])
+			for _, approval := range idr.Invoice.Approvals {
+				fmt.Printf("                  %v at %v\n", approval.PublicKey,
+					time.Unix(approval.Timestamp, 0))
+			}
+		}
+		if idr.Invoice.Status != v1.InvoiceStatusAwaitingApproval {
+			policy, err := fetchPolicy()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  Status changes: %v\n", formatStatusTransitions(
+				policy.Invoice.Workflow, idr.Invoice.Status))
+		}
+		for _, leadReview := range idr.Invoice.LeadReviews {
+			fmt.Printf("     Lead review: %v\n", formatLeadReview(leadReview))
+		}
+		for _, attachment := range idr.Invoice.Attachments {
+			fmt.Printf("      Attachment: %v (%v)\n", attachment.Name,
+				attachment.MIME)
+		}
 	}
 
 	return nil
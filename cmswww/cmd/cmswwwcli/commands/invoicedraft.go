@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type InvoiceDraftCmd struct {
+	Args struct {
+		Month string `positional-arg-name:"month"`
+		Year  uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" required:"true"`
+}
+
+// fetchInvoiceDraft returns the logged in user's invoice draft for a month.
+func fetchInvoiceDraft(month, year uint16) (*v1.InvoiceDraft, error) {
+	idd := v1.InvoiceDraftDetails{
+		Month: month,
+		Year:  year,
+	}
+
+	var iddr v1.InvoiceDraftDetailsReply
+	err := Ctx.Get(v1.RouteInvoiceDraft, idd, &iddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &iddr.Draft, nil
+}
+
+// printInvoiceDraft prints the line items of a draft, numbered from 1,
+// with the names of the invoice policy fields.
+func printInvoiceDraft(draft *v1.InvoiceDraft, policy *v1.PolicyReply) {
+	fmt.Printf("Draft: %v\n", config.GetInvoiceMonthStr(draft.Month,
+		draft.Year))
+	if len(draft.LineItems) == 0 {
+		fmt.Printf("Line items: none\n")
+		return
+	}
+	fmt.Printf("Last updated: %v\n", time.Unix(draft.Timestamp, 0))
+
+	for lineItemIdx, lineItem := range draft.LineItems {
+		fmt.Printf("  Line item %v\n", lineItemIdx+1)
+		for fieldIdx, field := range policy.Invoice.Fields {
+			if fieldIdx >= len(lineItem) || lineItem[fieldIdx] == "" {
+				continue
+			}
+			fmt.Printf("    %v: %v\n", field.Name, lineItem[fieldIdx])
+		}
+	}
+}
+
+func (cmd *InvoiceDraftCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	month, err := ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+
+	policy, err := fetchPolicy()
+	if err != nil {
+		return err
+	}
+
+	draft, err := fetchInvoiceDraft(month, cmd.Args.Year)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		printInvoiceDraft(draft, policy)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/politeia/politeiad/api/v1/identity"
+	"github.com/decred/politeia/util"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type InvoicePDFCmd struct {
+	Args struct {
+		Token string `positional-arg-name:"token"`
+	} `positional-args:"true" required:"true"`
+	Out string `long:"out" optional:"true" description:"Filepath to write the PDF to"`
+}
+
+// verifyInvoicePDF verifies the digest of an invoice document and the
+// server's signature of it, and returns the document.
+func verifyInvoicePDF(ipr *v1.InvoicePDFReply) ([]byte, error) {
+	pdf, err := base64.StdEncoding.DecodeString(ipr.File.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(util.Digest(pdf)) != ipr.File.Digest {
+		return nil, fmt.Errorf("the invoice document digest is invalid")
+	}
+
+	if ipr.PublicKey != config.ServerSigningPublicKey {
+		return nil, fmt.Errorf("the invoice document was not signed by "+
+			"the key of %v", config.Host)
+	}
+	pk, err := hex.DecodeString(ipr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	pi, err := identity.PublicIdentityFromBytes(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := identity.SignatureFromString(ipr.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if !pi.VerifyMessage([]byte(ipr.File.Digest), *sig) {
+		return nil, fmt.Errorf("the invoice document signature is invalid")
+	}
+
+	return pdf, nil
+}
+
+func (cmd *InvoicePDFCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	ip := v1.InvoicePDF{
+		Token: cmd.Args.Token,
+	}
+
+	var ipr v1.InvoicePDFReply
+	err = Ctx.Get(v1.RouteInvoicePDF, ip, &ipr)
+	if err != nil {
+		return err
+	}
+
+	pdf, err := verifyInvoicePDF(&ipr)
+	if err != nil {
+		return err
+	}
+
+	filename := cmd.Out
+	if filename == "" {
+		filename = ipr.File.Name
+	}
+	err = ioutil.WriteFile(filename, pdf, 0600)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("The invoice has been saved to %v, signed by %v with "+
+			"signature %v\n", filename, ipr.PublicKey, ipr.Signature)
+	}
+	return nil
+}
@@ -19,10 +19,15 @@ type InvoicesCmd struct {
 
 var (
 	invoiceStatuses = map[string]v1.InvoiceStatusT{
-		"unreviewed": v1.InvoiceStatusNotReviewed,
-		"rejected":   v1.InvoiceStatusRejected,
-		"approved":   v1.InvoiceStatusApproved,
-		"paid":       v1.InvoiceStatusPaid,
+		"unreviewed":       v1.InvoiceStatusNotReviewed,
+		"rejected":         v1.InvoiceStatusRejected,
+		"approved":         v1.InvoiceStatusApproved,
+		"paid":             v1.InvoiceStatusPaid,
+		"awaitingapproval": v1.InvoiceStatusAwaitingApproval,
+		"withdrawn":        v1.InvoiceStatusWithdrawn,
+		"onhold":           v1.InvoiceStatusOnHold,
+		"disputed":         v1.InvoiceStatusDisputed,
+		"readyforpayment":  v1.InvoiceStatusReadyForPayment,
 	}
 )
 
@@ -69,6 +74,9 @@ func (cmd *InvoicesCmd) Execute(args []string) error {
 				fmt.Printf("      Submitted by: %v\n", v.Username)
 				fmt.Printf("                at: %v\n",
 					time.Unix(v.Timestamp, 0).String())
+				if v.Late {
+					fmt.Printf("                    (late)\n")
+				}
 				if cmd.Status == "" {
 					fmt.Printf("            Status: %v\n",
 						v1.InvoiceStatus[v.Status])
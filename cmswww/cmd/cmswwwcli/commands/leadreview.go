@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type LeadReviewCmd struct {
+	Args struct {
+		Token  string `positional-arg-name:"token"`
+		Action string `positional-arg-name:"action"`
+		Reason string `positional-arg-name:"reason"`
+	} `positional-args:"true" optional:"true"`
+}
+
+var (
+	leadReviewActions = map[string]v1.LeadReviewActionT{
+		"endorse":        v1.LeadReviewEndorse,
+		"requestchanges": v1.LeadReviewRequestChanges,
+	}
+)
+
+// formatLeadReview returns a one line summary of a domain lead review.
+func formatLeadReview(leadReview v1.InvoiceLeadReview) string {
+	s := fmt.Sprintf("%v by %v (version %v)",
+		v1.LeadReviewAction[leadReview.Action], leadReview.Username,
+		leadReview.Version)
+	if leadReview.Reason != "" {
+		s += ": " + leadReview.Reason
+	}
+	return s
+}
+
+func (cmd *LeadReviewCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	id := config.LoggedInUserIdentity
+	if id == nil {
+		return ErrNotLoggedIn
+	}
+
+	action, ok := leadReviewActions[strings.ToLower(cmd.Args.Action)]
+	if !ok {
+		return fmt.Errorf("Invalid action: %v", cmd.Args.Action)
+	}
+
+	msg := cmd.Args.Token + strconv.FormatUint(uint64(action), 10)
+	signature := id.SignMessage([]byte(msg))
+
+	lri := v1.LeadReviewInvoice{
+		Token:     cmd.Args.Token,
+		Action:    action,
+		Reason:    cmd.Args.Reason,
+		PublicKey: hex.EncodeToString(id.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}
+
+	var lrir v1.LeadReviewInvoiceReply
+	err = Ctx.Post(v1.RouteLeadReviewInvoice, lri, &lrir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Review recorded: %v\n", v1.LeadReviewAction[action])
+	}
+
+	return nil
+}
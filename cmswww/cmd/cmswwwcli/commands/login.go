@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"bufio"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"strings"
 
 	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/client"
 	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
 )
 
@@ -11,7 +16,9 @@ type LoginCmd struct {
 	Args struct {
 		Email    string `positional-arg-name:"email"`
 		Password string `positional-arg-name:"password"`
-	} `positional-args:"true" required:"true"`
+	} `positional-args:"true" required:"1"`
+	TOTPCode string `long:"totp" optional:"true" description:"Two-factor authentication code or recovery code"`
+	Identity bool   `long:"identity" optional:"true" description:"Sign a login challenge with your identity instead of using a password"`
 }
 
 func (cmd *LoginCmd) Execute(args []string) error {
@@ -22,11 +29,48 @@ func (cmd *LoginCmd) Execute(args []string) error {
 
 	l := v1.Login{
 		Email:    cmd.Args.Email,
-		Password: DigestSHA3(cmd.Args.Password),
+		TOTPCode: cmd.TOTPCode,
+	}
+
+	if cmd.Identity {
+		// Sign a login challenge with the stored identity.
+		id, err := config.LoadUserIdentity(cmd.Args.Email)
+		if err != nil {
+			return fmt.Errorf("unable to load identity for %v: %v",
+				cmd.Args.Email, err)
+		}
+
+		var lcr v1.LoginChallengeReply
+		err = Ctx.Post(v1.RouteLoginChallenge,
+			v1.LoginChallenge{Email: cmd.Args.Email}, &lcr)
+		if err != nil {
+			return err
+		}
+
+		signature := id.SignMessage([]byte(v1.LoginChallengePrefix +
+			lcr.Challenge))
+		l.Challenge = lcr.Challenge
+		l.Signature = hex.EncodeToString(signature[:])
+	} else {
+		if cmd.Args.Password == "" {
+			return fmt.Errorf("a password is required unless --identity " +
+				"is used")
+		}
+		l.Password = DigestSHA3(cmd.Args.Password)
 	}
 
 	var lr v1.LoginReply
 	err = Ctx.Post(v1.RouteLogin, l, &lr)
+	if errReply, ok := err.(client.ErrorReply); ok && l.TOTPCode == "" &&
+		errReply.ErrorCode == v1.ErrorStatusTOTPCodeRequired {
+		// Prompt for the two-factor authentication code and try again.
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Print("Enter your two-factor authentication code: ")
+		l.TOTPCode, _ = reader.ReadString('\n')
+		l.TOTPCode = strings.TrimSpace(l.TOTPCode)
+
+		err = Ctx.Post(v1.RouteLogin, l, &lr)
+	}
 	if err != nil {
 		return err
 	}
@@ -34,6 +78,10 @@ func (cmd *LoginCmd) Execute(args []string) error {
 	config.LoggedInUser = &lr
 	if !config.JSONOutput {
 		fmt.Printf("You are now logged in as %v\n", lr.Username)
+		if lr.TOTPRequired {
+			fmt.Printf("WARNING: Admins and users with roles must enable " +
+				"two-factor authentication using the settotp command\n")
+		}
 	}
 
 	// Load identity, if available.
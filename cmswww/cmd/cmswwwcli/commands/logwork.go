@@ -2,7 +2,6 @@ package commands
 
 import (
 	"bufio"
-	"encoding/csv"
 	"fmt"
 	"os"
 	"strconv"
@@ -17,31 +16,15 @@ type LogWorkCmd struct {
 		Month string `positional-arg-name:"month"`
 		Year  uint16 `positional-arg-name:"year"`
 	} `positional-args:"true" required:"true"`
+	Expense bool `long:"expense" optional:"true" description:"Log an expense, such as travel or hosting, instead of labor"`
 }
 
 var (
-	file   *os.File
 	policy *v1.PolicyReply
 	month  uint16
 	year   uint16
 )
 
-func writeHeader() error {
-	_, err := fmt.Fprintf(file, "%v %v\n", string(policy.Invoice.CommentChar),
-		config.GetInvoiceMonthStr(month, year))
-	if err != nil {
-		return err
-	}
-
-	invoiceFieldNames := make([]string, 0, len(policy.Invoice.Fields))
-	for _, v := range policy.Invoice.Fields {
-		invoiceFieldNames = append(invoiceFieldNames, v.Name)
-	}
-	_, err = fmt.Fprintf(file, "%v %v\n", string(policy.Invoice.CommentChar),
-		strings.Join(invoiceFieldNames, ", "))
-	return err
-}
-
 func promptForFieldValues() ([]string, error) {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -90,17 +73,85 @@ func promptForFieldValues() ([]string, error) {
 	return invoiceFieldValues, nil
 }
 
-func writeValues(values []string) error {
-	csvWriter := csv.NewWriter(file)
-	csvWriter.Comma = policy.Invoice.FieldDelimiterChar
-	csvWriter.UseCRLF = false
+// promptForValue prompts for a single value until it's valid, or returns the
+// error if the output is JSON.
+func promptForValue(reader *bufio.Reader, prompt string, validate func(string) (string, error)) (string, error) {
+	for {
+		if !config.JSONOutput {
+			fmt.Printf("%v: ", prompt)
+		}
+		valueStr, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		value, err := validate(strings.TrimSpace(valueStr))
+		if err == nil {
+			return value, nil
+		}
+		if config.JSONOutput {
+			return "", err
+		}
+		fmt.Println(err)
+	}
+}
+
+// promptForExpenseValues prompts for the details of an expense and returns
+// them as the values of the invoice fields. An expense has the expense type
+// as its type of work, its category as its subtype, and no hours.
+func promptForExpenseValues() ([]string, error) {
+	reader := bufio.NewReader(os.Stdin)
 
-	err := csvWriter.Write(values)
+	categories := policy.Invoice.ExpenseCategories
+	category, err := promptForValue(reader, fmt.Sprintf("Category (%v)",
+		strings.Join(categories, ", ")), func(value string) (string, error) {
+		for _, category := range categories {
+			if strings.EqualFold(value, category) {
+				return category, nil
+			}
+		}
+		return "", fmt.Errorf("This field must be one of: %v",
+			strings.Join(categories, ", "))
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	csvWriter.Flush()
-	return csvWriter.Error()
+
+	description, err := promptForValue(reader, "Description",
+		func(value string) (string, error) {
+			if value == "" {
+				return "", fmt.Errorf("This field is required")
+			}
+			return value, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	proposal, err := promptForValue(reader, "Politeia proposal (optional)",
+		func(value string) (string, error) {
+			return value, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := promptForValue(reader, "Amount in USD",
+		func(value string) (string, error) {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || n == 0 {
+				return "", fmt.Errorf("This field must be a positive number")
+			}
+			return value, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// The values are in the order of the invoice fields: type, subtype,
+	// description, proposal, hours and total cost.
+	return []string{policy.Invoice.ExpenseType, category, description,
+		proposal, "0", amount}, nil
 }
 
 func (cmd *LogWorkCmd) Execute(args []string) error {
@@ -109,8 +160,7 @@ func (cmd *LogWorkCmd) Execute(args []string) error {
 		return err
 	}
 
-	id := config.LoggedInUserIdentity
-	if id == nil {
+	if config.LoggedInUser == nil {
 		return ErrNotLoggedIn
 	}
 
@@ -125,37 +175,32 @@ func (cmd *LogWorkCmd) Execute(args []string) error {
 		return err
 	}
 
-	invoiceFile, err := config.GetInvoiceFilename(month, year)
-	if err != nil {
-		return err
+	var invoiceValues []string
+	if cmd.Expense {
+		invoiceValues, err = promptForExpenseValues()
+	} else {
+		invoiceValues, err = promptForFieldValues()
 	}
-	invoiceAlreadyExists := config.FileExists(invoiceFile)
-
-	file, err = os.OpenFile(invoiceFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0640)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	if !invoiceAlreadyExists {
-		err = writeHeader()
-		if err != nil {
-			return err
-		}
-	}
 
-	invoiceValues, err := promptForFieldValues()
-	if err != nil {
-		return err
+	ndli := v1.NewDraftLineItem{
+		Month:    month,
+		Year:     year,
+		LineItem: invoiceValues,
 	}
 
-	err = writeValues(invoiceValues)
+	var ndlir v1.NewDraftLineItemReply
+	err = Ctx.Post(v1.RouteNewDraftLineItem, ndli, &ndlir)
 	if err != nil {
 		return err
 	}
 
 	if !config.JSONOutput {
-		fmt.Println("Work logged successfully.")
+		fmt.Printf("Work logged successfully as line item %v of the %v "+
+			"draft.\n", len(ndlir.Draft.LineItems),
+			config.GetInvoiceMonthStr(month, year))
 	}
 	return nil
 }
@@ -2,6 +2,9 @@ package commands
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 )
 
@@ -11,6 +14,8 @@ type ManageUserCmd struct {
 		Action string `positional-arg-name:"action"`
 		Reason string `positional-arg-name:"reason"`
 	} `positional-args:"true" required:"true"`
+	Roles   string `long:"roles" optional:"true" description:"Comma-separated list of roles for the setroles action, or none"`
+	EndDate string `long:"enddate" optional:"true" description:"Last day of work for the deactivate action, in YYYY-MM-DD format; defaults to today"`
 }
 
 var (
@@ -19,9 +24,39 @@ var (
 		"expireidentitytoken": v1.UserManageExpireUpdateIdentityVerification,
 		"lock":                v1.UserManageLock,
 		"unlock":              v1.UserManageUnlock,
+		"resettotp":           v1.UserManageResetTOTP,
+		"setroles":            v1.UserManageSetRoles,
+		"deactivate":          v1.UserManageDeactivate,
+		"reactivate":          v1.UserManageReactivate,
+	}
+
+	UserRoleCommands = map[string]v1.UserRoleT{
+		"reviewer":    v1.UserRoleReviewer,
+		"treasurer":   v1.UserRoleTreasurer,
+		"auditor":     v1.UserRoleAuditor,
+		"usermanager": v1.UserRoleUserManager,
+		"domainlead":  v1.UserRoleDomainLead,
 	}
 )
 
+// parseUserRoles converts a comma-separated list of roles into their sum.
+func parseUserRoles(rolesStr string) (v1.UserRoleT, error) {
+	var roles v1.UserRoleT
+	if rolesStr == "none" {
+		return roles, nil
+	}
+
+	for _, roleStr := range strings.Split(rolesStr, ",") {
+		role, ok := UserRoleCommands[strings.TrimSpace(roleStr)]
+		if !ok {
+			return 0, fmt.Errorf("%v is an invalid role", roleStr)
+		}
+		roles |= role
+	}
+
+	return roles, nil
+}
+
 func (cmd *ManageUserCmd) Execute(args []string) error {
 	err := InitialVersionRequest()
 	if err != nil {
@@ -33,12 +68,37 @@ func (cmd *ManageUserCmd) Execute(args []string) error {
 		return fmt.Errorf("%v is an invalid user manage action", cmd.Args.Action)
 	}
 
+	var roles v1.UserRoleT
+	if action == v1.UserManageSetRoles {
+		if cmd.Roles == "" {
+			return fmt.Errorf("the --roles flag is required for the " +
+				"setroles action")
+		}
+
+		roles, err = parseUserRoles(cmd.Roles)
+		if err != nil {
+			return err
+		}
+	}
+
+	var endDate int64
+	if action == v1.UserManageDeactivate && cmd.EndDate != "" {
+		t, err := time.Parse("2006-01-02", cmd.EndDate)
+		if err != nil {
+			return fmt.Errorf("invalid end date %v, the format must be "+
+				"YYYY-MM-DD", cmd.EndDate)
+		}
+		endDate = t.Unix()
+	}
+
 	mu := v1.ManageUser{
 		UserID:   cmd.Args.User,
 		Email:    cmd.Args.User,
 		Username: cmd.Args.User,
 		Action:   action,
 		Reason:   cmd.Args.Reason,
+		Roles:    roles,
+		EndDate:  endDate,
 	}
 
 	var mur v1.ManageUserReply
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type MissingInvoicesCmd struct {
+	Args struct {
+		Month string `positional-arg-name:"month"`
+		Year  uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *MissingInvoicesCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	month, err := ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+
+	mi := v1.MissingInvoices{
+		Month: month,
+		Year:  cmd.Args.Year,
+	}
+
+	var mir v1.MissingInvoicesReply
+	err = Ctx.Get(v1.RouteMissingInvoices, mi, &mir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Deadline: %v\n", time.Unix(mir.Deadline, 0))
+		fmt.Printf("Contractors missing invoices: ")
+		if len(mir.Users) == 0 {
+			fmt.Printf("none\n")
+		} else {
+			fmt.Println()
+			fmt.Printf("---------------------------\n")
+			for _, user := range mir.Users {
+				fmt.Printf("       ID: %v\n", user.ID)
+				fmt.Printf("    Email: %v\n", user.Email)
+				fmt.Printf(" Username: %v\n", user.Username)
+				fmt.Printf("---------------------------\n")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+const (
+	defaultAPITokenDays = 90
+)
+
+type NewAPITokenCmd struct {
+	Args struct {
+		Name   string   `positional-arg-name:"name"`
+		Scopes []string `positional-arg-name:"scope"`
+	} `positional-args:"true" required:"true"`
+	Days uint `long:"days" optional:"true" description:"Number of days until the token expires"`
+}
+
+var (
+	APITokenScopeCommands = map[string]v1.APITokenScopeT{
+		"read":          v1.APITokenScopeRead,
+		"submitinvoice": v1.APITokenScopeSubmitInvoice,
+		"adminreview":   v1.APITokenScopeAdminReview,
+		"adminpay":      v1.APITokenScopeAdminPay,
+		"adminusers":    v1.APITokenScopeAdminUsers,
+	}
+)
+
+func (cmd *NewAPITokenCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	if len(cmd.Args.Scopes) == 0 {
+		return fmt.Errorf("at least one scope must be provided")
+	}
+
+	var scopes v1.APITokenScopeT
+	for _, scopeStr := range cmd.Args.Scopes {
+		scope, ok := APITokenScopeCommands[scopeStr]
+		if !ok {
+			return fmt.Errorf("%v is an invalid API token scope", scopeStr)
+		}
+		scopes |= scope
+	}
+
+	days := cmd.Days
+	if days == 0 {
+		days = defaultAPITokenDays
+	}
+
+	nat := v1.NewAPIToken{
+		Name:   cmd.Args.Name,
+		Scopes: scopes,
+		Expiry: time.Now().AddDate(0, 0, int(days)).Unix(),
+	}
+
+	var natr v1.NewAPITokenReply
+	err = Ctx.Post(v1.RouteNewAPIToken, nat, &natr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("API token %v created; it will not be shown again:\n\n"+
+			"  %v\n\nUse it with the --apitoken flag or the %v environment "+
+			"variable.\n", natr.ID, natr.Token, config.APITokenEnvVar)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type ProposalReportCmd struct {
+	Args struct {
+		Token string `positional-arg-name:"token"`
+	} `positional-args:"true" optional:"true"`
+}
+
+func (cmd *ProposalReportCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	pr := v1.ProposalReport{
+		Token: cmd.Args.Token,
+	}
+
+	var prr v1.ProposalReportReply
+	err = Ctx.Get(v1.RouteProposalReport, pr, &prr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Proposal spending: ")
+		if len(prr.Proposals) == 0 {
+			fmt.Printf("none\n")
+		} else {
+			fmt.Println()
+			for _, ps := range prr.Proposals {
+				fmt.Printf("  %v\n", ps.Proposal.Token)
+				if ps.Proposal.Name != "" {
+					fmt.Printf("        Name: %v\n", ps.Proposal.Name)
+				}
+				fmt.Printf("      Budget: %v\n",
+					formatProposalBudget(ps.Proposal))
+				fmt.Printf("      Billed: $%v\n", ps.BilledUSD)
+				fmt.Printf("        Paid: $%v\n", ps.PaidUSD)
+				if ps.OverBudget {
+					fmt.Printf("              over budget by $%v\n",
+						ps.BilledUSD-ps.Proposal.BudgetUSD)
+				}
+				for _, month := range ps.Months {
+					date := time.Date(int(month.Year), time.Month(month.Month),
+						1, 0, 0, 0, 0, time.UTC)
+					fmt.Printf("    %v: billed $%v, paid $%v\n",
+						date.Format("January 2006"), month.BilledUSD,
+						month.PaidUSD)
+				}
+			}
+		}
+	}
+
+	return nil
+}
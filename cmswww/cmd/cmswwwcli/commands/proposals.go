@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type ProposalsCmd struct{}
+
+func (cmd *ProposalsCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	var pr v1.ProposalsReply
+	err = Ctx.Get(v1.RouteProposals, v1.Proposals{}, &pr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Proposals: ")
+		if len(pr.Proposals) == 0 {
+			fmt.Printf("none\n")
+		} else {
+			fmt.Println()
+			for _, proposal := range pr.Proposals {
+				fmt.Printf("  %v\n", proposal.Token)
+				if proposal.Name != "" {
+					fmt.Printf("      Name: %v\n", proposal.Name)
+				}
+				fmt.Printf("    Budget: %v\n", formatProposalBudget(proposal))
+				if proposal.Timestamp != 0 {
+					fmt.Printf("    Set at: %v\n",
+						time.Unix(proposal.Timestamp, 0).String())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatProposalBudget returns the budget of the proposal for display.
+func formatProposalBudget(proposal v1.Proposal) string {
+	if proposal.BudgetUSD == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("$%v", proposal.BudgetUSD)
+}
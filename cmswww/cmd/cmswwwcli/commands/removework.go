@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type RemoveWorkCmd struct {
+	Args struct {
+		Month    string `positional-arg-name:"month"`
+		Year     uint16 `positional-arg-name:"year"`
+		LineItem uint   `positional-arg-name:"lineitem"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *RemoveWorkCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	month, err := ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+
+	policy, err := fetchPolicy()
+	if err != nil {
+		return err
+	}
+
+	ddli := v1.DeleteDraftLineItem{
+		Month: month,
+		Year:  cmd.Args.Year,
+		Index: cmd.Args.LineItem,
+	}
+
+	var ddlir v1.DeleteDraftLineItemReply
+	err = Ctx.Post(v1.RouteDeleteDraftLineItem, ddli, &ddlir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		printInvoiceDraft(&ddlir.Draft, policy)
+	}
+	return nil
+}
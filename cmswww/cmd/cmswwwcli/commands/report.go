@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type ReportCmd struct {
+	Args struct {
+		Month string `positional-arg-name:"month"`
+		Year  uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" required:"true"`
+	CSV bool `long:"csv" optional:"true" description:"Print the report as CSV"`
+}
+
+func (cmd *ReportCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	month, err := ParseMonth(cmd.Args.Month)
+	if err != nil {
+		return err
+	}
+
+	sr := v1.SpendingReport{
+		Month: month,
+		Year:  cmd.Args.Year,
+	}
+
+	var srr v1.SpendingReportReply
+	err = Ctx.Get(v1.RouteSpendingReport, sr, &srr)
+	if err != nil {
+		return err
+	}
+
+	if config.JSONOutput {
+		return nil
+	}
+
+	if cmd.CSV {
+		return writeSpendingReportCSV(&srr)
+	}
+
+	printSpendingSummary("Month", srr.Month)
+	fmt.Println()
+	printSpendingSummary("Year to date", srr.YearToDate)
+	return nil
+}
+
+// spendingGrouping is one of the ways the spending of a summary is grouped.
+type spendingGrouping struct {
+	name   string
+	groups []v1.SpendingGroup
+}
+
+func spendingSummaryGroupings(summary v1.SpendingSummary) []spendingGrouping {
+	return []spendingGrouping{
+		{"type", summary.ByType},
+		{"subtype", summary.BySubtype},
+		{"contractor", summary.ByContractor},
+		{"domain", summary.ByDomain},
+		{"proposal", summary.ByProposal},
+	}
+}
+
+func printSpendingSummary(title string, summary v1.SpendingSummary) {
+	fmt.Printf("%v: %v invoices, %v hours, $%v, %.8f DCR\n", title,
+		summary.Invoices, summary.TotalHours, summary.TotalCostUSD,
+		summary.TotalCostDCR)
+
+	for _, grouping := range spendingSummaryGroupings(summary) {
+		if len(grouping.groups) == 0 {
+			continue
+		}
+
+		fmt.Printf("  By %v:\n", grouping.name)
+		for _, group := range grouping.groups {
+			name := group.Name
+			if name == "" {
+				name = "(none)"
+			}
+			if group.Type != "" {
+				name = fmt.Sprintf("%v / %v", group.Type, name)
+			}
+			fmt.Printf("    %-40v %6v hrs  $%-8v %.8f DCR\n", name,
+				group.TotalHours, group.TotalCostUSD, group.TotalCostDCR)
+		}
+	}
+}
+
+func writeSpendingReportCSV(srr *v1.SpendingReportReply) error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{"period", "grouping", "type", "name", "hours",
+		"usd", "dcr"})
+	if err != nil {
+		return err
+	}
+
+	periods := []struct {
+		name    string
+		summary v1.SpendingSummary
+	}{
+		{"month", srr.Month},
+		{"yeartodate", srr.YearToDate},
+	}
+	for _, period := range periods {
+		err = w.Write([]string{period.name, "total", "", "",
+			strconv.FormatUint(period.summary.TotalHours, 10),
+			strconv.FormatUint(period.summary.TotalCostUSD, 10),
+			strconv.FormatFloat(period.summary.TotalCostDCR, 'f', 8, 64)})
+		if err != nil {
+			return err
+		}
+
+		for _, grouping := range spendingSummaryGroupings(period.summary) {
+			for _, group := range grouping.groups {
+				err = w.Write([]string{period.name, grouping.name,
+					group.Type, group.Name,
+					strconv.FormatUint(group.TotalHours, 10),
+					strconv.FormatUint(group.TotalCostUSD, 10),
+					strconv.FormatFloat(group.TotalCostDCR, 'f', 8, 64)})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -45,17 +45,31 @@ func (cmd *ReviewInvoicesCmd) Execute(args []string) error {
 				fmt.Println()
 				fmt.Println()
 
-				totalRate := float64(invoice.TotalCostUSD) / float64(invoice.TotalHours)
+				totalRate := float64(invoice.TotalLaborUSD) / float64(invoice.TotalHours)
 
 				fmt.Printf("           User ID: %v\n", invoice.UserID)
 				fmt.Printf("          Username: %v\n", invoice.Username)
 				fmt.Printf("             Token: %v\n", invoice.Token)
+				for _, leadReview := range invoice.LeadReviews {
+					fmt.Printf("       Lead review: %v\n",
+						formatLeadReview(leadReview))
+				}
 				fmt.Printf("   ------------------------------------------\n")
 				for lineItemIdx, lineItem := range invoice.LineItems {
 					if lineItemIdx > 0 {
 						fmt.Printf("        --------------------------------\n")
 					}
 
+					if lineItem.Kind == v1.LineItemKindExpense {
+						fmt.Printf("              Expense: %v\n", lineItem.Subtype)
+						fmt.Printf("          Description: %v\n", lineItem.Description)
+						if lineItem.Proposal != "" {
+							fmt.Printf("    Politeia proposal: %v\n", lineItem.Proposal)
+						}
+						fmt.Printf("               Amount: $%v\n", lineItem.TotalCost)
+						continue
+					}
+
 					rate := float64(lineItem.TotalCost) / float64(lineItem.Hours)
 					fmt.Printf("                 Type: %v\n", lineItem.Type)
 					if lineItem.Subtype != "" {
@@ -71,8 +85,22 @@ func (cmd *ReviewInvoicesCmd) Execute(args []string) error {
 				}
 				fmt.Printf("   ------------------------------------------\n")
 				fmt.Printf("             Hours: %v\n", invoice.TotalHours)
-				fmt.Printf("        Total cost: $%v\n", invoice.TotalCostUSD)
+				fmt.Printf("        Labor cost: $%v\n", invoice.TotalLaborUSD)
 				fmt.Printf("      Average Rate: $%.2f / hr\n", totalRate)
+				if invoice.TotalExpensesUSD > 0 {
+					fmt.Printf("          Expenses: $%v\n", invoice.TotalExpensesUSD)
+				}
+				fmt.Printf("        Total cost: $%v\n", invoice.TotalCostUSD)
+				for _, warning := range invoice.BudgetWarnings {
+					name := warning.Token
+					if warning.Name != "" {
+						name = fmt.Sprintf("%v (%v)", warning.Name,
+							warning.Token)
+					}
+					fmt.Printf("           Warning: proposal %v would be "+
+						"billed $%v of its $%v budget\n", name,
+						warning.BilledUSD, warning.BudgetUSD)
+				}
 			}
 		}
 	}
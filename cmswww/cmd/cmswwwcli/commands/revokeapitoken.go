@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type RevokeAPITokenCmd struct {
+	Args struct {
+		ID string `positional-arg-name:"id"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *RevokeAPITokenCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	rat := v1.RevokeAPIToken{
+		ID: cmd.Args.ID,
+	}
+
+	var ratr v1.RevokeAPITokenReply
+	err = Ctx.Post(v1.RouteRevokeAPIToken, rat, &ratr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("API token %v has been revoked\n", cmd.Args.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type RevokeSessionsCmd struct {
+	Args struct {
+		ID string `positional-arg-name:"id"`
+	} `positional-args:"true" optional:"true"`
+	All bool `long:"all" optional:"true" description:"Revoke all sessions except the current one"`
+}
+
+func (cmd *RevokeSessionsCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	if (cmd.Args.ID == "") == !cmd.All {
+		return fmt.Errorf("Provide either a session id or the --all flag")
+	}
+
+	rs := v1.RevokeSessions{
+		ID:  cmd.Args.ID,
+		All: cmd.All,
+	}
+
+	var rsr v1.RevokeSessionsReply
+	err = Ctx.Post(v1.RouteRevokeSessions, rs, &rsr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		if cmd.All {
+			fmt.Printf("All other sessions have been revoked\n")
+		} else {
+			fmt.Printf("Session %v has been revoked\n", cmd.Args.ID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type SearchInvoicesCmd struct {
+	Statuses      []string `long:"status" optional:"true" description:"Invoice status, can be repeated"`
+	UserID        string   `long:"user" optional:"true" description:"ID of the user who submitted the invoice"`
+	From          string   `long:"from" optional:"true" description:"First invoice period, in YYYY-MM or YYYY format"`
+	To            string   `long:"to" optional:"true" description:"Last invoice period, in YYYY-MM or YYYY format"`
+	SubmittedFrom string   `long:"submittedfrom" optional:"true" description:"Submitted on or after this date, in YYYY-MM-DD format"`
+	SubmittedTo   string   `long:"submittedto" optional:"true" description:"Submitted on or before this date, in YYYY-MM-DD format"`
+	Proposal      string   `long:"proposal" optional:"true" description:"Token of a proposal billed against"`
+	Type          string   `long:"type" optional:"true" description:"Type of work of a line item"`
+	MinCost       uint64   `long:"mincost" optional:"true" description:"Minimum total cost in USD"`
+	MaxCost       uint64   `long:"maxcost" optional:"true" description:"Maximum total cost in USD"`
+	Text          string   `long:"text" optional:"true" description:"Text in a line item description"`
+	Sort          string   `long:"sort" optional:"true" description:"Sort order: newest, oldest, highestcost or lowestcost"`
+	Cursor        string   `long:"cursor" optional:"true" description:"Cursor of the page to return, from a previous search"`
+	Limit         uint     `long:"limit" optional:"true" description:"Maximum number of invoices to return"`
+}
+
+var (
+	invoiceSorts = map[string]v1.InvoiceSortT{
+		"newest":      v1.InvoiceSortNewest,
+		"oldest":      v1.InvoiceSortOldest,
+		"highestcost": v1.InvoiceSortHighestCost,
+		"lowestcost":  v1.InvoiceSortLowestCost,
+	}
+)
+
+// parseInvoicePeriod converts an invoice period in YYYY-MM or YYYY format
+// into its month and year. The month is 0 if only the year is given.
+func parseInvoicePeriod(periodStr string) (uint16, uint16, error) {
+	if periodStr == "" {
+		return 0, 0, nil
+	}
+
+	layout := "2006-01"
+	if !strings.Contains(periodStr, "-") {
+		layout = "2006"
+	}
+	t, err := time.Parse(layout, periodStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid invoice period %v, the format "+
+			"must be YYYY-MM or YYYY", periodStr)
+	}
+
+	if layout == "2006" {
+		return 0, uint16(t.Year()), nil
+	}
+	return uint16(t.Month()), uint16(t.Year()), nil
+}
+
+// parseSearchDate converts a date in YYYY-MM-DD format into a Unix
+// timestamp. The end of the day is used if endOfDay is set.
+func parseSearchDate(dateStr string, endOfDay bool) (int64, error) {
+	if dateStr == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %v, the format must be "+
+			"YYYY-MM-DD", dateStr)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t.Unix(), nil
+}
+
+func (cmd *SearchInvoicesCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	si := v1.SearchInvoices{
+		UserID:       cmd.UserID,
+		Proposal:     cmd.Proposal,
+		Type:         cmd.Type,
+		MinTotalCost: cmd.MinCost,
+		MaxTotalCost: cmd.MaxCost,
+		Text:         cmd.Text,
+		Cursor:       cmd.Cursor,
+		Limit:        cmd.Limit,
+	}
+
+	for _, statusStr := range cmd.Statuses {
+		status, ok := invoiceStatuses[strings.ToLower(statusStr)]
+		if !ok {
+			return fmt.Errorf("Invalid status: %v", statusStr)
+		}
+		si.Statuses = append(si.Statuses, status)
+	}
+
+	if cmd.Sort != "" {
+		var ok bool
+		si.Sort, ok = invoiceSorts[strings.ToLower(cmd.Sort)]
+		if !ok {
+			return fmt.Errorf("Invalid sort order: %v", cmd.Sort)
+		}
+	}
+
+	si.FromMonth, si.FromYear, err = parseInvoicePeriod(cmd.From)
+	if err != nil {
+		return err
+	}
+	si.ToMonth, si.ToYear, err = parseInvoicePeriod(cmd.To)
+	if err != nil {
+		return err
+	}
+
+	si.SubmittedFrom, err = parseSearchDate(cmd.SubmittedFrom, false)
+	if err != nil {
+		return err
+	}
+	si.SubmittedTo, err = parseSearchDate(cmd.SubmittedTo, true)
+	if err != nil {
+		return err
+	}
+
+	var sir v1.SearchInvoicesReply
+	err = Ctx.Get(v1.RouteSearchInvoices, si, &sir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		// The number of matches isn't counted for some searches.
+		if sir.TotalMatches != 0 {
+			fmt.Printf("Invoices (%v matches): ", sir.TotalMatches)
+		} else {
+			fmt.Printf("Invoices: ")
+		}
+		if len(sir.Invoices) == 0 {
+			fmt.Printf("none\n")
+		} else {
+			fmt.Println()
+			for _, v := range sir.Invoices {
+				fmt.Printf("  %v\n", v.CensorshipRecord.Token)
+				fmt.Printf("           Invoice: %v-%02v\n", v.Year, v.Month)
+				fmt.Printf("      Submitted by: %v\n", v.Username)
+				fmt.Printf("                at: %v\n",
+					time.Unix(v.Timestamp, 0).String())
+				if v.Late {
+					fmt.Printf("                    (late)\n")
+				}
+				fmt.Printf("            Status: %v\n",
+					v1.InvoiceStatus[v.Status])
+			}
+		}
+
+		if sir.NextCursor != "" {
+			fmt.Printf("\nMore invoices match; to see the next page, run "+
+				"the same search with --cursor %v\n", sir.NextCursor)
+		}
+	}
+
+	return nil
+}
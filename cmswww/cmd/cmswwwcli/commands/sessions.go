@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type SessionsCmd struct{}
+
+func (cmd *SessionsCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	var usr v1.UserSessionsReply
+	err = Ctx.Get(v1.RouteUserSessions, nil, &usr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("---------------------------\n")
+		for _, session := range usr.Sessions {
+			id := session.ID
+			if session.Current {
+				id += " (current)"
+			}
+
+			fmt.Printf("         ID: %v\n", id)
+			fmt.Printf("    Address: %v\n", session.IPAddress)
+			fmt.Printf(" User agent: %v\n", session.UserAgent)
+			fmt.Printf("    Created: %v\n", time.Unix(session.Timestamp, 0))
+			fmt.Printf("  Last seen: %v\n", time.Unix(session.LastSeen, 0))
+			fmt.Printf("    Expires: %v\n", time.Unix(session.Expiry, 0))
+			fmt.Printf("---------------------------\n")
+		}
+	}
+
+	return nil
+}
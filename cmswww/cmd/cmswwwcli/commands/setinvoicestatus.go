@@ -2,10 +2,14 @@ package commands
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strconv"
 	"strings"
 
+	"github.com/decred/politeia/politeiad/api/v1/identity"
+
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
 )
@@ -16,6 +20,37 @@ type SetInvoiceStatusCmd struct {
 		Status string  `positional-arg-name:"status"`
 		Reason *string `positional-arg-name:"reason"`
 	} `positional-args:"true" optional:"true"`
+	Batch string `long:"batch" optional:"true" description:"JSON file with a list of status changes to make in a single request"`
+}
+
+// batchStatusChange is a status change as it's listed in a batch file.
+type batchStatusChange struct {
+	Token  string  `json:"token"`
+	Status string  `json:"status"`
+	Reason *string `json:"reason,omitempty"`
+}
+
+// newSetInvoiceStatus returns the status change signed with the identity.
+func newSetInvoiceStatus(
+	id *identity.FullIdentity,
+	token, statusStr string,
+	reason *string,
+) (*v1.SetInvoiceStatus, error) {
+	status, ok := invoiceStatuses[strings.ToLower(statusStr)]
+	if !ok {
+		return nil, fmt.Errorf("Invalid status: %v", statusStr)
+	}
+
+	msg := token + strconv.FormatUint(uint64(status), 10)
+	signature := id.SignMessage([]byte(msg))
+
+	return &v1.SetInvoiceStatus{
+		Token:     token,
+		Status:    status,
+		Reason:    reason,
+		PublicKey: hex.EncodeToString(id.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}, nil
 }
 
 func (cmd *SetInvoiceStatusCmd) Execute(args []string) error {
@@ -29,20 +64,14 @@ func (cmd *SetInvoiceStatusCmd) Execute(args []string) error {
 		return ErrNotLoggedIn
 	}
 
-	status, ok := invoiceStatuses[strings.ToLower(cmd.Args.Status)]
-	if !ok {
-		return fmt.Errorf("Invalid status: %v", cmd.Args.Status)
+	if cmd.Batch != "" {
+		return cmd.executeBatch(id)
 	}
 
-	msg := cmd.Args.Token + strconv.FormatUint(uint64(status), 10)
-	signature := id.SignMessage([]byte(msg))
-
-	sis := v1.SetInvoiceStatus{
-		Token:     cmd.Args.Token,
-		Status:    status,
-		Reason:    cmd.Args.Reason,
-		PublicKey: hex.EncodeToString(id.Public.Key[:]),
-		Signature: hex.EncodeToString(signature[:]),
+	sis, err := newSetInvoiceStatus(id, cmd.Args.Token, cmd.Args.Status,
+		cmd.Args.Reason)
+	if err != nil {
+		return err
 	}
 
 	var sisr v1.SetInvoiceStatusReply
@@ -52,7 +81,78 @@ func (cmd *SetInvoiceStatusCmd) Execute(args []string) error {
 	}
 
 	if !config.JSONOutput {
-		fmt.Printf("Status changed to %v", v1.InvoiceStatus[sisr.Invoice.Status])
+		if sisr.Invoice.Status == v1.InvoiceStatusAwaitingApproval {
+			fmt.Printf("Approval recorded, %v of %v admins have approved\n",
+				len(sisr.Invoice.Approvals), sisr.Invoice.ApprovalsRequired)
+		} else {
+			fmt.Printf("Status changed to %v", v1.InvoiceStatus[sisr.Invoice.Status])
+		}
+	}
+
+	return nil
+}
+
+// executeBatch signs the status changes listed in the batch file and makes
+// them in a single request.
+func (cmd *SetInvoiceStatusCmd) executeBatch(id *identity.FullIdentity) error {
+	data, err := ioutil.ReadFile(cmd.Batch)
+	if err != nil {
+		return err
+	}
+
+	var changes []batchStatusChange
+	err = json.Unmarshal(data, &changes)
+	if err != nil {
+		return fmt.Errorf("Could not parse batch file %v: %v", cmd.Batch,
+			err)
+	}
+
+	siss := v1.SetInvoiceStatuses{
+		StatusChanges: make([]v1.SetInvoiceStatus, 0, len(changes)),
+	}
+	for _, change := range changes {
+		sis, err := newSetInvoiceStatus(id, change.Token, change.Status,
+			change.Reason)
+		if err != nil {
+			return fmt.Errorf("%v: %v", change.Token, err)
+		}
+		siss.StatusChanges = append(siss.StatusChanges, *sis)
+	}
+
+	var sissr v1.SetInvoiceStatusesReply
+	err = Ctx.Post(v1.RouteSetInvoiceStatuses, siss, &sissr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		var failed int
+		for _, result := range sissr.Results {
+			fmt.Printf("  %v: ", result.Token)
+			switch {
+			case result.Invoice == nil:
+				failed++
+				errorStatus, ok := v1.ErrorStatus[v1.ErrorStatusT(result.ErrorCode)]
+				if !ok {
+					errorStatus = "internal error " +
+						strconv.FormatInt(result.ErrorCode, 10)
+				}
+				if len(result.ErrorContext) != 0 {
+					errorStatus += " " + strings.Join(result.ErrorContext, ", ")
+				}
+				fmt.Printf("failed, %v\n", errorStatus)
+			case result.Invoice.Status == v1.InvoiceStatusAwaitingApproval:
+				fmt.Printf("approval recorded, %v of %v admins have "+
+					"approved\n", len(result.Invoice.Approvals),
+					result.Invoice.ApprovalsRequired)
+			default:
+				fmt.Printf("status changed to %v\n",
+					v1.InvoiceStatus[result.Invoice.Status])
+			}
+		}
+
+		fmt.Printf("%v of %v status changes made\n",
+			len(sissr.Results)-failed, len(sissr.Results))
 	}
 
 	return nil
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type SetProposalBudgetCmd struct {
+	Args struct {
+		Token     string `positional-arg-name:"token"`
+		BudgetUSD uint64 `positional-arg-name:"budget"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *SetProposalBudgetCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	spb := v1.SetProposalBudget{
+		Token:     cmd.Args.Token,
+		BudgetUSD: cmd.Args.BudgetUSD,
+	}
+
+	var spbr v1.SetProposalBudgetReply
+	return Ctx.Post(v1.RouteSetProposalBudget, spb, &spbr)
+}
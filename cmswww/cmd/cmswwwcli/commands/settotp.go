@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type SetTOTPCmd struct {
+	Code string `long:"code" optional:"true" description:"Current two-factor authentication code, required to replace an existing secret"`
+}
+
+func (cmd *SetTOTPCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	st := v1.SetTOTP{
+		Code: cmd.Code,
+	}
+
+	var str v1.SetTOTPReply
+	err = Ctx.Post(v1.RouteSetTOTP, st, &str)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Add the following secret to your authenticator app, "+
+			"either manually or by generating a QR code from the URI:\n\n"+
+			"  Secret: %v\n  URI:    %v\n\n"+
+			"Then enable two-factor authentication using the verifytotp "+
+			"command.\n", str.Secret, str.URI)
+	}
+
+	return nil
+}
@@ -10,9 +10,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/decred/dcrtime/merkle"
+	"github.com/decred/politeia/politeiad/api/v1/mime"
+	"github.com/decred/politeia/util"
+
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
 )
@@ -22,7 +27,8 @@ type SubmitInvoiceCmd struct {
 		Month string `positional-arg-name:"month"`
 		Year  uint16 `positional-arg-name:"year"`
 	} `positional-args:"true" optional:"true"`
-	InvoiceFilename string `long:"invoice" optional:"true" description:"Filepath to an invoice CSV"`
+	InvoiceFilename string   `long:"invoice" optional:"true" description:"Filepath to an invoice CSV"`
+	Attachments     []string `long:"attachment" optional:"true" description:"Filepath to a receipt or other supporting file; can be repeated"`
 }
 
 // SubmissionRecord is a record of an invoice submission to the server,
@@ -88,6 +94,49 @@ func validateInvoiceFile(filename string) error {
 	return nil
 }
 
+// readInvoiceAttachments reads the files to attach to an invoice.
+func readInvoiceAttachments(filenames []string) ([]v1.File, error) {
+	attachments := make([]v1.File, 0, len(filenames))
+	for _, filename := range filenames {
+		payload, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, v1.File{
+			Name:    filepath.Base(filename),
+			MIME:    mime.DetectMimeType(payload),
+			Digest:  hex.EncodeToString(util.Digest(payload)),
+			Payload: base64.StdEncoding.EncodeToString(payload),
+		})
+	}
+
+	return attachments, nil
+}
+
+// invoiceMerkleRoot returns the merkle root of the digests of the invoice
+// file and its attachments, which is what gets signed.
+func invoiceMerkleRoot(digest string, attachments []v1.File) (string, error) {
+	digests := make([]*[sha256.Size]byte, 0, len(attachments)+1)
+	for _, d := range append([]string{digest}, attachmentDigests(attachments)...) {
+		hash, ok := util.ConvertDigest(d)
+		if !ok {
+			return "", fmt.Errorf("could not convert digest %v", d)
+		}
+		digests = append(digests, &hash)
+	}
+
+	return hex.EncodeToString(merkle.Root(digests)[:]), nil
+}
+
+func attachmentDigests(attachments []v1.File) []string {
+	digests := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		digests = append(digests, attachment.Digest)
+	}
+	return digests
+}
+
 func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 	err := InitialVersionRequest()
 	if err != nil {
@@ -99,31 +148,46 @@ func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 		return ErrNotLoggedIn
 	}
 
-	var filename string
+	// Either submit the invoice draft stored on the server for the month,
+	// or the given invoice file.
+	var payload []byte
 	if cmd.Args.Month != "" && cmd.Args.Year != 0 {
-		month, err := ParseMonth(cmd.Args.Month)
+		month, err = ParseMonth(cmd.Args.Month)
 		if err != nil {
 			return err
 		}
 		year = cmd.Args.Year
 
-		filename, err = config.GetInvoiceFilename(month, year)
+		draft, err := fetchInvoiceDraft(month, year)
+		if err != nil {
+			return err
+		}
+		if len(draft.LineItems) == 0 {
+			return fmt.Errorf("No work has been logged for %v. Please "+
+				"first log it through the logwork command.",
+				config.GetInvoiceMonthStr(month, year))
+		}
+
+		payload, err = base64.StdEncoding.DecodeString(draft.File.Payload)
 		if err != nil {
 			return err
 		}
 	} else if cmd.InvoiceFilename != "" {
-		filename = cmd.InvoiceFilename
+		err = validateInvoiceFile(cmd.InvoiceFilename)
+		if err != nil {
+			return err
+		}
+
+		payload, err = ioutil.ReadFile(cmd.InvoiceFilename)
+		if err != nil {
+			return err
+		}
 	} else {
 		return fmt.Errorf("You must supply either a month and year or the " +
 			"filepath to an invoice in proper CSV format.")
 	}
 
-	err = validateInvoiceFile(filename)
-	if err != nil {
-		return err
-	}
-
-	payload, err := ioutil.ReadFile(filename)
+	attachments, err := readInvoiceAttachments(cmd.Attachments)
 	if err != nil {
 		return err
 	}
@@ -131,7 +195,11 @@ func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 	h := sha256.New()
 	h.Write(payload)
 	digest := hex.EncodeToString(h.Sum(nil))
-	signature := id.SignMessage([]byte(digest))
+	merkleRoot, err := invoiceMerkleRoot(digest, attachments)
+	if err != nil {
+		return err
+	}
+	signature := id.SignMessage([]byte(merkleRoot))
 
 	ni := v1.SubmitInvoice{
 		Month: month,
@@ -140,8 +208,9 @@ func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 			Digest:  digest,
 			Payload: base64.StdEncoding.EncodeToString(payload),
 		},
-		PublicKey: hex.EncodeToString(id.Public.Key[:]),
-		Signature: hex.EncodeToString(signature[:]),
+		Attachments: attachments,
+		PublicKey:   hex.EncodeToString(id.Public.Key[:]),
+		Signature:   hex.EncodeToString(signature[:]),
 	}
 
 	var nir v1.SubmitInvoiceReply
@@ -150,9 +219,10 @@ func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 		return err
 	}
 
-	if nir.CensorshipRecord.Merkle != digest {
-		return fmt.Errorf("Digest returned from server did not match client's"+
-			" digest: %v %v", digest, nir.CensorshipRecord.Merkle)
+	if nir.CensorshipRecord.Merkle != merkleRoot {
+		return fmt.Errorf("Merkle root returned from server did not match "+
+			"client's merkle root: %v %v", merkleRoot,
+			nir.CensorshipRecord.Merkle)
 	}
 
 	// Store the submission record in case the submitter ever needs it.
@@ -166,7 +236,7 @@ func (cmd *SubmitInvoiceCmd) Execute(args []string) error {
 		return err
 	}
 
-	filename, err = config.GetInvoiceSubmissionRecordFilename(month, year, "1")
+	filename, err := config.GetInvoiceSubmissionRecordFilename(month, year, "1")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type TeamInvoicesCmd struct {
+	Args struct {
+		Month string `positional-arg-name:"month"`
+		Year  uint16 `positional-arg-name:"year"`
+	} `positional-args:"true" optional:"true"`
+	Status string `long:"status" optional:"true" description:"Invoice status"`
+}
+
+func (cmd *TeamInvoicesCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	var status v1.InvoiceStatusT
+	if cmd.Status != "" {
+		var ok bool
+		status, ok = invoiceStatuses[strings.ToLower(cmd.Status)]
+		if !ok {
+			return fmt.Errorf("Invalid status: %v", cmd.Status)
+		}
+	}
+
+	var month uint16
+	if cmd.Args.Month != "" {
+		month, err = ParseMonth(cmd.Args.Month)
+		if err != nil {
+			return err
+		}
+	}
+
+	ti := v1.TeamInvoices{
+		Status: status,
+		Month:  month,
+		Year:   cmd.Args.Year,
+	}
+
+	var tir v1.TeamInvoicesReply
+	err = Ctx.Get(v1.RouteTeamInvoices, ti, &tir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Team invoices: ")
+		if len(tir.Invoices) == 0 {
+			fmt.Printf("none\n")
+		} else {
+			fmt.Println()
+			for _, v := range tir.Invoices {
+				date := time.Date(int(v.Year), time.Month(v.Month),
+					1, 0, 0, 0, 0, time.UTC)
+				fmt.Printf("  %v\n", v.CensorshipRecord.Token)
+				fmt.Printf("      Submitted by: %v\n", v.Username)
+				fmt.Printf("                at: %v\n",
+					time.Unix(v.Timestamp, 0).String())
+				fmt.Printf("               For: %v\n",
+					date.Format("January 2006"))
+				fmt.Printf("            Status: %v\n",
+					v1.InvoiceStatus[v.Status])
+				for _, leadReview := range v.LeadReviews {
+					fmt.Printf("       Lead review: %v\n",
+						formatLeadReview(leadReview))
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
@@ -38,12 +40,62 @@ func (cmd *UserDetailsCmd) Execute(args []string) error {
 		fmt.Printf("                  Email: %v\n", udr.User.Email)
 		fmt.Printf("               Username: %v\n", udr.User.Username)
 		fmt.Printf("                  Admin: %v\n", udr.User.Admin)
+		fmt.Printf("                  Roles: %v\n", formatUserRoles(udr.User.Roles))
 		fmt.Printf("    Extended public key: %v\n", udr.User.ExtendedPublicKey)
 		fmt.Printf("             Last login: %v\n", udr.User.LastLogin)
 		fmt.Printf("  Failed login attempts: %v\n", udr.User.FailedLoginAttempts)
-		fmt.Printf("                 Locked: %v\n",
-			udr.User.FailedLoginAttempts >= v1.LoginAttemptsToLockUser)
+		fmt.Printf("                 Locked: %v\n", udr.User.Locked)
+		if udr.User.Deactivated {
+			fmt.Printf("               End date: %v\n",
+				time.Unix(udr.User.EndDate, 0).UTC().Format("2006-01-02"))
+		}
+		printContract(&udr.User.Contract, "")
+
+		for _, change := range udr.ContractChanges {
+			fmt.Printf("---------------------------\n")
+			fmt.Printf("        Contract change: %v\n",
+				time.Unix(change.Timestamp, 0).UTC().Format("2006-01-02 15:04:05"))
+			fmt.Printf("               Admin ID: %v\n", change.AdminUserID)
+			fmt.Printf("                 Reason: %v\n", change.Reason)
+			printContract(&change.Contract, "  ")
+		}
 	}
 
 	return nil
 }
+
+// printContract prints the terms of a contract.
+func printContract(contract *v1.Contract, indent string) {
+	formatDate := func(date int64) string {
+		if date == 0 {
+			return "none"
+		}
+		return time.Unix(date, 0).UTC().Format("2006-01-02")
+	}
+
+	fmt.Printf("%v    Contract start date: %v\n", indent,
+		formatDate(contract.StartDate))
+	fmt.Printf("%v      Contract end date: %v\n", indent,
+		formatDate(contract.EndDate))
+	fmt.Printf("%v        Contract domain: %v\n", indent,
+		v1.ContractDomain[contract.Domain])
+	fmt.Printf("%v        Hourly rate cap: %v\n", indent,
+		contract.HourlyRateCap)
+	fmt.Printf("%v     Contract documents: %v\n", indent,
+		strings.Join(contract.Documents, ", "))
+}
+
+// formatUserRoles returns the comma-separated list of the given roles.
+func formatUserRoles(roles v1.UserRoleT) string {
+	var names []string
+	for role := v1.UserRoleReviewer; role <= v1.UserRoleDomainLead; role <<= 1 {
+		if roles&role != 0 {
+			names = append(names, v1.UserRole[role])
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
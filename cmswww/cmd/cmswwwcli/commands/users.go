@@ -8,7 +8,9 @@ import (
 )
 
 type UsersCmd struct {
-	Username string `long:"username" optional:"true" description:"Username"`
+	Username   string `long:"username" optional:"true" description:"Username"`
+	Domain     string `long:"domain" optional:"true" description:"Only return users whose contract is in this domain"`
+	EndsBefore string `long:"endsbefore" optional:"true" description:"Only return users whose contract ends before this date, in YYYY-MM-DD format"`
 }
 
 func (cmd *UsersCmd) Execute(args []string) error {
@@ -17,8 +19,27 @@ func (cmd *UsersCmd) Execute(args []string) error {
 		return err
 	}
 
+	var domain v1.ContractDomainT
+	if cmd.Domain != "" {
+		var ok bool
+		domain, ok = ContractDomainCommands[cmd.Domain]
+		if !ok {
+			return fmt.Errorf("%v is an invalid contract domain", cmd.Domain)
+		}
+	}
+
+	var endsBefore int64
+	if cmd.EndsBefore != "" {
+		endsBefore, err = parseContractDate(cmd.EndsBefore)
+		if err != nil {
+			return err
+		}
+	}
+
 	u := v1.Users{
-		Username: cmd.Username,
+		Username:           cmd.Username,
+		Domain:             domain,
+		ContractEndsBefore: endsBefore,
 	}
 
 	var ur v1.UsersReply
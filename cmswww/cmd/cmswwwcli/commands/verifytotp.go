@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type VerifyTOTPCmd struct {
+	Args struct {
+		Code string `positional-arg-name:"code"`
+	} `positional-args:"true" required:"true"`
+}
+
+func (cmd *VerifyTOTPCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	if config.LoggedInUser == nil {
+		return ErrNotLoggedIn
+	}
+
+	vt := v1.VerifyTOTP{
+		Code: cmd.Args.Code,
+	}
+
+	var vtr v1.VerifyTOTPReply
+	err = Ctx.Post(v1.RouteVerifyTOTP, vt, &vtr)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Two-factor authentication is now enabled. Store these " +
+			"recovery codes somewhere safe; each one can be used once in " +
+			"place of a code if you lose access to your authenticator app:\n\n")
+		for _, code := range vtr.RecoveryCodes {
+			fmt.Printf("  %v\n", code)
+		}
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@ func (cmd *VersionCmd) Execute(args []string) error {
 	}
 
 	config.ServerPublicKey = vr.PublicKey
+	config.ServerSigningPublicKey = vr.SigningPublicKey
 
 	if vr.User != nil {
 		config.LoggedInUser = vr.User
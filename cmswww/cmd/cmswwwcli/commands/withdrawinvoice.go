@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/cmd/cmswwwcli/config"
+)
+
+type WithdrawInvoiceCmd struct {
+	Args struct {
+		Token  string `positional-arg-name:"token"`
+		Reason string `positional-arg-name:"reason"`
+	} `positional-args:"true" optional:"true"`
+}
+
+func (cmd *WithdrawInvoiceCmd) Execute(args []string) error {
+	err := InitialVersionRequest()
+	if err != nil {
+		return err
+	}
+
+	id := config.LoggedInUserIdentity
+	if id == nil {
+		return ErrNotLoggedIn
+	}
+
+	msg := cmd.Args.Token +
+		strconv.FormatUint(uint64(v1.InvoiceStatusWithdrawn), 10)
+	signature := id.SignMessage([]byte(msg))
+
+	wi := v1.WithdrawInvoice{
+		Token:     cmd.Args.Token,
+		Reason:    cmd.Args.Reason,
+		PublicKey: hex.EncodeToString(id.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}
+
+	var wir v1.WithdrawInvoiceReply
+	err = Ctx.Post(v1.RouteWithdrawInvoice, wi, &wir)
+	if err != nil {
+		return err
+	}
+
+	if !config.JSONOutput {
+		fmt.Printf("Invoice withdrawn, a new invoice can be submitted for "+
+			"%v-%02v\n", wir.Invoice.Year, wir.Invoice.Month)
+	}
+
+	return nil
+}
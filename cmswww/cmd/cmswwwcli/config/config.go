@@ -22,6 +22,10 @@ const (
 	defaultHost = "https://127.0.0.1:4443"
 	FaucetURL   = "https://faucet.decred.org/requestfaucet"
 
+	// APITokenEnvVar is the environment variable from which the API token
+	// is read, if set.
+	APITokenEnvVar = "CMSWWWCLI_APITOKEN"
+
 	ErrorNoUserIdentity   = "No user identity found."
 	ErrorBeforeAfterFlags = "The 'before' and 'after' flags cannot be used at " +
 		"the same time."
@@ -35,6 +39,7 @@ var (
 	Host       = defaultHost
 	JSONOutput bool
 	Verbose    bool
+	APIToken   string
 
 	SuppressOutput bool
 
@@ -43,7 +48,8 @@ var (
 	LoggedInUser         *v1.LoginReply
 	LoggedInUserIdentity *identity.FullIdentity
 
-	ServerPublicKey string
+	ServerPublicKey        string
+	ServerSigningPublicKey string
 )
 
 func getUserIdentityFile(email string) string {
@@ -65,6 +71,30 @@ func LoadUserIdentity(email string) (*identity.FullIdentity, error) {
 	return id, err
 }
 
+// MigrateUserData moves the identity and the invoices stored for a user to
+// the user's new email address, so they can still be used after the email
+// address has been changed.
+func MigrateUserData(oldEmail, newEmail string) error {
+	paths := [][2]string{
+		{getUserIdentityFile(oldEmail), getUserIdentityFile(newEmail)},
+		{filepath.Join(InvoicesDir, oldEmail), filepath.Join(InvoicesDir, newEmail)},
+	}
+	for _, path := range paths {
+		if !FileExists(path[0]) {
+			continue
+		}
+		if FileExists(path[1]) {
+			return fmt.Errorf("unable to move %v, %v already exists",
+				path[0], path[1])
+		}
+		if err := os.Rename(path[0], path[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func GetInvoiceMonthStr(month, year uint16) string {
 	t := time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	return t.Format("2006-01")
@@ -74,20 +104,6 @@ func GetInvoiceDirectory() string {
 	return filepath.Join(InvoicesDir, LoggedInUser.Email)
 }
 
-func GetInvoiceFilename(month, year uint16) (string, error) {
-	filename := filepath.Join(GetInvoiceDirectory(),
-		fmt.Sprintf("%v.csv", GetInvoiceMonthStr(month, year)))
-
-	if !FileExists(filename) {
-		dirpath := filepath.Dir(filename)
-		if err := os.MkdirAll(dirpath, 0700); err != nil {
-			return "", err
-		}
-	}
-
-	return filename, nil
-}
-
 func GetInvoiceSubmissionRecordFilename(month, year uint16, version string) (string, error) {
 	filename := filepath.Join(GetInvoiceDirectory(),
 		fmt.Sprintf("submission_record_%v_%v.json",
@@ -153,6 +169,10 @@ func Load() error {
 		return err
 	}
 
+	// Scripts can provide an API token via the environment so that it
+	// doesn't show up in the process list.
+	APIToken = os.Getenv(APITokenEnvVar)
+
 	return LoadCookies()
 }
 
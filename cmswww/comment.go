@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// getInvoiceForComments fetches the invoice and validates that the user can
+// see it, and therefore its comments.
+func (c *cmswww) getInvoiceForComments(
+	token string,
+	user *database.User,
+) (*database.Invoice, error) {
+	dbInvoice, err := c.db.GetInvoiceByToken(token)
+	if err != nil {
+		if err == database.ErrInvoiceNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvoiceNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	err = c.validateUserCanAccessInvoice(
+		convertDatabaseInvoiceToInvoice(dbInvoice), user)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbInvoice, nil
+}
+
+// convertDatabaseInvoiceCommentToComment converts a database comment into
+// an API comment, looking up its author.
+func (c *cmswww) convertDatabaseInvoiceCommentToComment(
+	dbComment *database.InvoiceComment,
+) (*v1.InvoiceComment, error) {
+	userID, err := c.db.GetUserIdByPublicKey(dbComment.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	userIDStr := strconv.FormatUint(userID, 10)
+
+	return &v1.InvoiceComment{
+		CommentID: dbComment.CommentID,
+		ParentID:  dbComment.ParentID,
+		LineItem:  dbComment.LineItem,
+		UserID:    userIDStr,
+		Username:  c.getUsernameByID(userIDStr),
+		PublicKey: dbComment.PublicKey,
+		Signature: dbComment.Signature,
+		Comment:   dbComment.Comment,
+		Timestamp: dbComment.Timestamp,
+	}, nil
+}
+
+// validateCommentLineItem returns an error if the invoice doesn't have the
+// given line item.
+func (c *cmswww) validateCommentLineItem(
+	dbInvoice *database.Invoice,
+	lineItem uint,
+) error {
+	if lineItem == 0 {
+		return nil
+	}
+
+	err := c.fetchInvoiceFileIfNecessary(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	invoiceReview, err := c.createInvoiceReview(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	if lineItem > uint(len(invoiceReview.LineItems)) {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid line item"},
+		}
+	}
+
+	return nil
+}
+
+// HandleInvoiceComments returns all comments on an invoice, oldest first.
+func (c *cmswww) HandleInvoiceComments(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ic := req.(*v1.InvoiceComments)
+
+	dbInvoice, err := c.getInvoiceForComments(ic.Token, user)
+	if err != nil {
+		return nil, err
+	}
+
+	dbComments, err := c.db.GetInvoiceComments(dbInvoice.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]v1.InvoiceComment, 0, len(dbComments))
+	for _, dbComment := range dbComments {
+		comment, err := c.convertDatabaseInvoiceCommentToComment(&dbComment)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+
+	return &v1.InvoiceCommentsReply{
+		Comments: comments,
+	}, nil
+}
+
+// HandleNewInvoiceComment adds a comment to an invoice, or a reply to an
+// existing comment, and notifies the invoice's author and the author of the
+// parent comment.
+func (c *cmswww) HandleNewInvoiceComment(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	nic := req.(*v1.NewInvoiceComment)
+
+	if strings.TrimSpace(nic.Comment) == "" {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"empty comment"},
+		}
+	}
+	if len(nic.Comment) > v1.PolicyMaxCommentLength {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusCommentLengthExceededPolicy,
+		}
+	}
+
+	err := checkPublicKeyAndSignature(user, nic.PublicKey, nic.Signature,
+		nic.Token, strconv.FormatUint(nic.ParentID, 10),
+		strconv.FormatUint(uint64(nic.LineItem), 10), nic.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	dbInvoice, err := c.getInvoiceForComments(nic.Token, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if nic.ParentID == 0 {
+		err = c.validateCommentLineItem(dbInvoice, nic.LineItem)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dbComments, err := c.db.GetInvoiceComments(dbInvoice.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentUserID uint64
+	if nic.ParentID != 0 {
+		var parent *database.InvoiceComment
+		for i := range dbComments {
+			if dbComments[i].CommentID == nic.ParentID {
+				parent = &dbComments[i]
+				break
+			}
+		}
+		if parent == nil {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusCommentNotFound,
+			}
+		}
+		if parent.LineItem != nic.LineItem {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"replies must be on the line item " +
+					"of the parent comment"},
+			}
+		}
+
+		parentUserID, err = c.db.GetUserIdByPublicKey(parent.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	publicKey, ok := database.ActiveIdentityString(user.Identities)
+	if !ok {
+		return nil, fmt.Errorf("invalid user identity: %v", user.ID)
+	}
+
+	// The comment is stored in the database first, which assigns it the
+	// next comment ID of the invoice, and removed again if it can't be
+	// added to politeiad.
+	dbComment := database.InvoiceComment{
+		ParentID:  nic.ParentID,
+		LineItem:  nic.LineItem,
+		PublicKey: publicKey,
+		Signature: nic.Signature,
+		Comment:   nic.Comment,
+		Timestamp: time.Now().Unix(),
+	}
+	err = c.db.CreateInvoiceComment(dbInvoice.Token, &dbComment)
+	if err != nil {
+		return nil, err
+	}
+
+	mdComment := BackendInvoiceMDComment{
+		Version:   VersionBackendInvoiceMDComment,
+		CommentID: dbComment.CommentID,
+		ParentID:  dbComment.ParentID,
+		LineItem:  dbComment.LineItem,
+		PublicKey: dbComment.PublicKey,
+		Signature: dbComment.Signature,
+		Comment:   dbComment.Comment,
+		Timestamp: dbComment.Timestamp,
+	}
+	err = c.appendInvoiceMetadata(nic.Token, mdStreamComments, mdComment)
+	if err != nil {
+		deleteErr := c.db.DeleteInvoiceComment(dbInvoice.Token,
+			dbComment.CommentID)
+		if deleteErr != nil {
+			log.Errorf("HandleNewInvoiceComment: could not delete "+
+				"comment %v of invoice %v: %v", dbComment.CommentID,
+				dbInvoice.Token, deleteErr)
+		}
+		return nil, err
+	}
+
+	c.fireEvent(EventTypeInvoiceComment,
+		EventDataInvoiceComment{
+			Invoice:      dbInvoice,
+			Comment:      &dbComment,
+			User:         user,
+			ParentUserID: parentUserID,
+		},
+	)
+
+	comment, err := c.convertDatabaseInvoiceCommentToComment(&dbComment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.NewInvoiceCommentReply{
+		Comment: *comment,
+	}, nil
+}
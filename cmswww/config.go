@@ -17,6 +17,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	flags "github.com/btcsuite/go-flags"
 	"github.com/dajohi/goemail"
@@ -41,6 +42,23 @@ const (
 
 	defaultPaymentMinConfirmations = uint64(2)
 
+	// The deadline and reminder days are restricted to the first 28 days
+	// so they exist in every month.
+	defaultInvoiceDeadlineDay = uint(5)
+	maxInvoiceDayOfMonth      = uint(28)
+
+	// The default limits for the login, login challenge, register and reset
+	// password routes, in requests per minute and burst size.
+	defaultRateLimitIP           = uint(30)
+	defaultRateLimitIPBurst      = uint(10)
+	defaultRateLimitAccount      = uint(10)
+	defaultRateLimitAccountBurst = uint(5)
+
+	// Failed logins are delayed after this many consecutive failed attempts,
+	// doubling the delay with each failed attempt up to the max delay.
+	defaultLoginDelayAfter = uint64(3)
+	defaultMaxLoginDelay   = 15 * time.Minute
+
 	// dust value can be found increasing the amount value until we get false
 	// from IsDustAmount function. Amounts can not be lower than dust
 	// func IsDustAmount(amount int64, relayFeePerKb int64) bool {
@@ -56,6 +74,8 @@ var (
 	defaultRPCCertFile   = filepath.Join(sharedconfig.DefaultHomeDir, "rpc.cert")
 	defaultCookieKeyFile = filepath.Join(sharedconfig.DefaultHomeDir, "cookie.key")
 	defaultLogDir        = filepath.Join(sharedconfig.DefaultHomeDir, defaultLogDirname)
+
+	defaultInvoiceReminderDays = []uint{1, 4}
 )
 
 // runServiceCommand is only set to a real function on Windows.  It is used
@@ -86,20 +106,40 @@ type config struct {
 	RPCCert                  string `long:"rpccert" description:"File containing the https certificate file"`
 	RPCIdentityFile          string `long:"rpcidentityfile" description:"Path to file containing the politeiad identity"`
 	Identity                 *identity.PublicIdentity
+	SigningIdentityFile      string `long:"signingidentityfile" description:"Path to file containing the identity cmswww signs documents with"`
+	SigningIdentity          *identity.FullIdentity
 	RPCUser                  string `long:"rpcuser" description:"RPC user name for privileged commands"`
 	RPCPass                  string `long:"rpcpass" description:"RPC password for privileged commands"`
 	MailHost                 string `long:"mailhost" description:"Email server address in this format: <host>:<port>"`
 	MailUser                 string `long:"mailuser" description:"Email server username"`
 	MailPass                 string `long:"mailpass" description:"Email server password"`
 	SMTP                     *goemail.SMTP
-	FetchIdentity            bool   `long:"fetchidentity" description:"Whether or not cmswww fetches the identity from politeiad."`
-	WebServerAddress         string `long:"webserveraddress" description:"Address for the Politeia web server; it should have this format: <scheme>://<host>[:<port>]"`
-	Interactive              string `long:"interactive" description:"Set to i-know-this-is-a-bad-idea to turn off interactive mode during --fetchidentity."`
-	CockroachDBName          string `long:"cockroachdbname" description:"The cockroachdb database name"`
-	CockroachDBUsername      string `long:"cockroachdbusername" descrption:"The cockroachdb database username"`
-	CockroachDBHost          string `long:"cockroachdbhost" descrption:"The cockroachdb host; format: <address>:<port>"`
-	MinConfirmationsRequired uint64 `long:"minconfirmations" description:"Minimum blocks confirmation for accepting a payment as paid."`
+	FetchIdentity            bool          `long:"fetchidentity" description:"Whether or not cmswww fetches the identity from politeiad."`
+	CreateSigningIdentity    bool          `long:"createsigningidentity" description:"Create the identity cmswww signs documents with at the path given by --signingidentityfile and exit."`
+	WebServerAddress         string        `long:"webserveraddress" description:"Address for the Politeia web server; it should have this format: <scheme>://<host>[:<port>]"`
+	Interactive              string        `long:"interactive" description:"Set to i-know-this-is-a-bad-idea to turn off interactive mode during --fetchidentity."`
+	CockroachDBName          string        `long:"cockroachdbname" description:"The cockroachdb database name"`
+	CockroachDBUsername      string        `long:"cockroachdbusername" descrption:"The cockroachdb database username"`
+	CockroachDBHost          string        `long:"cockroachdbhost" descrption:"The cockroachdb host; format: <address>:<port>"`
+	MinConfirmationsRequired uint64        `long:"minconfirmations" description:"Minimum blocks confirmation for accepting a payment as paid."`
+	InvoiceDeadlineDay       uint          `long:"invoicedeadlineday" description:"Day of the month by which invoices for the previous month must be submitted"`
+	InvoiceReminderDays      []uint        `long:"invoicereminderday" description:"Add a day of the month on which contractors who have not submitted an invoice for the previous month are reminded"`
+	RequireAdminTOTP         bool          `long:"requireadmintotp" description:"Require admins and users with roles to enable two-factor authentication before using privileged routes"`
+	ApprovalRules            []string      `long:"approvalrule" description:"Add a rule requiring invoices whose total cost is at least the given amount to be approved by multiple distinct admins; format: <minimum total cost in USD>:<approvals>"`
+	InvoiceTransitions       []string      `long:"invoicetransition" description:"Add a status change which can be made to invoices, replacing the default invoice workflow; role is reviewer, treasurer or contractor, and reason requires a reason for the change; format: <from status>:<to status>:<role>[:reason]"`
+	Proposals                []string      `long:"proposal" description:"Add a Politeia proposal which invoice line items can be billed against; if none are added, any well-formed proposal token is accepted; format: <token>[:<name>]"`
+	RateLimitIP              uint          `long:"ratelimitip" description:"Maximum number of requests per minute from a client address to the login, login challenge, register and reset password routes; 0 disables the limit. The limit is kept by each cmswww instance, so the effective limit is multiplied by the number of instances behind a load balancer"`
+	RateLimitIPBurst         uint          `long:"ratelimitipburst" description:"Maximum number of requests a client address can make in a burst to the rate limited routes"`
+	RateLimitAccount         uint          `long:"ratelimitaccount" description:"Maximum number of requests per minute for an email address to the rate limited routes; 0 disables the limit. The limit is kept by each cmswww instance, so the effective limit is multiplied by the number of instances behind a load balancer"`
+	RateLimitAccountBurst    uint          `long:"ratelimitaccountburst" description:"Maximum number of requests for an email address in a burst to the rate limited routes"`
+	TrustedProxies           []string      `long:"trustedproxy" description:"Add the address or CIDR range of a reverse proxy whose X-Forwarded-For header is trusted to identify clients for rate limiting"`
+	LoginDelayAfter          uint64        `long:"logindelayafter" description:"Number of consecutive failed login attempts after which further attempts are delayed; 0 disables the delay"`
+	MaxLoginDelay            time.Duration `long:"maxlogindelay" description:"Maximum delay between login attempts after repeated failed attempts"`
 	AdminLogFile             string
+	InvoiceApprovalRules     approvalRules
+	InvoiceWorkflow          *invoiceWorkflow
+	ProposalNames            map[string]string
+	TrustedProxyNets         []*net.IPNet
 }
 
 // serviceOptions defines the configuration options for the rpc as a service
@@ -287,9 +327,10 @@ func loadIdentity(cfg *config) error {
 		cfg.RPCIdentityFile = cleanAndExpandPath(cfg.RPCIdentityFile)
 	}
 
-	if cfg.FetchIdentity {
+	if cfg.FetchIdentity || cfg.CreateSigningIdentity {
 		// Don't try to load the identity from the existing file if the
-		// caller is trying to fetch a new one.
+		// caller is trying to fetch a new one or to create the signing
+		// identity.
 		return nil
 	}
 
@@ -309,6 +350,44 @@ func loadIdentity(cfg *config) error {
 	return nil
 }
 
+// loadSigningIdentity loads the identity which cmswww signs documents with.
+// The identity is never created implicitly, so that a misconfigured path
+// can't silently replace the key that clients verify documents against.
+func loadSigningIdentity(cfg *config) error {
+	if cfg.FetchIdentity {
+		return nil
+	}
+
+	if cfg.SigningIdentityFile == "" {
+		return fmt.Errorf("you must specify the identity cmswww signs " +
+			"documents with using the --signingidentityfile option")
+	}
+	cfg.SigningIdentityFile = cleanAndExpandPath(cfg.SigningIdentityFile)
+
+	_, err := os.Stat(cfg.SigningIdentityFile)
+	if cfg.CreateSigningIdentity {
+		// Don't overwrite an existing identity.
+		if err == nil {
+			return fmt.Errorf("the signing identity %v already exists",
+				cfg.SigningIdentityFile)
+		}
+		return nil
+	}
+	if os.IsNotExist(err) {
+		return fmt.Errorf("the signing identity %v does not exist; you "+
+			"must create it first using the --createsigningidentity flag",
+			cfg.SigningIdentityFile)
+	}
+
+	cfg.SigningIdentity, err = identity.LoadFullIdentity(cfg.SigningIdentityFile)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Signing identity loaded from: %v", cfg.SigningIdentityFile)
+	return nil
+}
+
 // loadConfig initializes and parses the config using a config file and command
 // line options.
 //
@@ -337,6 +416,13 @@ func loadConfig() (*config, []string, error) {
 		CockroachDBUsername:      sharedconfig.DefaultDBUsername,
 		CockroachDBHost:          sharedconfig.DefaultDBHost,
 		MinConfirmationsRequired: defaultPaymentMinConfirmations,
+		InvoiceDeadlineDay:       defaultInvoiceDeadlineDay,
+		RateLimitIP:              defaultRateLimitIP,
+		RateLimitIPBurst:         defaultRateLimitIPBurst,
+		RateLimitAccount:         defaultRateLimitAccount,
+		RateLimitAccountBurst:    defaultRateLimitAccountBurst,
+		LoginDelayAfter:          defaultLoginDelayAfter,
+		MaxLoginDelay:            defaultMaxLoginDelay,
 		Version:                  version(),
 	}
 
@@ -541,6 +627,61 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Validate the invoice deadline and reminder days.
+	if len(cfg.InvoiceReminderDays) == 0 {
+		cfg.InvoiceReminderDays = defaultInvoiceReminderDays
+	}
+	for _, day := range append(cfg.InvoiceReminderDays, cfg.InvoiceDeadlineDay) {
+		if day < 1 || day > maxInvoiceDayOfMonth {
+			str := "%s: Invoice deadline and reminder days must be " +
+				"between 1 and %v"
+			err := fmt.Errorf(str, funcName, maxInvoiceDayOfMonth)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Parse the invoice approval rules.
+	cfg.InvoiceApprovalRules, err = parseApprovalRules(cfg.ApprovalRules)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Parse the invoice workflow.
+	cfg.InvoiceWorkflow, err = parseInvoiceWorkflow(cfg.InvoiceTransitions)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Parse the proposals.
+	cfg.ProposalNames, err = parseProposals(cfg.Proposals)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Parse the trusted proxies.
+	cfg.TrustedProxyNets, err = parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Add the default listener if none were specified. The default
 	// listener is all addresses on the listen port for the network
 	// we are to connect to.
@@ -635,6 +776,10 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if err := loadSigningIdentity(&cfg); err != nil {
+		return nil, nil, err
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done.  This prevents the warning on help messages and invalid
 	// options.  Note this should go directly before the return.
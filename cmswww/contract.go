@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// isInContractPeriod returns whether the given month overlaps with the
+// user's contract period. Contracts without a start or end date are open
+// ended.
+func isInContractPeriod(user *database.User, month, year uint16) bool {
+	m := int(year)*12 + int(month) - 1
+	if user.ContractStartDate != 0 {
+		start := time.Unix(user.ContractStartDate, 0).UTC()
+		if m < start.Year()*12+int(start.Month())-1 {
+			return false
+		}
+	}
+	if user.ContractEndDate != 0 {
+		end := time.Unix(user.ContractEndDate, 0).UTC()
+		if m > end.Year()*12+int(end.Month())-1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateContract validates the contract terms and normalizes the
+// document references.
+func validateContract(contract *v1.Contract) error {
+	if contract.StartDate < 0 || contract.EndDate < 0 {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid contract dates"},
+		}
+	}
+	if contract.StartDate != 0 && contract.EndDate != 0 &&
+		contract.EndDate < contract.StartDate {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"contract ends before it starts"},
+		}
+	}
+
+	if _, ok := v1.ContractDomain[contract.Domain]; !ok {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid contract domain"},
+		}
+	}
+
+	if len(contract.Documents) > v1.PolicyMaxContractDocuments {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"too many contract documents"},
+		}
+	}
+
+	// The document references are stored as a comma separated list, so
+	// they can't contain commas.
+	for i, document := range contract.Documents {
+		document = strings.TrimSpace(document)
+		if document == "" ||
+			len(document) > v1.PolicyMaxContractDocumentLength ||
+			strings.ContainsAny(document, ", \t\n") {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"invalid contract document reference"},
+			}
+		}
+		contract.Documents[i] = document
+	}
+
+	return nil
+}
+
+// formatContract returns a human readable summary of the contract terms for
+// the audit log.
+func formatContract(contract *v1.Contract) string {
+	formatDate := func(date int64) string {
+		if date == 0 {
+			return "none"
+		}
+		return time.Unix(date, 0).UTC().Format("2006-01-02")
+	}
+
+	return fmt.Sprintf("start %v end %v domain %v rate cap %v documents %v",
+		formatDate(contract.StartDate), formatDate(contract.EndDate),
+		v1.ContractDomain[contract.Domain], contract.HourlyRateCap,
+		len(contract.Documents))
+}
+
+// HandleEditUserContract replaces the contract terms of a user and records
+// the change in the user's contract history.
+func (c *cmswww) HandleEditUserContract(
+	req interface{},
+	adminUser *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	euc := req.(*v1.EditUserContract)
+
+	// Fetch the database user.
+	targetUser, err := c.findUser(euc.UserID, euc.Email, euc.Username, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only admins can manage other admins.
+	if !adminUser.Admin && targetUser.Admin {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusRoleRequired,
+			ErrorContext: []string{"admin"},
+		}
+	}
+
+	euc.Reason = strings.TrimSpace(euc.Reason)
+	if len(euc.Reason) == 0 {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusReasonNotProvided,
+		}
+	}
+
+	err = validateContract(&euc.Contract)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser.ContractStartDate = euc.Contract.StartDate
+	targetUser.ContractEndDate = euc.Contract.EndDate
+	targetUser.ContractDomain = euc.Contract.Domain
+	targetUser.ContractHourlyRateCap = euc.Contract.HourlyRateCap
+	targetUser.ContractDocuments = euc.Contract.Documents
+
+	err = c.db.UpdateUserContract(targetUser, &database.ContractChange{
+		UserID:        targetUser.ID,
+		AdminUserID:   adminUser.ID,
+		StartDate:     euc.Contract.StartDate,
+		EndDate:       euc.Contract.EndDate,
+		Domain:        euc.Contract.Domain,
+		HourlyRateCap: euc.Contract.HourlyRateCap,
+		Documents:     euc.Contract.Documents,
+		Reason:        euc.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Append this action to the admin log file.
+	action := fmt.Sprintf("edit contract: %v", formatContract(&euc.Contract))
+	err = c.logAdminUserAction(adminUser, targetUser, action, euc.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.EditUserContractReply{}, nil
+}
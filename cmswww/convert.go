@@ -13,6 +13,10 @@ import (
 	pd "github.com/decred/politeia/politeiad/api/v1"
 )
 
+// invoiceFilename is the name of the invoice file in the politeiad record;
+// any other files of the record are attachments.
+const invoiceFilename = "invoice.csv"
+
 type BackendInvoiceMetadata struct {
 	Version   uint64 `json:"version"` // BackendInvoiceMetadata version
 	Month     uint16 `json:"month"`
@@ -23,11 +27,15 @@ type BackendInvoiceMetadata struct {
 }
 
 type BackendInvoiceMDChange struct {
-	Version        uint              `json:"version"`        // Version of the struct
-	AdminPublicKey string            `json:"adminpublickey"` // Identity of the administrator
-	NewStatus      v1.InvoiceStatusT `json:"newstatus"`      // Status
-	Reason         *string           `json:"reason"`         // Reason
-	Timestamp      int64             `json:"timestamp"`      // Timestamp of the change
+	Version        uint              `json:"version"`                  // Version of the struct
+	AdminPublicKey string            `json:"adminpublickey"`           // Identity of the administrator
+	AdminSignature string            `json:"adminsignature,omitempty"` // Administrator's signature of Token+string(status)
+	UserPublicKey  string            `json:"userpublickey,omitempty"`  // Identity of the contractor, for changes made by the contractor
+	UserSignature  string            `json:"usersignature,omitempty"`  // Contractor's signature of Token+string(status)
+	NewStatus      v1.InvoiceStatusT `json:"newstatus"`                // Status
+	ApprovalFor    v1.InvoiceStatusT `json:"approvalfor,omitempty"`    // Status being approved, if awaiting approval
+	Reason         *string           `json:"reason"`                   // Reason
+	Timestamp      int64             `json:"timestamp"`                // Timestamp of the change
 }
 
 type BackendInvoiceMDPayment struct {
@@ -39,6 +47,27 @@ type BackendInvoiceMDPayment struct {
 	TxID        string `json:"txid"`        // Transaction ID of the actual payment
 }
 
+type BackendInvoiceMDLeadReview struct {
+	Version        uint                 `json:"version"`          // Version of the struct
+	LeadPublicKey  string               `json:"leadpublickey"`    // Identity of the domain lead
+	LeadSignature  string               `json:"leadsignature"`    // Domain lead's signature of Token+string(action)
+	Action         v1.LeadReviewActionT `json:"action"`           // Review action
+	Reason         string               `json:"reason,omitempty"` // Reason for the action
+	InvoiceVersion string               `json:"invoiceversion"`   // Version of the invoice that was reviewed
+	Timestamp      int64                `json:"timestamp"`        // Timestamp of the review
+}
+
+type BackendInvoiceMDComment struct {
+	Version   uint   `json:"version"`   // Version of the struct
+	CommentID uint64 `json:"commentid"` // Sequential ID of the comment within the invoice
+	ParentID  uint64 `json:"parentid"`  // ID of the comment being replied to, or 0
+	LineItem  uint   `json:"lineitem"`  // Line item being commented on, or 0
+	PublicKey string `json:"publickey"` // Identity of the author
+	Signature string `json:"signature"` // Author's signature of Token+string(ParentID)+string(LineItem)+Comment
+	Comment   string `json:"comment"`   // Comment text
+	Timestamp int64  `json:"timestamp"` // Timestamp of the comment
+}
+
 func convertDatabaseUserToUser(user *database.User) v1.User {
 	return v1.User{
 		ID:                               strconv.FormatUint(user.ID, 10),
@@ -48,18 +77,52 @@ func convertDatabaseUserToUser(user *database.User) v1.User {
 		Location:                         user.Location,
 		ExtendedPublicKey:                user.ExtendedPublicKey,
 		Admin:                            user.Admin,
+		Roles:                            user.Roles,
 		RegisterVerificationToken:        user.RegisterVerificationToken,
 		RegisterVerificationExpiry:       user.RegisterVerificationExpiry,
 		UpdateIdentityVerificationToken:  user.UpdateIdentityVerificationToken,
 		UpdateIdentityVerificationExpiry: user.UpdateIdentityVerificationExpiry,
 		LastLogin:                        user.LastLogin,
 		FailedLoginAttempts:              user.FailedLoginAttempts,
-		Locked:                           IsUserLocked(user.FailedLoginAttempts),
+		Locked:                           IsUserLocked(user),
+		Deactivated:                      user.IsDeactivated(),
+		EndDate:                          user.EndDate,
 		EmailNotifications:               user.EmailNotifications,
+		TOTPEnabled:                      user.TOTPEnabled(),
+		Contract:                         convertDatabaseUserToContract(user),
 		Identities:                       convertDatabaseIdentitiesToIdentities(user.Identities),
 	}
 }
 
+func convertDatabaseUserToContract(user *database.User) v1.Contract {
+	return v1.Contract{
+		StartDate:     user.ContractStartDate,
+		EndDate:       user.ContractEndDate,
+		Domain:        user.ContractDomain,
+		HourlyRateCap: user.ContractHourlyRateCap,
+		Documents:     user.ContractDocuments,
+	}
+}
+
+func convertDatabaseContractChangesToContractChanges(dbContractChanges []database.ContractChange) []v1.ContractChange {
+	contractChanges := make([]v1.ContractChange, 0, len(dbContractChanges))
+	for _, dbContractChange := range dbContractChanges {
+		contractChanges = append(contractChanges, v1.ContractChange{
+			AdminUserID: strconv.FormatUint(dbContractChange.AdminUserID, 10),
+			Contract: v1.Contract{
+				StartDate:     dbContractChange.StartDate,
+				EndDate:       dbContractChange.EndDate,
+				Domain:        dbContractChange.Domain,
+				HourlyRateCap: dbContractChange.HourlyRateCap,
+				Documents:     dbContractChange.Documents,
+			},
+			Reason:    dbContractChange.Reason,
+			Timestamp: dbContractChange.Timestamp,
+		})
+	}
+	return contractChanges
+}
+
 func convertDatabaseIdentitiesToIdentities(dbIdentities []database.Identity) []v1.UserIdentity {
 	identities := make([]v1.UserIdentity, 0, len(dbIdentities))
 	for _, dbIdentity := range dbIdentities {
@@ -75,13 +138,22 @@ func convertDatabaseIdentityToIdentity(dbIdentity database.Identity) v1.UserIden
 	}
 }
 
-func convertInvoiceFileFromWWW(f *v1.File) []pd.File {
-	return []pd.File{{
-		Name:    "invoice.csv",
+func convertInvoiceFileFromWWW(f *v1.File, attachments []v1.File) []pd.File {
+	files := []pd.File{{
+		Name:    invoiceFilename,
 		MIME:    "text/plain; charset=utf-8",
 		Digest:  f.Digest,
 		Payload: f.Payload,
 	}}
+	for _, attachment := range attachments {
+		files = append(files, pd.File{
+			Name:    attachment.Name,
+			MIME:    attachment.MIME,
+			Digest:  attachment.Digest,
+			Payload: attachment.Payload,
+		})
+	}
+	return files
 }
 
 func convertInvoiceCensorFromWWW(f v1.CensorshipRecord) pd.CensorshipRecord {
@@ -92,25 +164,60 @@ func convertInvoiceCensorFromWWW(f v1.CensorshipRecord) pd.CensorshipRecord {
 	}
 }
 
-func convertInvoiceFileFromPD(files []pd.File) *v1.File {
+// findInvoiceFile returns the invoice file among the files of a record.
+// Records created before invoices had attachments only have one file.
+func findInvoiceFile(files []pd.File) *pd.File {
 	if len(files) == 0 {
 		return nil
 	}
 
+	for i := range files {
+		if files[i].Name == invoiceFilename {
+			return &files[i]
+		}
+	}
+	return &files[0]
+}
+
+func convertInvoiceFileFromPD(files []pd.File) *v1.File {
+	f := findInvoiceFile(files)
+	if f == nil {
+		return nil
+	}
+
 	return &v1.File{
-		Digest:  files[0].Digest,
-		Payload: files[0].Payload,
+		Digest:  f.Digest,
+		Payload: f.Payload,
+	}
+}
+
+func convertInvoiceAttachmentsFromPD(files []pd.File) []v1.File {
+	f := findInvoiceFile(files)
+
+	attachments := make([]v1.File, 0, len(files))
+	for _, file := range files {
+		if f != nil && file.Name == f.Name {
+			continue
+		}
+		attachments = append(attachments, v1.File{
+			Name:    file.Name,
+			MIME:    file.MIME,
+			Digest:  file.Digest,
+			Payload: file.Payload,
+		})
 	}
+	return attachments
 }
 
 func convertRecordFilesToDatabaseInvoiceFile(files []pd.File) *database.File {
-	if len(files) == 0 {
+	f := findInvoiceFile(files)
+	if f == nil {
 		return nil
 	}
 
 	return &database.File{
-		Digest:  files[0].Digest,
-		Payload: files[0].Payload,
+		Digest:  f.Digest,
+		Payload: f.Payload,
 	}
 }
 
@@ -127,8 +234,10 @@ func (c *cmswww) convertRecordToDatabaseInvoice(p pd.Record) (*database.Invoice,
 		File:            convertRecordFilesToDatabaseInvoiceFile(p.Files),
 		Token:           p.CensorshipRecord.Token,
 		ServerSignature: p.CensorshipRecord.Signature,
+		Merkle:          p.CensorshipRecord.Merkle,
 		Version:         p.Version,
 	}
+	var submitted int64
 	for _, m := range p.Metadata {
 		switch m.ID {
 		case mdStreamGeneral:
@@ -161,6 +270,12 @@ func (c *cmswww) convertRecordToDatabaseInvoice(p pd.Record) (*database.Invoice,
 					return nil, err
 				}
 
+				// The first change is recorded when the invoice is
+				// submitted, so use it to determine if it was late.
+				if len(dbInvoice.Changes) == 0 {
+					submitted = mdChange.Timestamp
+				}
+
 				dbInvoice.Changes = append(dbInvoice.Changes,
 					convertStreamChangeToDatabaseInvoiceChange(mdChange))
 				dbInvoice.Status = mdChange.NewStatus
@@ -184,6 +299,34 @@ func (c *cmswww) convertRecordToDatabaseInvoice(p pd.Record) (*database.Invoice,
 				dbInvoice.Payments = append(dbInvoice.Payments,
 					convertStreamPaymentToDatabaseInvoicePayment(mdPayment))
 			}
+		case mdStreamLeadReviews:
+			f := strings.NewReader(m.Payload)
+			d := json.NewDecoder(f)
+			for {
+				var mdLeadReview BackendInvoiceMDLeadReview
+				if err := d.Decode(&mdLeadReview); err == io.EOF {
+					break
+				} else if err != nil {
+					return nil, err
+				}
+
+				dbInvoice.LeadReviews = append(dbInvoice.LeadReviews,
+					convertStreamLeadReviewToDatabaseInvoiceLeadReview(mdLeadReview))
+			}
+		case mdStreamComments:
+			f := strings.NewReader(m.Payload)
+			d := json.NewDecoder(f)
+			for {
+				var mdComment BackendInvoiceMDComment
+				if err := d.Decode(&mdComment); err == io.EOF {
+					break
+				} else if err != nil {
+					return nil, err
+				}
+
+				dbInvoice.Comments = append(dbInvoice.Comments,
+					convertStreamCommentToDatabaseInvoiceComment(mdComment))
+			}
 		default:
 			// Log error but proceed
 			log.Errorf("initializeInventory: invalid "+
@@ -192,6 +335,11 @@ func (c *cmswww) convertRecordToDatabaseInvoice(p pd.Record) (*database.Invoice,
 		}
 	}
 
+	if submitted != 0 {
+		dbInvoice.Late = c.isInvoiceLate(dbInvoice.Month, dbInvoice.Year,
+			submitted)
+	}
+
 	return &dbInvoice, nil
 }
 
@@ -199,7 +347,9 @@ func convertStreamChangeToDatabaseInvoiceChange(mdChange BackendInvoiceMDChange)
 	dbInvoiceChange := database.InvoiceChange{}
 
 	dbInvoiceChange.AdminPublicKey = mdChange.AdminPublicKey
+	dbInvoiceChange.AdminSignature = mdChange.AdminSignature
 	dbInvoiceChange.NewStatus = mdChange.NewStatus
+	dbInvoiceChange.ApprovalFor = mdChange.ApprovalFor
 	if mdChange.Reason != nil {
 		dbInvoiceChange.Reason = *mdChange.Reason
 	}
@@ -220,6 +370,29 @@ func convertStreamPaymentToDatabaseInvoicePayment(mdPayment BackendInvoiceMDPaym
 	return dbInvoicePayment
 }
 
+func convertStreamLeadReviewToDatabaseInvoiceLeadReview(mdLeadReview BackendInvoiceMDLeadReview) database.InvoiceLeadReview {
+	return database.InvoiceLeadReview{
+		LeadPublicKey:  mdLeadReview.LeadPublicKey,
+		LeadSignature:  mdLeadReview.LeadSignature,
+		Action:         mdLeadReview.Action,
+		Reason:         mdLeadReview.Reason,
+		InvoiceVersion: mdLeadReview.InvoiceVersion,
+		Timestamp:      mdLeadReview.Timestamp,
+	}
+}
+
+func convertStreamCommentToDatabaseInvoiceComment(mdComment BackendInvoiceMDComment) database.InvoiceComment {
+	return database.InvoiceComment{
+		CommentID: mdComment.CommentID,
+		ParentID:  mdComment.ParentID,
+		LineItem:  mdComment.LineItem,
+		PublicKey: mdComment.PublicKey,
+		Signature: mdComment.Signature,
+		Comment:   mdComment.Comment,
+		Timestamp: mdComment.Timestamp,
+	}
+}
+
 func convertDatabaseInvoicePaymentsToStreamPayments(dbInvoice *database.Invoice) (string, error) {
 	mdPayments := ""
 	for _, dbInvoicePayment := range dbInvoice.Payments {
@@ -254,6 +427,7 @@ func convertDatabaseInvoiceToInvoice(dbInvoice *database.Invoice) *v1.InvoiceRec
 	invoice.PublicKey = dbInvoice.PublicKey
 	invoice.Signature = dbInvoice.UserSignature
 	invoice.Version = dbInvoice.Version
+	invoice.Late = dbInvoice.Late
 	if dbInvoice.File != nil {
 		invoice.File = &v1.File{
 			Digest:  dbInvoice.File.Digest,
@@ -261,13 +435,13 @@ func convertDatabaseInvoiceToInvoice(dbInvoice *database.Invoice) *v1.InvoiceRec
 		}
 	}
 	invoice.CensorshipRecord = v1.CensorshipRecord{
-		Token: dbInvoice.Token,
-		//Merkle:    dbInvoice.File.Digest,
+		Token:     dbInvoice.Token,
+		Merkle:    dbInvoice.Merkle,
 		Signature: dbInvoice.ServerSignature,
 	}
 
 	// TODO: clean up, merkle should always be set
-	if dbInvoice.File != nil {
+	if invoice.CensorshipRecord.Merkle == "" && dbInvoice.File != nil {
 		invoice.CensorshipRecord.Merkle = dbInvoice.File.Digest
 	}
 
@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/badoux/checkmail"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/lib/pq"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 	"github.com/decred/contractor-mgmt/cmswww/database"
@@ -45,6 +47,14 @@ func (c *cockroachdb) addWhereClause(db *gorm.DB, paramsMap map[string]interface
 	return db
 }
 
+// pqErrorUniqueViolation is the error code returned when a unique constraint
+// is violated.
+const pqErrorUniqueViolation = "23505"
+
+// maxCommentIDAttempts is the number of times the next comment id of an
+// invoice is tried when comments are added concurrently.
+const maxCommentIDAttempts = 10
+
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
 func (c *cockroachdb) dropTable(tableName string) error {
@@ -85,7 +95,70 @@ func (c *cockroachdb) CreateUser(dbUser *database.User) error {
 func (c *cockroachdb) UpdateUser(dbUser *database.User) error {
 	user := EncodeUser(dbUser)
 	log.Debugf("UpdateUser: %v", user.Email)
-	return c.db.Model(&User{}).Updates(*user).Error
+	err := c.db.Model(&User{}).Updates(*user).Error
+	if err != nil {
+		return err
+	}
+
+	// Updates skips fields with zero values, so the fields which can be
+	// reset to zero are updated explicitly.
+	return c.db.Model(user).UpdateColumns(map[string]interface{}{
+		"failed_login_attempts": user.FailedLoginAttempts,
+		"locked":                user.Locked,
+		"end_date":              user.EndDate,
+	}).Error
+}
+
+// ChangeUserEmail changes the email address of a user and clears its pending
+// email change, as long as the user's email address is still the old one and
+// the new one isn't used by another user.
+//
+// ChangeUserEmail satisfies the backend interface.
+func (c *cockroachdb) ChangeUserEmail(id uint64, oldEmail, newEmail string) error {
+	log.Debugf("ChangeUserEmail: %v %v", oldEmail, newEmail)
+
+	if err := checkmail.ValidateFormat(newEmail); err != nil {
+		return database.ErrInvalidEmail
+	}
+
+	tx := c.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	var count int
+	result := tx.Model(&User{}).Where("email = ?", newEmail).Count(&count)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if count > 0 {
+		tx.Rollback()
+		return database.ErrUserExists
+	}
+
+	result = tx.Model(&User{}).Where("id = ? AND email = ?", id,
+		oldEmail).UpdateColumns(map[string]interface{}{
+		"email":                               newEmail,
+		"change_email_address":                nil,
+		"change_email_old_verification_token": nil,
+		"change_email_new_verification_token": nil,
+		"change_email_verification_expiry":    nil,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		if pqErr, ok := result.Error.(*pq.Error); ok &&
+			pqErr.Code == pqErrorUniqueViolation {
+			return database.ErrUserExists
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return database.ErrUserNotFound
+	}
+
+	return tx.Commit().Error
 }
 
 // GetUser returns a user record if found in the database.
@@ -176,24 +249,39 @@ func (c *cockroachdb) GetAllUsers(callbackFn func(u *database.User)) error {
 	return nil
 }
 
-// Returns a list of users and the total count that match the provided username.
+// Returns a list of users and the total count that match the provided filters.
 //
 // GetUsers satisfies the backend interface.
-func (c *cockroachdb) GetUsers(username string, page int) ([]database.User, int, error) {
+func (c *cockroachdb) GetUsers(req database.UsersRequest) ([]database.User, int, error) {
 	log.Debugf("GetUsers")
 
-	query := "? = '' OR (lower(username) like lower(?) || '%')"
 	order := "created_at ASC"
-	username = strings.TrimSpace(username)
+	username := strings.TrimSpace(req.Username)
+
+	// addFilters adds the filters to both the query for the page of users
+	// and the query which counts all matching users.
+	addFilters := func(db *gorm.DB) *gorm.DB {
+		if username != "" {
+			db = db.Where("lower(username) like lower(?) || '%'", username)
+		}
+		if req.Domain != v1.ContractDomainInvalid {
+			db = db.Where("contract_domain = ?", uint(req.Domain))
+		}
+		if req.ContractEndsBefore != 0 {
+			db = db.Where("contract_end_date < ?",
+				time.Unix(req.ContractEndsBefore, 0))
+		}
+		return db
+	}
 
 	var users []User
 
-	db := c.db
-	if page > -1 {
-		db = db.Offset(page * v1.ListPageSize).Limit(v1.ListPageSize)
+	db := addFilters(c.db)
+	if req.Page > -1 {
+		db = db.Offset(req.Page * v1.ListPageSize).Limit(v1.ListPageSize)
 	}
 
-	result := db.Order(order).Find(&users, query, username, username)
+	result := db.Order(order).Find(&users)
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
@@ -211,8 +299,7 @@ func (c *cockroachdb) GetUsers(username string, page int) ([]database.User, int,
 	// find the count of all users that match the query.
 	numMatches := len(users)
 	if len(users) == v1.ListPageSize {
-		result = c.db.Model(&User{}).Where(query, username,
-			username).Count(&numMatches)
+		result = addFilters(c.db.Model(&User{})).Count(&numMatches)
 		if result.Error != nil {
 			return nil, 0, result.Error
 		}
@@ -221,6 +308,69 @@ func (c *cockroachdb) GetUsers(username string, page int) ([]database.User, int,
 	return dbUsers, numMatches, nil
 }
 
+// Updates the contract of a user and records the change, atomically.
+//
+// UpdateUserContract satisfies the backend interface.
+func (c *cockroachdb) UpdateUserContract(dbUser *database.User, dbContractChange *database.ContractChange) error {
+	user := EncodeUser(dbUser)
+	contractChange := EncodeContractChange(dbContractChange)
+	log.Debugf("UpdateUserContract: %v", user.Email)
+
+	tx := c.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// The contract terms are updated explicitly so that they can be
+	// cleared.
+	result := tx.Model(user).UpdateColumns(map[string]interface{}{
+		"contract_start_date":      user.ContractStartDate,
+		"contract_end_date":        user.ContractEndDate,
+		"contract_domain":          user.ContractDomain,
+		"contract_hourly_rate_cap": user.ContractHourlyRateCap,
+		"contract_documents":       user.ContractDocuments,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return database.ErrUserNotFound
+	}
+
+	err := tx.Create(contractChange).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Return the contract changes of a user, oldest first.
+//
+// GetContractChanges satisfies the backend interface.
+func (c *cockroachdb) GetContractChanges(userID uint64) ([]database.ContractChange, error) {
+	log.Debugf("GetContractChanges: %v", userID)
+
+	var contractChanges []ContractChange
+	result := c.db.Where("user_id = ?", userID).Order("created_at asc").
+		Find(&contractChanges)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbContractChanges := make([]database.ContractChange, 0,
+		len(contractChanges))
+	for _, contractChange := range contractChanges {
+		dbContractChanges = append(dbContractChanges,
+			*DecodeContractChange(&contractChange))
+	}
+
+	return dbContractChanges, nil
+}
+
 // Create new invoice.
 //
 // CreateInvoice satisfies the backend interface.
@@ -266,6 +416,119 @@ func (c *cockroachdb) GetInvoiceByToken(token string) (*database.Invoice, error)
 	return DecodeInvoice(&invoice)
 }
 
+// Return the status changes of an invoice, oldest first.
+//
+// GetInvoiceChanges satisfies the backend interface.
+func (c *cockroachdb) GetInvoiceChanges(token string) ([]database.InvoiceChange, error) {
+	log.Debugf("GetInvoiceChanges: %v", token)
+
+	var invoiceChanges []InvoiceChange
+	result := c.db.Where("invoice_token = ?", token).Order(
+		"timestamp asc, id asc").Find(&invoiceChanges)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbInvoiceChanges := make([]database.InvoiceChange, 0, len(invoiceChanges))
+	for _, invoiceChange := range invoiceChanges {
+		dbInvoiceChanges = append(dbInvoiceChanges,
+			*DecodeInvoiceChange(&invoiceChange))
+	}
+
+	return dbInvoiceChanges, nil
+}
+
+// GetInvoiceLeadReviews satisfies the backend interface.
+func (c *cockroachdb) GetInvoiceLeadReviews(token string) ([]database.InvoiceLeadReview, error) {
+	log.Debugf("GetInvoiceLeadReviews: %v", token)
+
+	var invoiceLeadReviews []InvoiceLeadReview
+	result := c.db.Where("invoice_token = ?", token).Order(
+		"timestamp asc, id asc").Find(&invoiceLeadReviews)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbInvoiceLeadReviews := make([]database.InvoiceLeadReview, 0,
+		len(invoiceLeadReviews))
+	for _, invoiceLeadReview := range invoiceLeadReviews {
+		dbInvoiceLeadReviews = append(dbInvoiceLeadReviews,
+			*DecodeInvoiceLeadReview(&invoiceLeadReview))
+	}
+
+	return dbInvoiceLeadReviews, nil
+}
+
+// GetInvoiceComments satisfies the backend interface.
+func (c *cockroachdb) GetInvoiceComments(token string) ([]database.InvoiceComment, error) {
+	log.Debugf("GetInvoiceComments: %v", token)
+
+	var invoiceComments []InvoiceComment
+	result := c.db.Where("invoice_token = ?", token).Order(
+		"comment_id asc").Find(&invoiceComments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbInvoiceComments := make([]database.InvoiceComment, 0,
+		len(invoiceComments))
+	for _, invoiceComment := range invoiceComments {
+		dbInvoiceComments = append(dbInvoiceComments,
+			*DecodeInvoiceComment(&invoiceComment))
+	}
+
+	return dbInvoiceComments, nil
+}
+
+// Store a new invoice comment. The comment is assigned the next comment id
+// of the invoice; the unique index on the invoice token and comment id
+// ensures that comments added concurrently get different ids, by retrying
+// with the next id when it's taken.
+//
+// CreateInvoiceComment satisfies the backend interface.
+func (c *cockroachdb) CreateInvoiceComment(token string, dbInvoiceComment *database.InvoiceComment) error {
+	invoiceComment := EncodeInvoiceComment(dbInvoiceComment)
+	invoiceComment.InvoiceToken = token
+
+	log.Debugf("CreateInvoiceComment: %v", token)
+
+	for attempt := 1; ; attempt++ {
+		var lastCommentIDs []uint64
+		result := c.db.Unscoped().Model(&InvoiceComment{}).Where(
+			"invoice_token = ?", token).Pluck(
+			"coalesce(max(comment_id), 0)", &lastCommentIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		invoiceComment.ID = 0
+		invoiceComment.CommentID = lastCommentIDs[0] + 1
+		result = c.db.Create(invoiceComment)
+		if result.Error == nil {
+			break
+		}
+
+		pqErr, ok := result.Error.(*pq.Error)
+		if !ok || pqErr.Code != pqErrorUniqueViolation ||
+			attempt == maxCommentIDAttempts {
+			return result.Error
+		}
+	}
+
+	dbInvoiceComment.CommentID = invoiceComment.CommentID
+	return nil
+}
+
+// Delete an invoice comment.
+//
+// DeleteInvoiceComment satisfies the backend interface.
+func (c *cockroachdb) DeleteInvoiceComment(token string, commentID uint64) error {
+	log.Debugf("DeleteInvoiceComment: %v %v", token, commentID)
+
+	return c.db.Unscoped().Where("invoice_token = ? and comment_id = ?",
+		token, commentID).Delete(&InvoiceComment{}).Error
+}
+
 // Return a list of invoices.
 func (c *cockroachdb) GetInvoices(invoicesRequest database.InvoicesRequest) ([]database.Invoice, int, error) {
 	log.Debugf("GetInvoices")
@@ -287,6 +550,10 @@ func (c *cockroachdb) GetInvoices(invoicesRequest database.InvoicesRequest) ([]d
 		paramsMap["i.status"] = statuses
 	}
 
+	if invoicesRequest.Domain != v1.ContractDomainInvalid {
+		paramsMap["u.contract_domain"] = uint(invoicesRequest.Domain)
+	}
+
 	if invoicesRequest.Month != 0 {
 		paramsMap["i.month"] = invoicesRequest.Month
 	}
@@ -298,15 +565,64 @@ func (c *cockroachdb) GetInvoices(invoicesRequest database.InvoicesRequest) ([]d
 	tbl := fmt.Sprintf("%v i", tableNameInvoice)
 	sel := "i.*, u.username"
 	join := fmt.Sprintf("inner join %v u on i.user_id = u.id", tableNameUser)
-	order := "i.timestamp asc"
+	order := "i.timestamp asc, i.token asc"
+	if invoicesRequest.Newest {
+		order = "i.timestamp desc, i.token desc"
+	}
+
+	// Invoice periods are compared as year*12+month.
+	where := func(db *gorm.DB) *gorm.DB {
+		db = c.addWhereClause(db, paramsMap)
+		if invoicesRequest.FromYear != 0 {
+			from := int(invoicesRequest.FromYear)*12 +
+				int(invoicesRequest.FromMonth)
+			if invoicesRequest.FromMonth == 0 {
+				from++
+			}
+			db = db.Where("i.year * 12 + i.month >= ?", from)
+		}
+		if invoicesRequest.ToYear != 0 {
+			to := int(invoicesRequest.ToYear)*12 +
+				int(invoicesRequest.ToMonth)
+			if invoicesRequest.ToMonth == 0 {
+				to += 12
+			}
+			db = db.Where("i.year * 12 + i.month <= ?", to)
+		}
+		if invoicesRequest.SubmittedFrom != 0 {
+			db = db.Where("i.timestamp >= ?",
+				time.Unix(invoicesRequest.SubmittedFrom, 0))
+		}
+		if invoicesRequest.SubmittedTo != 0 {
+			db = db.Where("i.timestamp <= ?",
+				time.Unix(invoicesRequest.SubmittedTo, 0))
+		}
+		if invoicesRequest.Proposal != "" {
+			db = db.Where(fmt.Sprintf("exists (select 1 from %v c where "+
+				"c.invoice_token = i.token and c.proposal = ?)",
+				tableNameInvoiceProposalCost), invoicesRequest.Proposal)
+		}
+		return db
+	}
 
 	db := c.db.Table(tbl)
-	if invoicesRequest.Page > -1 {
+	if invoicesRequest.Limit > 0 {
+		db = db.Limit(invoicesRequest.Limit)
+		if invoicesRequest.AfterToken != "" {
+			cmp := ">"
+			if invoicesRequest.Newest {
+				cmp = "<"
+			}
+			db = db.Where("(i.timestamp, i.token) "+cmp+" (?, ?)",
+				time.Unix(invoicesRequest.AfterTime, 0),
+				invoicesRequest.AfterToken)
+		}
+	} else if invoicesRequest.Page > -1 {
 		offset := invoicesRequest.Page * v1.ListPageSize
 		db = db.Offset(offset).Limit(v1.ListPageSize)
 	}
 	db = db.Select(sel).Joins(join)
-	db = c.addWhereClause(db, paramsMap)
+	db = where(db)
 	db = db.Order(order)
 
 	var invoices []Invoice
@@ -321,15 +637,22 @@ func (c *cockroachdb) GetInvoices(invoicesRequest database.InvoicesRequest) ([]d
 	// If the number of users returned equals the apage size,
 	// find the count of all users that match the query.
 	numMatches := len(invoices)
-	if len(invoices) == v1.ListPageSize {
+	if len(invoices) == v1.ListPageSize || invoicesRequest.Limit > 0 {
 		db = c.db.Table(tbl).Select(sel).Joins(join)
-		db = c.addWhereClause(db, paramsMap)
+		db = where(db)
 		result = db.Count(&numMatches)
 		if result.Error != nil {
 			return nil, 0, result.Error
 		}
 	}
 
+	if invoicesRequest.IncludePayments && len(invoices) > 0 {
+		err = c.addInvoicePayments(invoices)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
 	dbInvoices, err := DecodeInvoices(invoices)
 	if err != nil {
 		return nil, 0, err
@@ -337,6 +660,28 @@ func (c *cockroachdb) GetInvoices(invoicesRequest database.InvoicesRequest) ([]d
 	return dbInvoices, numMatches, nil
 }
 
+// addInvoicePayments loads the payments of the invoices with a single query.
+func (c *cockroachdb) addInvoicePayments(invoices []Invoice) error {
+	tokens := make([]string, 0, len(invoices))
+	indexes := make(map[string]int, len(invoices))
+	for i, invoice := range invoices {
+		tokens = append(tokens, invoice.Token)
+		indexes[invoice.Token] = i
+	}
+
+	var payments []InvoicePayment
+	result := c.db.Where("invoice_token IN (?)", tokens).Find(&payments)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, payment := range payments {
+		i := indexes[payment.InvoiceToken]
+		invoices[i].Payments = append(invoices[i].Payments, payment)
+	}
+	return nil
+}
+
 func (c *cockroachdb) UpdateInvoicePayment(dbInvoicePayment *database.InvoicePayment) error {
 	invoicePayment := EncodeInvoicePayment(dbInvoicePayment)
 
@@ -345,13 +690,402 @@ func (c *cockroachdb) UpdateInvoicePayment(dbInvoicePayment *database.InvoicePay
 	return c.db.Save(invoicePayment).Error
 }
 
+// Store new API token.
+//
+// CreateAPIToken satisfies the backend interface.
+func (c *cockroachdb) CreateAPIToken(dbAPIToken *database.APIToken) error {
+	apiToken := EncodeAPIToken(dbAPIToken)
+	log.Debugf("CreateAPIToken: %v %v", apiToken.UserID, apiToken.Name)
+
+	err := c.db.Create(apiToken).Error
+	if err != nil {
+		return err
+	}
+
+	dbAPIToken.ID = uint64(apiToken.ID)
+	dbAPIToken.Timestamp = apiToken.CreatedAt.Unix()
+	return nil
+}
+
+// Update an existing API token.
+//
+// UpdateAPIToken satisfies the backend interface.
+func (c *cockroachdb) UpdateAPIToken(dbAPIToken *database.APIToken) error {
+	apiToken := EncodeAPIToken(dbAPIToken)
+	log.Debugf("UpdateAPIToken: %v", apiToken.ID)
+	return c.db.Model(&APIToken{}).Updates(*apiToken).Error
+}
+
+// GetAPITokenByHash returns an API token given the hash of the token, if
+// found in the database.
+//
+// GetAPITokenByHash satisfies the backend interface.
+func (c *cockroachdb) GetAPITokenByHash(hash string) (*database.APIToken, error) {
+	var apiToken APIToken
+	result := c.db.Where("token_hash = ?", hash).First(&apiToken)
+	if result.Error != nil {
+		if gorm.IsRecordNotFoundError(result.Error) {
+			return nil, database.ErrAPITokenNotFound
+		}
+		return nil, result.Error
+	}
+
+	return DecodeAPIToken(&apiToken), nil
+}
+
+// GetAPITokensByUser returns all API tokens owned by the given user.
+//
+// GetAPITokensByUser satisfies the backend interface.
+func (c *cockroachdb) GetAPITokensByUser(userID uint64) ([]database.APIToken, error) {
+	var apiTokens []APIToken
+	result := c.db.Where("user_id = ?", userID).Order("created_at asc").
+		Find(&apiTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbAPITokens := make([]database.APIToken, 0, len(apiTokens))
+	for _, apiToken := range apiTokens {
+		dbAPITokens = append(dbAPITokens, *DecodeAPIToken(&apiToken))
+	}
+
+	return dbAPITokens, nil
+}
+
+// DeleteAPIToken deletes an API token given its id.
+//
+// DeleteAPIToken satisfies the backend interface.
+func (c *cockroachdb) DeleteAPIToken(id uint64) error {
+	log.Debugf("DeleteAPIToken: %v", id)
+
+	result := c.db.Delete(&APIToken{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrAPITokenNotFound
+	}
+
+	return nil
+}
+
+// Create a new session or update an existing one.
+//
+// SaveSession satisfies the backend interface.
+func (c *cockroachdb) SaveSession(dbSession *database.Session) error {
+	session := EncodeSession(dbSession)
+	log.Debugf("SaveSession: %v %v", session.ID, session.UserID)
+
+	if session.ID != 0 {
+		return c.db.Model(&Session{}).Updates(*session).Error
+	}
+
+	err := c.db.Create(session).Error
+	if err != nil {
+		return err
+	}
+
+	dbSession.ID = uint64(session.ID)
+	dbSession.Timestamp = session.CreatedAt.Unix()
+	return nil
+}
+
+// GetSessionByKey returns a session given its key, if found in the database.
+//
+// GetSessionByKey satisfies the backend interface.
+func (c *cockroachdb) GetSessionByKey(key string) (*database.Session, error) {
+	var session Session
+	result := c.db.Where("key = ?", key).First(&session)
+	if result.Error != nil {
+		if gorm.IsRecordNotFoundError(result.Error) {
+			return nil, database.ErrSessionNotFound
+		}
+		return nil, result.Error
+	}
+
+	return DecodeSession(&session), nil
+}
+
+// GetSessionsByUser returns all sessions of the given user.
+//
+// GetSessionsByUser satisfies the backend interface.
+func (c *cockroachdb) GetSessionsByUser(userID uint64) ([]database.Session, error) {
+	var sessions []Session
+	result := c.db.Where("user_id = ?", userID).Order("last_seen desc").
+		Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbSessions := make([]database.Session, 0, len(sessions))
+	for _, session := range sessions {
+		dbSessions = append(dbSessions, *DecodeSession(&session))
+	}
+
+	return dbSessions, nil
+}
+
+// DeleteSession deletes a session given its id.
+//
+// DeleteSession satisfies the backend interface.
+func (c *cockroachdb) DeleteSession(id uint64) error {
+	log.Debugf("DeleteSession: %v", id)
+
+	result := c.db.Unscoped().Delete(&Session{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteSessionsByUser deletes all sessions of the given user, except the
+// sessions with the given ids.
+//
+// DeleteSessionsByUser satisfies the backend interface.
+func (c *cockroachdb) DeleteSessionsByUser(userID uint64, exceptIDs ...uint64) error {
+	log.Debugf("DeleteSessionsByUser: %v", userID)
+
+	db := c.db.Unscoped().Where("user_id = ?", userID)
+	if len(exceptIDs) > 0 {
+		db = db.Where("id not in (?)", exceptIDs)
+	}
+	return db.Delete(&Session{}).Error
+}
+
+// Create a new invoice draft or update an existing one. The draft is only
+// saved if it hasn't been created or changed by another request since it
+// was read, which is detected with its version.
+//
+// SaveInvoiceDraft satisfies the backend interface.
+func (c *cockroachdb) SaveInvoiceDraft(dbInvoiceDraft *database.InvoiceDraft) error {
+	invoiceDraft, err := EncodeInvoiceDraft(dbInvoiceDraft)
+	if err != nil {
+		return err
+	}
+	log.Debugf("SaveInvoiceDraft: %v %v %v", invoiceDraft.UserID,
+		invoiceDraft.Month, invoiceDraft.Year)
+
+	invoiceDraft.Version++
+	if invoiceDraft.ID == 0 {
+		err = c.db.Create(invoiceDraft).Error
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok &&
+				pqErr.Code == pqErrorUniqueViolation {
+				return database.ErrInvoiceDraftChanged
+			}
+			return err
+		}
+	} else {
+		// The columns are updated explicitly, so that the line items can
+		// be cleared.
+		invoiceDraft.UpdatedAt = time.Now()
+		result := c.db.Model(&InvoiceDraft{}).Where("id = ? and version = ?",
+			invoiceDraft.ID, dbInvoiceDraft.Version).UpdateColumns(
+			map[string]interface{}{
+				"line_items": invoiceDraft.LineItems,
+				"version":    invoiceDraft.Version,
+				"updated_at": invoiceDraft.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return database.ErrInvoiceDraftChanged
+		}
+	}
+
+	dbInvoiceDraft.ID = uint64(invoiceDraft.ID)
+	dbInvoiceDraft.Version = invoiceDraft.Version
+	dbInvoiceDraft.Timestamp = invoiceDraft.UpdatedAt.Unix()
+	return nil
+}
+
+// GetInvoiceDraft returns a user's invoice draft given the month and year,
+// if found in the database.
+//
+// GetInvoiceDraft satisfies the backend interface.
+func (c *cockroachdb) GetInvoiceDraft(userID uint64, month, year uint16) (*database.InvoiceDraft, error) {
+	var invoiceDraft InvoiceDraft
+	result := c.db.Where("user_id = ? and month = ? and year = ?", userID,
+		month, year).First(&invoiceDraft)
+	if result.Error != nil {
+		if gorm.IsRecordNotFoundError(result.Error) {
+			return nil, database.ErrInvoiceDraftNotFound
+		}
+		return nil, result.Error
+	}
+
+	return DecodeInvoiceDraft(&invoiceDraft)
+}
+
+// DeleteInvoiceDraft deletes a user's invoice draft given the month and
+// year.
+//
+// DeleteInvoiceDraft satisfies the backend interface.
+func (c *cockroachdb) DeleteInvoiceDraft(userID uint64, month, year uint16) error {
+	log.Debugf("DeleteInvoiceDraft: %v %v %v", userID, month, year)
+
+	// The draft is deleted permanently, so that a new draft can be created
+	// for the same month.
+	result := c.db.Unscoped().Where("user_id = ? and month = ? and year = ?",
+		userID, month, year).Delete(&InvoiceDraft{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrInvoiceDraftNotFound
+	}
+
+	return nil
+}
+
+// Create a new proposal budget or update the existing budget of the
+// proposal.
+//
+// SaveProposalBudget satisfies the backend interface.
+func (c *cockroachdb) SaveProposalBudget(dbProposalBudget *database.ProposalBudget) error {
+	proposalBudget := EncodeProposalBudget(dbProposalBudget)
+	log.Debugf("SaveProposalBudget: %v %v", proposalBudget.Token,
+		proposalBudget.BudgetUSD)
+
+	var existing ProposalBudget
+	result := c.db.Where("token = ?", proposalBudget.Token).First(&existing)
+	if result.Error != nil && !gorm.IsRecordNotFoundError(result.Error) {
+		return result.Error
+	}
+	proposalBudget.ID = existing.ID
+	proposalBudget.CreatedAt = existing.CreatedAt
+
+	err := c.db.Save(proposalBudget).Error
+	if err != nil {
+		return err
+	}
+
+	dbProposalBudget.ID = uint64(proposalBudget.ID)
+	dbProposalBudget.Timestamp = proposalBudget.UpdatedAt.Unix()
+	return nil
+}
+
+// GetProposalBudgets returns the budgets of all proposals.
+//
+// GetProposalBudgets satisfies the backend interface.
+func (c *cockroachdb) GetProposalBudgets() ([]database.ProposalBudget, error) {
+	var proposalBudgets []ProposalBudget
+	result := c.db.Order("token").Find(&proposalBudgets)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbProposalBudgets := make([]database.ProposalBudget, 0,
+		len(proposalBudgets))
+	for _, proposalBudget := range proposalBudgets {
+		dbProposalBudgets = append(dbProposalBudgets,
+			*DecodeProposalBudget(&proposalBudget))
+	}
+
+	return dbProposalBudgets, nil
+}
+
+// Replace the amounts billed against proposals by an invoice.
+//
+// SetInvoiceProposalCosts satisfies the backend interface.
+func (c *cockroachdb) SetInvoiceProposalCosts(token string, dbInvoiceProposalCosts []database.InvoiceProposalCost) error {
+	log.Debugf("SetInvoiceProposalCosts: %v", token)
+
+	tx := c.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	result := tx.Unscoped().Where("invoice_token = ?", token).Delete(
+		&InvoiceProposalCost{})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	for _, dbInvoiceProposalCost := range dbInvoiceProposalCosts {
+		invoiceProposalCost := EncodeInvoiceProposalCost(&dbInvoiceProposalCost)
+		invoiceProposalCost.InvoiceToken = token
+		result = tx.Create(invoiceProposalCost)
+		if result.Error != nil {
+			tx.Rollback()
+			return result.Error
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Return the amounts billed against proposals by the invoices of each month
+// and status.
+//
+// GetProposalCosts satisfies the backend interface.
+func (c *cockroachdb) GetProposalCosts(proposalCostsRequest database.ProposalCostsRequest) ([]database.ProposalCost, error) {
+	log.Debugf("GetProposalCosts")
+
+	db := c.db.Table(fmt.Sprintf("%v c", tableNameInvoiceProposalCost)).Select(
+		"c.proposal, i.month, i.year, i.status, " +
+			"sum(c.total_cost) as total_cost").Joins(
+		fmt.Sprintf("inner join %v i on c.invoice_token = i.token",
+			tableNameInvoice))
+	if len(proposalCostsRequest.Proposals) > 0 {
+		db = db.Where("c.proposal in (?)", proposalCostsRequest.Proposals)
+	}
+	if len(proposalCostsRequest.StatusMap) > 0 {
+		statuses := make([]uint, 0, len(proposalCostsRequest.StatusMap))
+		for k := range proposalCostsRequest.StatusMap {
+			statuses = append(statuses, uint(k))
+		}
+		db = db.Where("i.status in (?)", statuses)
+	}
+	db = db.Group("c.proposal, i.month, i.year, i.status")
+
+	var proposalCosts []struct {
+		Proposal  string
+		Month     uint
+		Year      uint
+		Status    uint
+		TotalCost uint64
+	}
+	result := db.Scan(&proposalCosts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	dbProposalCosts := make([]database.ProposalCost, 0, len(proposalCosts))
+	for _, proposalCost := range proposalCosts {
+		dbProposalCosts = append(dbProposalCosts, database.ProposalCost{
+			Proposal:  proposalCost.Proposal,
+			Month:     uint16(proposalCost.Month),
+			Year:      uint16(proposalCost.Year),
+			Status:    v1.InvoiceStatusT(proposalCost.Status),
+			TotalCost: proposalCost.TotalCost,
+		})
+	}
+
+	return dbProposalCosts, nil
+}
+
 // Deletes all data from all tables.
 //
 // DeleteAllData satisfies the backend interface.
 func (c *cockroachdb) DeleteAllData() error {
 	log.Debugf("DeleteAllData")
 
+	c.dropTable(tableNameInvoiceProposalCost)
+	c.dropTable(tableNameProposalBudget)
+	c.dropTable(tableNameInvoiceDraft)
+	c.dropTable(tableNameContractChange)
+	c.dropTable(tableNameSession)
+	c.dropTable(tableNameAPIToken)
 	c.dropTable(tableNameInvoicePayment)
+	c.dropTable(tableNameInvoiceComment)
+	c.dropTable(tableNameInvoiceLeadReview)
 	c.dropTable(tableNameInvoiceChange)
 	c.dropTable(tableNameInvoice)
 	c.dropTable(tableNameIdentity)
@@ -412,6 +1146,21 @@ func New(dataDir, dbName, username, host string) (*cockroachdb, error) {
 		return nil, fmt.Errorf("error dropping %v table: %v",
 			tableNameInvoiceChange, err)
 	}
+	err = c.dropTable(tableNameInvoiceComment)
+	if err != nil {
+		return nil, fmt.Errorf("error dropping %v table: %v",
+			tableNameInvoiceComment, err)
+	}
+	err = c.dropTable(tableNameInvoiceLeadReview)
+	if err != nil {
+		return nil, fmt.Errorf("error dropping %v table: %v",
+			tableNameInvoiceLeadReview, err)
+	}
+	err = c.dropTable(tableNameInvoiceProposalCost)
+	if err != nil {
+		return nil, fmt.Errorf("error dropping %v table: %v",
+			tableNameInvoiceProposalCost, err)
+	}
 	err = c.dropTable(tableNameInvoicePayment)
 	if err != nil {
 		return nil, fmt.Errorf("error dropping %v table: %v",
@@ -423,13 +1172,37 @@ func New(dataDir, dbName, username, host string) (*cockroachdb, error) {
 			err)
 	}
 
+	// Accounts used to be locked once they reached a number of failed
+	// login attempts, and are now locked by setting the locked column.
+	// The accounts which were locked that way are kept locked when the
+	// column is added.
+	addLocked := c.db.HasTable(tableNameUser) &&
+		!c.db.Dialect().HasColumn(tableNameUser, "locked")
+
 	c.db.AutoMigrate(
 		&User{},
 		&Identity{},
 		&Invoice{},
 		&InvoiceChange{},
+		&InvoiceLeadReview{},
+		&InvoiceComment{},
 		&InvoicePayment{},
+		&APIToken{},
+		&Session{},
+		&ContractChange{},
+		&InvoiceDraft{},
+		&ProposalBudget{},
+		&InvoiceProposalCost{},
 	)
 
+	if addLocked {
+		err = c.db.Exec(fmt.Sprintf("UPDATE %v SET locked = true WHERE "+
+			"failed_login_attempts >= ?;", tableNameUser),
+			v1.LoginAttemptsToLockUser).Error
+		if err != nil {
+			return nil, fmt.Errorf("error locking users: %v", err)
+		}
+	}
+
 	return &c, nil
 }
@@ -2,6 +2,8 @@ package cockroachdb
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
@@ -19,8 +21,25 @@ func EncodeUser(dbUser *database.User) *User {
 	user.ExtendedPublicKey = dbUser.ExtendedPublicKey
 	user.Admin = dbUser.Admin
 	user.FailedLoginAttempts = dbUser.FailedLoginAttempts
+	user.Locked = dbUser.Locked
 	user.PaymentAddressIndex = dbUser.PaymentAddressIndex
 	user.EmailNotifications = dbUser.EmailNotifications
+	user.TOTPLastUsedStep = dbUser.TOTPLastUsedStep
+	user.ContractDomain = uint(dbUser.ContractDomain)
+	user.ContractHourlyRateCap = dbUser.ContractHourlyRateCap
+	user.ContractDocuments = strings.Join(dbUser.ContractDocuments, ",")
+
+	// The roles are always valid so that removing all roles is persisted.
+	user.Roles.Valid = true
+	user.Roles.Int64 = int64(dbUser.Roles)
+
+	// The TOTP fields are always valid so that clearing them is persisted.
+	user.TOTPSecret.Valid = true
+	user.TOTPSecret.String = dbUser.TOTPSecret
+	user.TOTPVerified.Valid = true
+	user.TOTPVerified.Bool = dbUser.TOTPVerified
+	user.TOTPRecoveryCodes.Valid = true
+	user.TOTPRecoveryCodes.String = strings.Join(dbUser.TOTPRecoveryCodes, ",")
 
 	if len(dbUser.Username) > 0 {
 		user.Username.Valid = true
@@ -64,11 +83,53 @@ func EncodeUser(dbUser *database.User) *User {
 		user.UpdateExtendedPublicKeyVerificationExpiry.Time = time.Unix(dbUser.UpdateExtendedPublicKeyVerificationExpiry, 0)
 	}
 
+	if dbUser.ChangeEmailAddress != "" {
+		user.ChangeEmailAddress.Valid = true
+		user.ChangeEmailAddress.String = dbUser.ChangeEmailAddress
+
+		user.ChangeEmailOldVerificationToken.Valid = true
+		user.ChangeEmailOldVerificationToken.String = hex.EncodeToString(dbUser.ChangeEmailOldVerificationToken)
+
+		user.ChangeEmailNewVerificationToken.Valid = true
+		user.ChangeEmailNewVerificationToken.String = hex.EncodeToString(dbUser.ChangeEmailNewVerificationToken)
+
+		user.ChangeEmailVerificationExpiry.Valid = true
+		user.ChangeEmailVerificationExpiry.Time = time.Unix(dbUser.ChangeEmailVerificationExpiry, 0)
+	}
+
+	if dbUser.LoginChallenge != nil {
+		user.LoginChallenge.Valid = true
+		user.LoginChallenge.String = hex.EncodeToString(dbUser.LoginChallenge)
+
+		user.LoginChallengeExpiry.Valid = true
+		user.LoginChallengeExpiry.Time = time.Unix(dbUser.LoginChallengeExpiry, 0)
+	}
+
 	if dbUser.LastLogin != 0 {
 		user.LastLogin.Valid = true
 		user.LastLogin.Time = time.Unix(dbUser.LastLogin, 0)
 	}
 
+	if dbUser.EndDate != 0 {
+		user.EndDate.Valid = true
+		user.EndDate.Time = time.Unix(dbUser.EndDate, 0)
+	}
+
+	if dbUser.LastFailedLogin != 0 {
+		user.LastFailedLogin.Valid = true
+		user.LastFailedLogin.Time = time.Unix(dbUser.LastFailedLogin, 0)
+	}
+
+	if dbUser.ContractStartDate != 0 {
+		user.ContractStartDate.Valid = true
+		user.ContractStartDate.Time = time.Unix(dbUser.ContractStartDate, 0)
+	}
+
+	if dbUser.ContractEndDate != 0 {
+		user.ContractEndDate.Valid = true
+		user.ContractEndDate.Time = time.Unix(dbUser.ContractEndDate, 0)
+	}
+
 	for _, dbID := range dbUser.Identities {
 		user.Identities = append(user.Identities, *EncodeIdentity(&dbID))
 	}
@@ -79,16 +140,31 @@ func EncodeUser(dbUser *database.User) *User {
 // DecodeUser decodes a cockroachdb User instance into a generic database.User.
 func DecodeUser(user *User) (*database.User, error) {
 	dbUser := database.User{
-		ID:                  uint64(user.ID),
-		Email:               user.Email,
-		Username:            user.Username.String,
-		Name:                user.Name,
-		Location:            user.Location,
-		ExtendedPublicKey:   user.ExtendedPublicKey,
-		Admin:               user.Admin,
-		FailedLoginAttempts: user.FailedLoginAttempts,
-		PaymentAddressIndex: user.PaymentAddressIndex,
-		EmailNotifications:  user.EmailNotifications,
+		ID:                    uint64(user.ID),
+		Email:                 user.Email,
+		Username:              user.Username.String,
+		Name:                  user.Name,
+		Location:              user.Location,
+		ExtendedPublicKey:     user.ExtendedPublicKey,
+		Admin:                 user.Admin,
+		FailedLoginAttempts:   user.FailedLoginAttempts,
+		Locked:                user.Locked,
+		PaymentAddressIndex:   user.PaymentAddressIndex,
+		EmailNotifications:    user.EmailNotifications,
+		Roles:                 v1.UserRoleT(user.Roles.Int64),
+		TOTPSecret:            user.TOTPSecret.String,
+		TOTPVerified:          user.TOTPVerified.Bool,
+		TOTPLastUsedStep:      user.TOTPLastUsedStep,
+		ContractDomain:        v1.ContractDomainT(user.ContractDomain),
+		ContractHourlyRateCap: user.ContractHourlyRateCap,
+	}
+
+	if user.TOTPRecoveryCodes.String != "" {
+		dbUser.TOTPRecoveryCodes = strings.Split(user.TOTPRecoveryCodes.String, ",")
+	}
+
+	if user.ContractDocuments != "" {
+		dbUser.ContractDocuments = strings.Split(user.ContractDocuments, ",")
 	}
 
 	var err error
@@ -132,10 +208,50 @@ func DecodeUser(user *User) (*database.User, error) {
 		dbUser.UpdateExtendedPublicKeyVerificationExpiry = user.UpdateExtendedPublicKeyVerificationExpiry.Time.Unix()
 	}
 
+	if user.ChangeEmailAddress.Valid {
+		dbUser.ChangeEmailAddress = user.ChangeEmailAddress.String
+
+		dbUser.ChangeEmailOldVerificationToken, err = hex.DecodeString(user.ChangeEmailOldVerificationToken.String)
+		if err != nil {
+			return nil, err
+		}
+
+		dbUser.ChangeEmailNewVerificationToken, err = hex.DecodeString(user.ChangeEmailNewVerificationToken.String)
+		if err != nil {
+			return nil, err
+		}
+
+		dbUser.ChangeEmailVerificationExpiry = user.ChangeEmailVerificationExpiry.Time.Unix()
+	}
+
+	if user.LoginChallenge.Valid {
+		dbUser.LoginChallenge, err = hex.DecodeString(user.LoginChallenge.String)
+		if err != nil {
+			return nil, err
+		}
+		dbUser.LoginChallengeExpiry = user.LoginChallengeExpiry.Time.Unix()
+	}
+
 	if user.LastLogin.Valid {
 		dbUser.LastLogin = user.LastLogin.Time.Unix()
 	}
 
+	if user.EndDate.Valid {
+		dbUser.EndDate = user.EndDate.Time.Unix()
+	}
+
+	if user.LastFailedLogin.Valid {
+		dbUser.LastFailedLogin = user.LastFailedLogin.Time.Unix()
+	}
+
+	if user.ContractStartDate.Valid {
+		dbUser.ContractStartDate = user.ContractStartDate.Time.Unix()
+	}
+
+	if user.ContractEndDate.Valid {
+		dbUser.ContractEndDate = user.ContractEndDate.Time.Unix()
+	}
+
 	for _, id := range user.Identities {
 		dbID, err := DecodeIdentity(&id)
 		if err != nil {
@@ -219,8 +335,10 @@ func EncodeInvoice(dbInvoice *database.Invoice) *Invoice {
 	invoice.PublicKey = dbInvoice.PublicKey
 	invoice.UserSignature = dbInvoice.UserSignature
 	invoice.ServerSignature = dbInvoice.ServerSignature
+	invoice.Merkle = dbInvoice.Merkle
 	invoice.Proposal = dbInvoice.Proposal
 	invoice.Version = dbInvoice.Version
+	invoice.Late = dbInvoice.Late
 
 	for _, dbInvoiceChange := range dbInvoice.Changes {
 		invoiceChange := EncodeInvoiceChange(&dbInvoiceChange)
@@ -235,6 +353,18 @@ func EncodeInvoice(dbInvoice *database.Invoice) *Invoice {
 		invoice.Payments = append(invoice.Payments, *invoicePayment)
 	}
 
+	for _, dbInvoiceLeadReview := range dbInvoice.LeadReviews {
+		invoiceLeadReview := EncodeInvoiceLeadReview(&dbInvoiceLeadReview)
+		invoiceLeadReview.InvoiceToken = invoice.Token
+		invoice.LeadReviews = append(invoice.LeadReviews, *invoiceLeadReview)
+	}
+
+	for _, dbInvoiceComment := range dbInvoice.Comments {
+		invoiceComment := EncodeInvoiceComment(&dbInvoiceComment)
+		invoiceComment.InvoiceToken = invoice.Token
+		invoice.Comments = append(invoice.Comments, *invoiceComment)
+	}
+
 	return &invoice
 }
 
@@ -244,12 +374,45 @@ func EncodeInvoiceChange(dbInvoiceChange *database.InvoiceChange) *InvoiceChange
 	invoiceChange := InvoiceChange{}
 
 	invoiceChange.AdminPublicKey = dbInvoiceChange.AdminPublicKey
+	invoiceChange.AdminSignature = dbInvoiceChange.AdminSignature
 	invoiceChange.NewStatus = uint(dbInvoiceChange.NewStatus)
+	invoiceChange.ApprovalFor = uint(dbInvoiceChange.ApprovalFor)
 	invoiceChange.Timestamp = time.Unix(dbInvoiceChange.Timestamp, 0)
 
 	return &invoiceChange
 }
 
+// EncodeInvoiceLeadReview encodes a generic database.InvoiceLeadReview instance
+// into a cockroachdb InvoiceLeadReview.
+func EncodeInvoiceLeadReview(dbInvoiceLeadReview *database.InvoiceLeadReview) *InvoiceLeadReview {
+	invoiceLeadReview := InvoiceLeadReview{}
+
+	invoiceLeadReview.LeadPublicKey = dbInvoiceLeadReview.LeadPublicKey
+	invoiceLeadReview.LeadSignature = dbInvoiceLeadReview.LeadSignature
+	invoiceLeadReview.Action = uint(dbInvoiceLeadReview.Action)
+	invoiceLeadReview.Reason = dbInvoiceLeadReview.Reason
+	invoiceLeadReview.InvoiceVersion = dbInvoiceLeadReview.InvoiceVersion
+	invoiceLeadReview.Timestamp = time.Unix(dbInvoiceLeadReview.Timestamp, 0)
+
+	return &invoiceLeadReview
+}
+
+// EncodeInvoiceComment encodes a generic database.InvoiceComment instance into
+// a cockroachdb InvoiceComment.
+func EncodeInvoiceComment(dbInvoiceComment *database.InvoiceComment) *InvoiceComment {
+	invoiceComment := InvoiceComment{}
+
+	invoiceComment.CommentID = dbInvoiceComment.CommentID
+	invoiceComment.ParentID = dbInvoiceComment.ParentID
+	invoiceComment.LineItem = dbInvoiceComment.LineItem
+	invoiceComment.PublicKey = dbInvoiceComment.PublicKey
+	invoiceComment.Signature = dbInvoiceComment.Signature
+	invoiceComment.Comment = dbInvoiceComment.Comment
+	invoiceComment.Timestamp = time.Unix(dbInvoiceComment.Timestamp, 0)
+
+	return &invoiceComment
+}
+
 // EncodeInvoicePayment encodes a generic database.InvoicePayment instance into a cockroachdb
 // InvoicePayment.
 func EncodeInvoicePayment(dbInvoicePayment *database.InvoicePayment) *InvoicePayment {
@@ -289,8 +452,10 @@ func DecodeInvoice(invoice *Invoice) (*database.Invoice, error) {
 	dbInvoice.PublicKey = invoice.PublicKey
 	dbInvoice.UserSignature = invoice.UserSignature
 	dbInvoice.ServerSignature = invoice.ServerSignature
+	dbInvoice.Merkle = invoice.Merkle
 	dbInvoice.Proposal = invoice.Proposal
 	dbInvoice.Version = invoice.Version
+	dbInvoice.Late = invoice.Late
 	/*
 		for _, invoiceChange := range invoice.Changes {
 			dbInvoiceChange := DecodeInvoiceChange(&invoiceChange)
@@ -311,12 +476,45 @@ func DecodeInvoiceChange(invoiceChange *InvoiceChange) *database.InvoiceChange {
 	dbInvoiceChange := database.InvoiceChange{}
 
 	dbInvoiceChange.AdminPublicKey = invoiceChange.AdminPublicKey
+	dbInvoiceChange.AdminSignature = invoiceChange.AdminSignature
 	dbInvoiceChange.NewStatus = v1.InvoiceStatusT(invoiceChange.NewStatus)
+	dbInvoiceChange.ApprovalFor = v1.InvoiceStatusT(invoiceChange.ApprovalFor)
 	dbInvoiceChange.Timestamp = invoiceChange.Timestamp.Unix()
 
 	return &dbInvoiceChange
 }
 
+// DecodeInvoiceLeadReview decodes a cockroachdb InvoiceLeadReview instance
+// into a generic database.InvoiceLeadReview.
+func DecodeInvoiceLeadReview(invoiceLeadReview *InvoiceLeadReview) *database.InvoiceLeadReview {
+	dbInvoiceLeadReview := database.InvoiceLeadReview{}
+
+	dbInvoiceLeadReview.LeadPublicKey = invoiceLeadReview.LeadPublicKey
+	dbInvoiceLeadReview.LeadSignature = invoiceLeadReview.LeadSignature
+	dbInvoiceLeadReview.Action = v1.LeadReviewActionT(invoiceLeadReview.Action)
+	dbInvoiceLeadReview.Reason = invoiceLeadReview.Reason
+	dbInvoiceLeadReview.InvoiceVersion = invoiceLeadReview.InvoiceVersion
+	dbInvoiceLeadReview.Timestamp = invoiceLeadReview.Timestamp.Unix()
+
+	return &dbInvoiceLeadReview
+}
+
+// DecodeInvoiceComment decodes a cockroachdb InvoiceComment instance into a
+// generic database.InvoiceComment.
+func DecodeInvoiceComment(invoiceComment *InvoiceComment) *database.InvoiceComment {
+	dbInvoiceComment := database.InvoiceComment{}
+
+	dbInvoiceComment.CommentID = invoiceComment.CommentID
+	dbInvoiceComment.ParentID = invoiceComment.ParentID
+	dbInvoiceComment.LineItem = invoiceComment.LineItem
+	dbInvoiceComment.PublicKey = invoiceComment.PublicKey
+	dbInvoiceComment.Signature = invoiceComment.Signature
+	dbInvoiceComment.Comment = invoiceComment.Comment
+	dbInvoiceComment.Timestamp = invoiceComment.Timestamp.Unix()
+
+	return &dbInvoiceComment
+}
+
 // DecodeInvoicePayment decodes a cockroachdb InvoicePayment instance into a
 // generic database.InvoicePayment.
 func DecodeInvoicePayment(invoicePayment *InvoicePayment) *database.InvoicePayment {
@@ -350,3 +548,211 @@ func DecodeInvoices(invoices []Invoice) ([]database.Invoice, error) {
 
 	return dbInvoices, nil
 }
+
+// EncodeAPIToken encodes a generic database.APIToken instance into a
+// cockroachdb APIToken.
+func EncodeAPIToken(dbAPIToken *database.APIToken) *APIToken {
+	apiToken := APIToken{}
+
+	apiToken.ID = uint(dbAPIToken.ID)
+	apiToken.UserID = uint(dbAPIToken.UserID)
+	apiToken.Name = dbAPIToken.Name
+	apiToken.TokenHash = dbAPIToken.TokenHash
+	apiToken.Scopes = uint64(dbAPIToken.Scopes)
+	apiToken.Expiry = time.Unix(dbAPIToken.Expiry, 0)
+
+	if dbAPIToken.LastUsed != 0 {
+		apiToken.LastUsed.Valid = true
+		apiToken.LastUsed.Time = time.Unix(dbAPIToken.LastUsed, 0)
+	}
+
+	return &apiToken
+}
+
+// DecodeAPIToken decodes a cockroachdb APIToken instance into a generic
+// database.APIToken.
+func DecodeAPIToken(apiToken *APIToken) *database.APIToken {
+	dbAPIToken := database.APIToken{}
+
+	dbAPIToken.ID = uint64(apiToken.ID)
+	dbAPIToken.UserID = uint64(apiToken.UserID)
+	dbAPIToken.Name = apiToken.Name
+	dbAPIToken.TokenHash = apiToken.TokenHash
+	dbAPIToken.Scopes = v1.APITokenScopeT(apiToken.Scopes)
+	dbAPIToken.Timestamp = apiToken.CreatedAt.Unix()
+	dbAPIToken.Expiry = apiToken.Expiry.Unix()
+
+	if apiToken.LastUsed.Valid {
+		dbAPIToken.LastUsed = apiToken.LastUsed.Time.Unix()
+	}
+
+	return &dbAPIToken
+}
+
+// EncodeContractChange encodes a generic database.ContractChange instance
+// into a cockroachdb ContractChange.
+func EncodeContractChange(dbContractChange *database.ContractChange) *ContractChange {
+	contractChange := ContractChange{}
+
+	contractChange.ID = uint(dbContractChange.ID)
+	contractChange.UserID = uint(dbContractChange.UserID)
+	contractChange.AdminUserID = uint(dbContractChange.AdminUserID)
+	contractChange.Domain = uint(dbContractChange.Domain)
+	contractChange.HourlyRateCap = dbContractChange.HourlyRateCap
+	contractChange.Documents = strings.Join(dbContractChange.Documents, ",")
+	contractChange.Reason = dbContractChange.Reason
+
+	if dbContractChange.StartDate != 0 {
+		contractChange.StartDate.Valid = true
+		contractChange.StartDate.Time = time.Unix(dbContractChange.StartDate, 0)
+	}
+
+	if dbContractChange.EndDate != 0 {
+		contractChange.EndDate.Valid = true
+		contractChange.EndDate.Time = time.Unix(dbContractChange.EndDate, 0)
+	}
+
+	return &contractChange
+}
+
+// DecodeContractChange decodes a cockroachdb ContractChange instance into a
+// generic database.ContractChange.
+func DecodeContractChange(contractChange *ContractChange) *database.ContractChange {
+	dbContractChange := database.ContractChange{}
+
+	dbContractChange.ID = uint64(contractChange.ID)
+	dbContractChange.UserID = uint64(contractChange.UserID)
+	dbContractChange.AdminUserID = uint64(contractChange.AdminUserID)
+	dbContractChange.Domain = v1.ContractDomainT(contractChange.Domain)
+	dbContractChange.HourlyRateCap = contractChange.HourlyRateCap
+	dbContractChange.Reason = contractChange.Reason
+	dbContractChange.Timestamp = contractChange.CreatedAt.Unix()
+
+	if contractChange.Documents != "" {
+		dbContractChange.Documents = strings.Split(contractChange.Documents, ",")
+	}
+
+	if contractChange.StartDate.Valid {
+		dbContractChange.StartDate = contractChange.StartDate.Time.Unix()
+	}
+
+	if contractChange.EndDate.Valid {
+		dbContractChange.EndDate = contractChange.EndDate.Time.Unix()
+	}
+
+	return &dbContractChange
+}
+
+// EncodeSession encodes a generic database.Session instance into a
+// cockroachdb Session.
+func EncodeSession(dbSession *database.Session) *Session {
+	session := Session{}
+
+	session.ID = uint(dbSession.ID)
+	session.Key = dbSession.Key
+	session.UserID = uint(dbSession.UserID)
+	session.Values = dbSession.Values
+	session.IPAddress = dbSession.IPAddress
+	session.UserAgent = dbSession.UserAgent
+	session.LastSeen = time.Unix(dbSession.LastSeen, 0)
+	session.Expiry = time.Unix(dbSession.Expiry, 0)
+
+	return &session
+}
+
+// DecodeSession decodes a cockroachdb Session instance into a generic
+// database.Session.
+func DecodeSession(session *Session) *database.Session {
+	dbSession := database.Session{}
+
+	dbSession.ID = uint64(session.ID)
+	dbSession.Key = session.Key
+	dbSession.UserID = uint64(session.UserID)
+	dbSession.Values = session.Values
+	dbSession.IPAddress = session.IPAddress
+	dbSession.UserAgent = session.UserAgent
+	dbSession.Timestamp = session.CreatedAt.Unix()
+	dbSession.LastSeen = session.LastSeen.Unix()
+	dbSession.Expiry = session.Expiry.Unix()
+
+	return &dbSession
+}
+
+// EncodeInvoiceDraft encodes a generic database.InvoiceDraft instance into a
+// cockroachdb InvoiceDraft.
+func EncodeInvoiceDraft(dbInvoiceDraft *database.InvoiceDraft) (*InvoiceDraft, error) {
+	invoiceDraft := InvoiceDraft{}
+
+	invoiceDraft.ID = uint(dbInvoiceDraft.ID)
+	invoiceDraft.UserID = uint(dbInvoiceDraft.UserID)
+	invoiceDraft.Month = uint(dbInvoiceDraft.Month)
+	invoiceDraft.Year = uint(dbInvoiceDraft.Year)
+	invoiceDraft.Version = dbInvoiceDraft.Version
+
+	lineItems, err := json.Marshal(dbInvoiceDraft.LineItems)
+	if err != nil {
+		return nil, err
+	}
+	invoiceDraft.LineItems = string(lineItems)
+
+	return &invoiceDraft, nil
+}
+
+// DecodeInvoiceDraft decodes a cockroachdb InvoiceDraft instance into a
+// generic database.InvoiceDraft.
+func DecodeInvoiceDraft(invoiceDraft *InvoiceDraft) (*database.InvoiceDraft, error) {
+	dbInvoiceDraft := database.InvoiceDraft{}
+
+	dbInvoiceDraft.ID = uint64(invoiceDraft.ID)
+	dbInvoiceDraft.UserID = uint64(invoiceDraft.UserID)
+	dbInvoiceDraft.Month = uint16(invoiceDraft.Month)
+	dbInvoiceDraft.Year = uint16(invoiceDraft.Year)
+	dbInvoiceDraft.Version = invoiceDraft.Version
+	dbInvoiceDraft.Timestamp = invoiceDraft.UpdatedAt.Unix()
+
+	if invoiceDraft.LineItems != "" {
+		err := json.Unmarshal([]byte(invoiceDraft.LineItems),
+			&dbInvoiceDraft.LineItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &dbInvoiceDraft, nil
+}
+
+// EncodeProposalBudget encodes a generic database.ProposalBudget instance
+// into a cockroachdb ProposalBudget.
+func EncodeProposalBudget(dbProposalBudget *database.ProposalBudget) *ProposalBudget {
+	proposalBudget := ProposalBudget{}
+
+	proposalBudget.ID = uint(dbProposalBudget.ID)
+	proposalBudget.Token = dbProposalBudget.Token
+	proposalBudget.BudgetUSD = dbProposalBudget.BudgetUSD
+
+	return &proposalBudget
+}
+
+// DecodeProposalBudget decodes a cockroachdb ProposalBudget instance into a
+// generic database.ProposalBudget.
+func DecodeProposalBudget(proposalBudget *ProposalBudget) *database.ProposalBudget {
+	dbProposalBudget := database.ProposalBudget{}
+
+	dbProposalBudget.ID = uint64(proposalBudget.ID)
+	dbProposalBudget.Token = proposalBudget.Token
+	dbProposalBudget.BudgetUSD = proposalBudget.BudgetUSD
+	dbProposalBudget.Timestamp = proposalBudget.UpdatedAt.Unix()
+
+	return &dbProposalBudget
+}
+
+// EncodeInvoiceProposalCost encodes a generic database.InvoiceProposalCost
+// instance into a cockroachdb InvoiceProposalCost.
+func EncodeInvoiceProposalCost(dbInvoiceProposalCost *database.InvoiceProposalCost) *InvoiceProposalCost {
+	invoiceProposalCost := InvoiceProposalCost{}
+
+	invoiceProposalCost.Proposal = dbInvoiceProposalCost.Proposal
+	invoiceProposalCost.TotalCost = dbInvoiceProposalCost.TotalCost
+
+	return &invoiceProposalCost
+}
@@ -10,11 +10,19 @@ import (
 )
 
 const (
-	tableNameUser           = "users"
-	tableNameIdentity       = "identities"
-	tableNameInvoice        = "invoices"
-	tableNameInvoiceChange  = "invoice_changes"
-	tableNameInvoicePayment = "invoice_payments"
+	tableNameUser                = "users"
+	tableNameIdentity            = "identities"
+	tableNameInvoice             = "invoices"
+	tableNameInvoiceChange       = "invoice_changes"
+	tableNameInvoiceLeadReview   = "invoice_lead_reviews"
+	tableNameInvoiceComment      = "invoice_comments"
+	tableNameInvoicePayment      = "invoice_payments"
+	tableNameAPIToken            = "api_tokens"
+	tableNameSession             = "sessions"
+	tableNameContractChange      = "contract_changes"
+	tableNameInvoiceDraft        = "invoice_drafts"
+	tableNameProposalBudget      = "proposal_budgets"
+	tableNameInvoiceProposalCost = "invoice_proposal_costs"
 )
 
 type User struct {
@@ -26,6 +34,7 @@ type User struct {
 	Location                                  string `gorm:"not_null"`
 	ExtendedPublicKey                         string `gorm:"not_null"`
 	Admin                                     bool   `gorm:"not_null"`
+	Roles                                     sql.NullInt64
 	RegisterVerificationToken                 sql.NullString
 	RegisterVerificationExpiry                pq.NullTime
 	UpdateIdentityVerificationToken           sql.NullString
@@ -34,10 +43,28 @@ type User struct {
 	ResetPasswordVerificationExpiry           pq.NullTime
 	UpdateExtendedPublicKeyVerificationToken  sql.NullString
 	UpdateExtendedPublicKeyVerificationExpiry pq.NullTime
+	ChangeEmailAddress                        sql.NullString
+	ChangeEmailOldVerificationToken           sql.NullString
+	ChangeEmailNewVerificationToken           sql.NullString
+	ChangeEmailVerificationExpiry             pq.NullTime
+	LoginChallenge                            sql.NullString
+	LoginChallengeExpiry                      pq.NullTime
 	LastLogin                                 pq.NullTime
+	LastFailedLogin                           pq.NullTime
 	FailedLoginAttempts                       uint64 `gorm:"not_null"`
+	Locked                                    bool   `gorm:"not_null"`
+	EndDate                                   pq.NullTime
 	PaymentAddressIndex                       uint64 `gorm:"not_null"`
 	EmailNotifications                        uint64 `gorm:"not_null"`
+	TOTPSecret                                sql.NullString
+	TOTPVerified                              sql.NullBool
+	TOTPRecoveryCodes                         sql.NullString
+	TOTPLastUsedStep                          uint64 `gorm:"not_null"`
+	ContractStartDate                         pq.NullTime
+	ContractEndDate                           pq.NullTime
+	ContractDomain                            uint   `gorm:"not_null"`
+	ContractHourlyRateCap                     uint64 `gorm:"not_null"`
+	ContractDocuments                         string `gorm:"type:text"`
 
 	Identities []Identity
 	Invoices   []Invoice
@@ -74,11 +101,15 @@ type Invoice struct {
 	PublicKey          string `gorm:"not_null"`
 	UserSignature      string `gorm:"not_null"`
 	ServerSignature    string `gorm:"not_null"`
+	Merkle             string
 	Proposal           string
 	Version            string
+	Late               bool `gorm:"not_null"`
 
-	Changes  []InvoiceChange
-	Payments []InvoicePayment
+	Changes     []InvoiceChange
+	Payments    []InvoicePayment
+	LeadReviews []InvoiceLeadReview
+	Comments    []InvoiceComment
 
 	// gorm.Model fields, included manually
 	CreatedAt time.Time
@@ -94,7 +125,9 @@ type InvoiceChange struct {
 	gorm.Model
 	InvoiceToken   string
 	AdminPublicKey string
+	AdminSignature string
 	NewStatus      uint
+	ApprovalFor    uint
 	Timestamp      time.Time
 }
 
@@ -102,6 +135,37 @@ func (i InvoiceChange) TableName() string {
 	return tableNameInvoiceChange
 }
 
+type InvoiceLeadReview struct {
+	gorm.Model
+	InvoiceToken   string
+	LeadPublicKey  string `gorm:"not_null"`
+	LeadSignature  string `gorm:"not_null"`
+	Action         uint   `gorm:"not_null"`
+	Reason         string
+	InvoiceVersion string
+	Timestamp      time.Time
+}
+
+func (i InvoiceLeadReview) TableName() string {
+	return tableNameInvoiceLeadReview
+}
+
+type InvoiceComment struct {
+	gorm.Model
+	InvoiceToken string `gorm:"unique_index:idx_invoice_comments_token_comment_id"`
+	CommentID    uint64 `gorm:"unique_index:idx_invoice_comments_token_comment_id;not_null"`
+	ParentID     uint64 `gorm:"not_null"`
+	LineItem     uint   `gorm:"not_null"`
+	PublicKey    string `gorm:"not_null"`
+	Signature    string `gorm:"not_null"`
+	Comment      string `gorm:"type:text"`
+	Timestamp    time.Time
+}
+
+func (i InvoiceComment) TableName() string {
+	return tableNameInvoiceComment
+}
+
 type InvoicePayment struct {
 	gorm.Model
 	InvoiceToken string
@@ -116,3 +180,82 @@ type InvoicePayment struct {
 func (i InvoicePayment) TableName() string {
 	return tableNameInvoicePayment
 }
+
+type APIToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"not_null"`
+	Name      string    `gorm:"not_null"`
+	TokenHash string    `gorm:"unique_index;not_null"`
+	Scopes    uint64    `gorm:"not_null"`
+	Expiry    time.Time `gorm:"not_null"`
+	LastUsed  pq.NullTime
+}
+
+func (t APIToken) TableName() string {
+	return tableNameAPIToken
+}
+
+type Session struct {
+	gorm.Model
+	Key       string `gorm:"unique_index;not_null"`
+	UserID    uint   `gorm:"index"`
+	Values    string `gorm:"type:text"`
+	IPAddress string
+	UserAgent string
+	LastSeen  time.Time `gorm:"not_null"`
+	Expiry    time.Time `gorm:"not_null"`
+}
+
+func (s Session) TableName() string {
+	return tableNameSession
+}
+
+type ContractChange struct {
+	gorm.Model
+	UserID        uint `gorm:"index;not_null"`
+	AdminUserID   uint `gorm:"not_null"`
+	StartDate     pq.NullTime
+	EndDate       pq.NullTime
+	Domain        uint   `gorm:"not_null"`
+	HourlyRateCap uint64 `gorm:"not_null"`
+	Documents     string `gorm:"type:text"`
+	Reason        string
+}
+
+func (c ContractChange) TableName() string {
+	return tableNameContractChange
+}
+
+type InvoiceDraft struct {
+	gorm.Model
+	UserID    uint   `gorm:"unique_index:idx_invoice_drafts_user_month;not_null"`
+	Month     uint   `gorm:"unique_index:idx_invoice_drafts_user_month;not_null"`
+	Year      uint   `gorm:"unique_index:idx_invoice_drafts_user_month;not_null"`
+	LineItems string `gorm:"type:text"` // JSON-encoded values of the line items
+	Version   uint64 `gorm:"not_null;default:0"`
+}
+
+func (i InvoiceDraft) TableName() string {
+	return tableNameInvoiceDraft
+}
+
+type ProposalBudget struct {
+	gorm.Model
+	Token     string `gorm:"unique_index;not_null"`
+	BudgetUSD uint64 `gorm:"not_null"`
+}
+
+func (p ProposalBudget) TableName() string {
+	return tableNameProposalBudget
+}
+
+type InvoiceProposalCost struct {
+	gorm.Model
+	InvoiceToken string `gorm:"index;not_null"`
+	Proposal     string `gorm:"index;not_null"`
+	TotalCost    uint64 `gorm:"not_null"`
+}
+
+func (i InvoiceProposalCost) TableName() string {
+	return tableNameInvoiceProposalCost
+}
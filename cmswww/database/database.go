@@ -26,36 +26,121 @@ var (
 
 	// ErrInvalidEmail indicates that a user's email is not properly formatted.
 	ErrInvalidEmail = errors.New("invalid user email")
+
+	// ErrAPITokenNotFound indicates that the API token was not found in the
+	// database.
+	ErrAPITokenNotFound = errors.New("api token not found")
+
+	// ErrSessionNotFound indicates that the session was not found in the
+	// database.
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrInvoiceDraftNotFound indicates that the invoice draft was not found
+	// in the database.
+	ErrInvoiceDraftNotFound = errors.New("invoice draft not found")
+
+	// ErrInvoiceDraftChanged indicates that the invoice draft was changed
+	// by another request since it was read.
+	ErrInvoiceDraftChanged = errors.New("invoice draft changed")
 )
 
 // InvoicesRequest is used for passing parameters into the
 // GetInvoices() function.
 type InvoicesRequest struct {
 	UserID    string
+	Domain    v1.ContractDomainT // Only invoices of users in this contract domain
 	Month     uint16
 	Year      uint16
 	StatusMap map[v1.InvoiceStatusT]bool
 	Page      int
+
+	// IncludePayments loads the payments of the returned invoices.
+	IncludePayments bool
+
+	// Invoice period range, both ends inclusive. A year without a month
+	// covers the whole year.
+	FromMonth uint16
+	FromYear  uint16
+	ToMonth   uint16
+	ToYear    uint16
+
+	SubmittedFrom int64  // Only invoices submitted at or after this Unix timestamp
+	SubmittedTo   int64  // Only invoices submitted at or before this Unix timestamp
+	Proposal      string // Only invoices which bill against this proposal
+
+	// Keyset pagination: when Limit is set, at most Limit invoices which
+	// sort after the given timestamp and token are returned instead of a
+	// page, and the count returned is that of all matching invoices.
+	Newest     bool // Sort the most recently submitted invoices first
+	AfterTime  int64
+	AfterToken string
+	Limit      int
+}
+
+// UsersRequest is used for passing parameters into the
+// GetUsers() function.
+type UsersRequest struct {
+	Username           string
+	Domain             v1.ContractDomainT
+	ContractEndsBefore int64
+	Page               int
 }
 
 // Database interface that is required by the web server.
 type Database interface {
 	// User functions
-	CreateUser(*User) error                                  // Create new user
-	UpdateUser(*User) error                                  // Update existing user
-	GetUserByEmail(string) (*User, error)                    // Return user record given the email address
-	GetUserByUsername(string) (*User, error)                 // Return user record given the username
-	GetUserById(uint64) (*User, error)                       // Return user record given its id
-	GetUserIdByPublicKey(string) (uint64, error)             // Return user id by public key
-	GetAllUsers(callbackFn func(u *User)) error              // Iterate all users
-	GetUsers(username string, page int) ([]User, int, error) // Returns a list of users and total count that match the provided username.
+	CreateUser(*User) error                       // Create new user
+	UpdateUser(*User) error                       // Update existing user
+	ChangeUserEmail(uint64, string, string) error // Atomically change a user's email address
+	GetUserByEmail(string) (*User, error)         // Return user record given the email address
+	GetUserByUsername(string) (*User, error)      // Return user record given the username
+	GetUserById(uint64) (*User, error)            // Return user record given its id
+	GetUserIdByPublicKey(string) (uint64, error)  // Return user id by public key
+	GetAllUsers(callbackFn func(u *User)) error   // Iterate all users
+	GetUsers(UsersRequest) ([]User, int, error)   // Returns a list of users and total count that match the provided filters.
+
+	// Contract functions
+	UpdateUserContract(*User, *ContractChange) error     // Update a user's contract and record the change
+	GetContractChanges(uint64) ([]ContractChange, error) // Return a user's contract changes, oldest first
 
 	// Invoice functions
-	CreateInvoice(*Invoice) error                        // Create new invoice
-	UpdateInvoice(*Invoice) error                        // Update existing invoice
-	GetInvoiceByToken(string) (*Invoice, error)          // Return invoice given its token
-	GetInvoices(InvoicesRequest) ([]Invoice, int, error) // Return a list of invoices
-	UpdateInvoicePayment(*InvoicePayment) error          // Update an existing invoice's payment
+	CreateInvoice(*Invoice) error                              // Create new invoice
+	UpdateInvoice(*Invoice) error                              // Update existing invoice
+	GetInvoiceByToken(string) (*Invoice, error)                // Return invoice given its token
+	GetInvoices(InvoicesRequest) ([]Invoice, int, error)       // Return a list of invoices
+	GetInvoiceChanges(string) ([]InvoiceChange, error)         // Return an invoice's status changes, oldest first
+	GetInvoiceLeadReviews(string) ([]InvoiceLeadReview, error) // Return an invoice's domain lead reviews, oldest first
+	GetInvoiceComments(string) ([]InvoiceComment, error)       // Return an invoice's comments, oldest first
+	CreateInvoiceComment(string, *InvoiceComment) error        // Create new invoice comment and assign its comment id
+	DeleteInvoiceComment(string, uint64) error                 // Delete an invoice comment given the invoice token and comment id
+	UpdateInvoicePayment(*InvoicePayment) error                // Update an existing invoice's payment
+
+	// API token functions
+	CreateAPIToken(*APIToken) error                // Create new API token
+	UpdateAPIToken(*APIToken) error                // Update existing API token
+	GetAPITokenByHash(string) (*APIToken, error)   // Return API token given the hash of the token
+	GetAPITokensByUser(uint64) ([]APIToken, error) // Return all API tokens owned by a user
+	DeleteAPIToken(uint64) error                   // Delete an API token given its id
+
+	// Session functions
+	SaveSession(*Session) error                   // Create or update a session
+	GetSessionByKey(string) (*Session, error)     // Return session given its key
+	GetSessionsByUser(uint64) ([]Session, error)  // Return all sessions of a user
+	DeleteSession(uint64) error                   // Delete a session given its id
+	DeleteSessionsByUser(uint64, ...uint64) error // Delete all sessions of a user, except the given ones
+
+	// Invoice draft functions
+	SaveInvoiceDraft(*InvoiceDraft) error                          // Create or update an invoice draft, unless it changed since it was read
+	GetInvoiceDraft(uint64, uint16, uint16) (*InvoiceDraft, error) // Return a user's invoice draft given the month and year
+	DeleteInvoiceDraft(uint64, uint16, uint16) error               // Delete a user's invoice draft given the month and year
+
+	// Proposal budget functions
+	SaveProposalBudget(*ProposalBudget) error      // Create or update the budget of a proposal
+	GetProposalBudgets() ([]ProposalBudget, error) // Return the budgets of all proposals
+
+	// Proposal cost functions
+	SetInvoiceProposalCosts(string, []InvoiceProposalCost) error   // Replace the amounts an invoice bills against proposals
+	GetProposalCosts(ProposalCostsRequest) ([]ProposalCost, error) // Return the amounts billed against proposals per month and invoice status
 
 	DeleteAllData() error // Delete all data from all tables
 
@@ -73,6 +158,7 @@ type User struct {
 	ExtendedPublicKey                         string
 	HashedPassword                            []byte
 	Admin                                     bool
+	Roles                                     v1.UserRoleT
 	RegisterVerificationToken                 []byte
 	RegisterVerificationExpiry                int64
 	UpdateIdentityVerificationToken           []byte
@@ -81,10 +167,28 @@ type User struct {
 	ResetPasswordVerificationExpiry           int64
 	UpdateExtendedPublicKeyVerificationToken  []byte
 	UpdateExtendedPublicKeyVerificationExpiry int64
+	ChangeEmailAddress                        string // New email address awaiting verification
+	ChangeEmailOldVerificationToken           []byte // Token sent to the current email address
+	ChangeEmailNewVerificationToken           []byte // Token sent to the new email address
+	ChangeEmailVerificationExpiry             int64
+	LoginChallenge                            []byte
+	LoginChallengeExpiry                      int64
 	LastLogin                                 int64
+	LastFailedLogin                           int64
 	FailedLoginAttempts                       uint64
+	Locked                                    bool  // Whether an admin has locked the account
+	EndDate                                   int64 // Last day of work of a deactivated contractor, 0 if active
 	PaymentAddressIndex                       uint64
 	EmailNotifications                        uint64
+	TOTPSecret                                string   // Base32 encoded TOTP secret, empty if not set
+	TOTPVerified                              bool     // Whether the TOTP secret has been verified
+	TOTPRecoveryCodes                         []string // Hashes of the unused recovery codes
+	TOTPLastUsedStep                          uint64   // Time step of the last accepted code
+	ContractStartDate                         int64    // First day of the contract, 0 if not set
+	ContractEndDate                           int64    // Last day of the contract, 0 if not set
+	ContractDomain                            v1.ContractDomainT
+	ContractHourlyRateCap                     uint64   // Maximum hourly rate in USD, 0 if not set
+	ContractDocuments                         []string // References to the contract documents
 
 	Identities []Identity
 }
@@ -112,11 +216,15 @@ type Invoice struct {
 	PublicKey          string
 	UserSignature      string
 	ServerSignature    string
+	Merkle             string // Merkle root of the digests of the invoice file and its attachments
 	Proposal           string // Optional link to a Politeia proposal
 	Version            string // Version number of this invoice
+	Late               bool   // Whether the invoice was submitted after the deadline
 
-	Changes  []InvoiceChange
-	Payments []InvoicePayment
+	Changes     []InvoiceChange
+	Payments    []InvoicePayment
+	LeadReviews []InvoiceLeadReview
+	Comments    []InvoiceComment
 }
 
 type File struct {
@@ -127,11 +235,50 @@ type File struct {
 
 type InvoiceChange struct {
 	AdminPublicKey string
+	AdminSignature string
 	NewStatus      v1.InvoiceStatusT
+	ApprovalFor    v1.InvoiceStatusT // Status being approved, if NewStatus is awaiting approval
 	Reason         string
 	Timestamp      int64
 }
 
+// InvoiceLeadReview is a first-level review of an invoice by a lead of the
+// invoice author's domain.
+type InvoiceLeadReview struct {
+	LeadPublicKey  string
+	LeadSignature  string
+	Action         v1.LeadReviewActionT
+	Reason         string
+	InvoiceVersion string // Version of the invoice that was reviewed
+	Timestamp      int64
+}
+
+// InvoiceComment is a comment on an invoice, or a reply to another comment.
+type InvoiceComment struct {
+	CommentID uint64 // Sequential ID within the invoice, starting at 1
+	ParentID  uint64 // ID of the comment being replied to, or 0
+	LineItem  uint   // Line item being commented on, starting at 1, or 0
+	PublicKey string
+	Signature string
+	Comment   string
+	Timestamp int64
+}
+
+// ContractChange is a change made by an admin to a user's contract; it
+// contains the contract terms after the change.
+type ContractChange struct {
+	ID            uint64
+	UserID        uint64
+	AdminUserID   uint64
+	StartDate     int64
+	EndDate       int64
+	Domain        v1.ContractDomainT
+	HourlyRateCap uint64
+	Documents     []string
+	Reason        string
+	Timestamp     int64
+}
+
 type InvoicePayment struct {
 	ID           uint64
 	InvoiceToken string
@@ -143,10 +290,100 @@ type InvoicePayment struct {
 	TxID         string
 }
 
+// APIToken is a long-lived token which authenticates as a user for the
+// routes covered by its scopes.
+type APIToken struct {
+	ID        uint64
+	UserID    uint64
+	Name      string
+	TokenHash string // SHA-256 hash of the token
+	Scopes    v1.APITokenScopeT
+	Timestamp int64 // Creation time
+	Expiry    int64
+	LastUsed  int64
+}
+
+// InvoiceDraft is an invoice which a user is building up over a month,
+// before submitting it.
+type InvoiceDraft struct {
+	ID        uint64
+	UserID    uint64
+	Month     uint16
+	Year      uint16
+	LineItems [][]string // Values of each line item, in the order of the invoice policy fields
+	Version   uint64     // Incremented each time the draft is saved
+	Timestamp int64      // Last update time
+}
+
+// ProposalBudget is the budget, registered by an admin, which the line items
+// billed against a Politeia proposal are tracked against.
+type ProposalBudget struct {
+	ID        uint64
+	Token     string // Censorship token of the proposal
+	BudgetUSD uint64
+	Timestamp int64 // Last update time
+}
+
+// InvoiceProposalCost is the amount billed against a proposal by the line
+// items of an invoice.
+type InvoiceProposalCost struct {
+	Proposal  string // Censorship token of the proposal
+	TotalCost uint64 // in USD
+}
+
+// ProposalCostsRequest is used for passing parameters into the
+// GetProposalCosts() function.
+type ProposalCostsRequest struct {
+	Proposals []string // Censorship tokens of the proposals, or all if empty
+	StatusMap map[v1.InvoiceStatusT]bool
+}
+
+// ProposalCost is the amount billed against a proposal by the invoices of a
+// month which have the same status.
+type ProposalCost struct {
+	Proposal  string
+	Month     uint16
+	Year      uint16
+	Status    v1.InvoiceStatusT
+	TotalCost uint64 // in USD
+}
+
+// Session is a login session of a user. The key is the random identifier
+// stored in the session cookie.
+type Session struct {
+	ID        uint64
+	Key       string
+	UserID    uint64 // 0 if no user is logged in
+	Values    string // Encoded session values
+	IPAddress string
+	UserAgent string
+	Timestamp int64 // Creation time
+	LastSeen  int64
+	Expiry    int64
+}
+
 func (id *Identity) IsActive() bool {
 	return id.Activated != 0 && id.Deactivated == 0
 }
 
+// TOTPEnabled returns whether the user has enabled two-factor
+// authentication.
+func (u *User) TOTPEnabled() bool {
+	return u.TOTPSecret != "" && u.TOTPVerified
+}
+
+// HasRole returns whether the user is an admin or has any of the given
+// roles.
+func (u *User) HasRole(roles v1.UserRoleT) bool {
+	return u.Admin || u.Roles&roles != 0
+}
+
+// IsDeactivated returns whether the user has been deactivated as a
+// contractor.
+func (u *User) IsDeactivated() bool {
+	return u.EndDate != 0
+}
+
 func (u *User) IsVerified() bool {
 	return u.RegisterVerificationToken != nil && len(u.RegisterVerificationToken) > 0
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+const (
+	// deactivationCheckInterval is how often the identities of deactivated
+	// contractors are checked for expiry.
+	deactivationCheckInterval = time.Hour
+)
+
+// isActiveInMonth returns whether the user works in the given month, which
+// is the case for every month up to and including the month of a deactivated
+// contractor's end date.
+func isActiveInMonth(user *database.User, month, year uint16) bool {
+	if !user.IsDeactivated() {
+		return true
+	}
+
+	end := time.Unix(user.EndDate, 0).UTC()
+	return int(year) < end.Year() ||
+		(int(year) == end.Year() && time.Month(month) <= end.Month())
+}
+
+// finalInvoiceDeadline returns the deadline for the final invoice of a
+// deactivated contractor, after which its identities are deactivated.
+func (c *cmswww) finalInvoiceDeadline(user *database.User) time.Time {
+	end := time.Unix(user.EndDate, 0).UTC()
+	return c.invoiceDeadline(uint16(end.Month()), uint16(end.Year()))
+}
+
+// isPastFinalInvoiceDeadline returns whether the user has been deactivated
+// and the deadline for its final invoice has passed.
+func (c *cmswww) isPastFinalInvoiceDeadline(user *database.User, now time.Time) bool {
+	return user.IsDeactivated() && !now.Before(c.finalInvoiceDeadline(user))
+}
+
+// checkUserNotDeactivated returns an error if the user has been deactivated
+// and can no longer submit invoices.
+func (c *cmswww) checkUserNotDeactivated(user *database.User) error {
+	if c.isPastFinalInvoiceDeadline(user, time.Now()) {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusUserDeactivated,
+		}
+	}
+
+	return nil
+}
+
+// deactivateUserIdentities deactivates all of the identities of a
+// deactivated contractor once the deadline for its final invoice has passed.
+func (c *cmswww) deactivateUserIdentities(user *database.User, now time.Time) error {
+	if !c.isPastFinalInvoiceDeadline(user, now) {
+		return nil
+	}
+
+	deactivated := false
+	for k, id := range user.Identities {
+		if id.Deactivated == 0 {
+			user.Identities[k].Deactivated = now.Unix()
+			deactivated = true
+		}
+	}
+	if !deactivated {
+		return nil
+	}
+
+	log.Infof("Deactivating identities of %v, whose end date was %v",
+		user.Email, time.Unix(user.EndDate, 0).UTC().Format("2006-01-02"))
+	return c.db.UpdateUser(user)
+}
+
+// deactivateExpiredIdentities deactivates the identities of all deactivated
+// contractors whose final invoice deadline has passed.
+func (c *cmswww) deactivateExpiredIdentities(now time.Time) {
+	var ids []uint64
+	err := c.db.GetAllUsers(func(user *database.User) {
+		if c.isPastFinalInvoiceDeadline(user, now) {
+			ids = append(ids, user.ID)
+		}
+	})
+	if err != nil {
+		log.Errorf("cannot fetch deactivated users: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		// The identities aren't loaded along with all users.
+		user, err := c.db.GetUserById(id)
+		if err != nil {
+			log.Errorf("cannot fetch user %v: %v", id, err)
+			continue
+		}
+
+		err = c.deactivateUserIdentities(user, now)
+		if err != nil {
+			log.Errorf("cannot deactivate identities of %v: %v",
+				user.Email, err)
+		}
+	}
+}
+
+func (c *cmswww) checkForDeactivatedUsers() {
+	for {
+		c.deactivateExpiredIdentities(time.Now())
+		time.Sleep(deactivationCheckInterval)
+	}
+}
+
+func (c *cmswww) initUserDeactivation() {
+	// Start the thread that deactivates the identities of contractors
+	// who have left.
+	go c.checkForDeactivatedUsers()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// invoiceDeadline returns the time after which an invoice for the given
+// month and year is considered late; it's the end of the configured
+// deadline day in the following month.
+func (c *cmswww) invoiceDeadline(month, year uint16) time.Time {
+	return time.Date(int(year), time.Month(month)+1,
+		int(c.cfg.InvoiceDeadlineDay)+1, 0, 0, 0, 0, time.UTC)
+}
+
+// isInvoiceLate returns whether an invoice for the given month and year
+// which was submitted at the given timestamp missed the deadline.
+func (c *cmswww) isInvoiceLate(month, year uint16, submitted int64) bool {
+	return !time.Unix(submitted, 0).Before(c.invoiceDeadline(month, year))
+}
+
+// isActiveContractor returns whether the user has completed registration
+// and is able to submit invoices.
+func isActiveContractor(user *database.User) bool {
+	return !user.Admin && !user.IsVerified() &&
+		len(user.HashedPassword) > 0 && !IsUserLocked(user)
+}
+
+// getContractorsMissingInvoice returns all active contractors who have not
+// submitted an invoice for the given month and year. Deactivated contractors
+// are only included up to the month of their end date.
+func (c *cmswww) getContractorsMissingInvoice(month, year uint16) ([]database.User, error) {
+	invoices, _, err := c.db.GetInvoices(database.InvoicesRequest{
+		Month: month,
+		Year:  year,
+		Page:  -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	submitted := make(map[uint64]bool, len(invoices))
+	for _, invoice := range invoices {
+		if invoice.Status != v1.InvoiceStatusWithdrawn {
+			submitted[invoice.UserID] = true
+		}
+	}
+
+	var users []database.User
+	err = c.db.GetAllUsers(func(user *database.User) {
+		if isActiveContractor(user) && isActiveInMonth(user, month, year) &&
+			isInContractPeriod(user, month, year) && !submitted[user.ID] {
+			users = append(users, *user)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// nextInvoiceReminderTime returns the start of the next configured
+// reminder day after the given time.
+func (c *cmswww) nextInvoiceReminderTime(now time.Time) time.Time {
+	now = now.UTC()
+
+	var next time.Time
+	for _, monthOffset := range []int{0, 1} {
+		for _, day := range c.cfg.InvoiceReminderDays {
+			t := time.Date(now.Year(), now.Month()+time.Month(monthOffset),
+				int(day), 0, 0, 0, 0, time.UTC)
+			if !t.After(now) {
+				continue
+			}
+
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+	}
+
+	return next
+}
+
+// sendInvoiceReminders emails every active contractor who has not submitted
+// an invoice for the month prior to the given time.
+func (c *cmswww) sendInvoiceReminders(now time.Time) {
+	prevMonth := now.UTC().AddDate(0, 0, -now.UTC().Day())
+	month := uint16(prevMonth.Month())
+	year := uint16(prevMonth.Year())
+
+	users, err := c.getContractorsMissingInvoice(month, year)
+	if err != nil {
+		log.Errorf("cannot fetch contractors missing invoices: %v", err)
+		return
+	}
+
+	deadline := c.invoiceDeadline(month, year)
+	for _, user := range users {
+		err := c.emailInvoiceReminder(&user, month, year, deadline)
+		if err != nil {
+			log.Errorf("email invoice reminder to %v: %v", user.Email, err)
+		}
+	}
+
+	log.Infof("Sent %v invoice reminders for %v/%v", len(users), month,
+		year)
+}
+
+func (c *cmswww) checkForMissingInvoices() {
+	for {
+		next := c.nextInvoiceReminderTime(time.Now())
+		time.Sleep(time.Until(next))
+
+		c.sendInvoiceReminders(next)
+	}
+}
+
+func (c *cmswww) initInvoiceReminders() {
+	if c.cfg.SMTP == nil {
+		log.Infof("Email server not set up, invoice reminders are disabled")
+		return
+	}
+
+	// Start the thread that reminds contractors to submit invoices.
+	go c.checkForMissingInvoices()
+}
+
+// HandleMissingInvoices returns the list of active contractors who have not
+// submitted an invoice for the given month and year.
+func (c *cmswww) HandleMissingInvoices(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	mi := req.(*v1.MissingInvoices)
+
+	if mi.Month < 1 || mi.Month > 12 || mi.Year == 0 {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInput,
+		}
+	}
+
+	users, err := c.getContractorsMissingInvoice(mi.Month, mi.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	mir := v1.MissingInvoicesReply{
+		Deadline: c.invoiceDeadline(mi.Month, mi.Year).Unix(),
+		Users:    make([]v1.AbridgedUser, 0, len(users)),
+	}
+	for _, user := range users {
+		mir.Users = append(mir.Users, v1.AbridgedUser{
+			ID:       strconv.FormatUint(user.ID, 10),
+			Email:    user.Email,
+			Username: user.Username,
+			Admin:    user.Admin,
+		})
+	}
+
+	// Sort results alphabetically.
+	sort.Slice(mir.Users, func(i, j int) bool {
+		return mir.Users[i].Username < mir.Users[j].Username
+	})
+
+	return &mir, nil
+}
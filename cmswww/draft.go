@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/politeia/util"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// maxDraftUpdateAttempts is the number of times a change to an invoice draft
+// is applied when the draft is changed concurrently by other requests.
+const maxDraftUpdateAttempts = 5
+
+// validateDraftMonth returns an error if the month and year of a draft are
+// invalid.
+func validateDraftMonth(month, year uint16) error {
+	if month < 1 || month > 12 || year == 0 {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid month"},
+		}
+	}
+
+	return nil
+}
+
+// validateDraftLineItem validates the values of a line item against the
+// invoice policy fields, trimming them in place, and then validates it like
+// a line item of a submitted invoice.
+func (c *cmswww) validateDraftLineItem(user *database.User, lineItem []string, index int) error {
+	if len(lineItem) != len(v1.InvoiceFields) {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{fmt.Sprintf("a line item must have %v "+
+				"fields", len(v1.InvoiceFields))},
+		}
+	}
+
+	for idx, field := range v1.InvoiceFields {
+		value := strings.TrimSpace(lineItem[idx])
+		lineItem[idx] = value
+
+		if value == "" {
+			if field.Required {
+				return v1.UserError{
+					ErrorCode: v1.ErrorStatusInvalidInput,
+					ErrorContext: []string{fmt.Sprintf("%v is required",
+						field.Name)},
+				}
+			}
+			continue
+		}
+
+		if field.Type == v1.InvoiceFieldTypeUint {
+			_, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return v1.UserError{
+					ErrorCode: v1.ErrorStatusInvalidInput,
+					ErrorContext: []string{fmt.Sprintf("%v must be a "+
+						"number", field.Name)},
+				}
+			}
+		}
+	}
+
+	err := validateLineItem(user, index, lineItem)
+	if err != nil {
+		return err
+	}
+
+	return c.validateLineItemProposal(index, lineItem)
+}
+
+// createInvoiceDraftFile creates the invoice file which the draft is
+// submitted as. It's formatted like the files created by the CLI: a
+// comment with the month, a comment with the field names, and a line per
+// line item.
+func createInvoiceDraftFile(dbDraft *database.InvoiceDraft) (*v1.File, error) {
+	var buf bytes.Buffer
+
+	t := time.Date(int(dbDraft.Year), time.Month(dbDraft.Month), 1, 0, 0, 0,
+		0, time.UTC)
+	fmt.Fprintf(&buf, "%v %v\n", string(v1.PolicyInvoiceCommentChar),
+		t.Format("2006-01"))
+
+	fieldNames := make([]string, 0, len(v1.InvoiceFields))
+	for _, field := range v1.InvoiceFields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	fmt.Fprintf(&buf, "%v %v\n", string(v1.PolicyInvoiceCommentChar),
+		strings.Join(fieldNames, ", "))
+
+	csvWriter := csv.NewWriter(&buf)
+	csvWriter.Comma = v1.PolicyInvoiceFieldDelimiterChar
+	csvWriter.UseCRLF = false
+	err := csvWriter.WriteAll(dbDraft.LineItems)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.File{
+		Digest:  hex.EncodeToString(util.Digest(buf.Bytes())),
+		Payload: base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+func convertDatabaseInvoiceDraftToDraft(dbDraft *database.InvoiceDraft) (*v1.InvoiceDraft, error) {
+	file, err := createInvoiceDraftFile(dbDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItems := dbDraft.LineItems
+	if lineItems == nil {
+		lineItems = make([][]string, 0)
+	}
+
+	return &v1.InvoiceDraft{
+		Month:     dbDraft.Month,
+		Year:      dbDraft.Year,
+		LineItems: lineItems,
+		File:      *file,
+		Timestamp: dbDraft.Timestamp,
+	}, nil
+}
+
+// getInvoiceDraft returns the user's invoice draft for the month, or a new
+// empty draft if there isn't one yet.
+func (c *cmswww) getInvoiceDraft(user *database.User, month, year uint16) (*database.InvoiceDraft, error) {
+	err := validateDraftMonth(month, year)
+	if err != nil {
+		return nil, err
+	}
+
+	dbDraft, err := c.db.GetInvoiceDraft(user.ID, month, year)
+	if err == database.ErrInvoiceDraftNotFound {
+		return &database.InvoiceDraft{
+			UserID: user.ID,
+			Month:  month,
+			Year:   year,
+		}, nil
+	}
+
+	return dbDraft, err
+}
+
+// validateDraftLineItemIndex returns an error if the draft doesn't have the
+// given line item, numbered from 1.
+func validateDraftLineItemIndex(dbDraft *database.InvoiceDraft, index uint) error {
+	if index < 1 || index > uint(len(dbDraft.LineItems)) {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid line item"},
+		}
+	}
+
+	return nil
+}
+
+// updateInvoiceDraft applies a change to the user's invoice draft for the
+// month and saves it. If the draft is changed by another request in the
+// meantime, the change is applied again to the latest draft.
+func (c *cmswww) updateInvoiceDraft(
+	user *database.User,
+	month, year uint16,
+	update func(dbDraft *database.InvoiceDraft) error,
+) (*database.InvoiceDraft, error) {
+	for attempt := 1; ; attempt++ {
+		dbDraft, err := c.getInvoiceDraft(user, month, year)
+		if err != nil {
+			return nil, err
+		}
+
+		err = update(dbDraft)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.db.SaveInvoiceDraft(dbDraft)
+		if err == nil {
+			return dbDraft, nil
+		}
+		if err != database.ErrInvoiceDraftChanged ||
+			attempt == maxDraftUpdateAttempts {
+			return nil, err
+		}
+	}
+}
+
+// HandleInvoiceDraftDetails returns the user's invoice draft for a month.
+func (c *cmswww) HandleInvoiceDraftDetails(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	idd := req.(*v1.InvoiceDraftDetails)
+
+	dbDraft, err := c.getInvoiceDraft(user, idd.Month, idd.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := convertDatabaseInvoiceDraftToDraft(dbDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.InvoiceDraftDetailsReply{
+		Draft: *draft,
+	}, nil
+}
+
+// HandleNewDraftLineItem adds a line item to the user's invoice draft for a
+// month.
+func (c *cmswww) HandleNewDraftLineItem(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ndli := req.(*v1.NewDraftLineItem)
+
+	dbDraft, err := c.updateInvoiceDraft(user, ndli.Month, ndli.Year,
+		func(dbDraft *database.InvoiceDraft) error {
+			err := c.validateDraftLineItem(user, ndli.LineItem,
+				len(dbDraft.LineItems)+1)
+			if err != nil {
+				return err
+			}
+
+			dbDraft.LineItems = append(dbDraft.LineItems, ndli.LineItem)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := convertDatabaseInvoiceDraftToDraft(dbDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.NewDraftLineItemReply{
+		Draft: *draft,
+	}, nil
+}
+
+// HandleEditDraftLineItem replaces a line item of the user's invoice draft
+// for a month.
+func (c *cmswww) HandleEditDraftLineItem(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	edli := req.(*v1.EditDraftLineItem)
+
+	dbDraft, err := c.updateInvoiceDraft(user, edli.Month, edli.Year,
+		func(dbDraft *database.InvoiceDraft) error {
+			err := validateDraftLineItemIndex(dbDraft, edli.Index)
+			if err != nil {
+				return err
+			}
+
+			err = c.validateDraftLineItem(user, edli.LineItem,
+				int(edli.Index))
+			if err != nil {
+				return err
+			}
+
+			dbDraft.LineItems[edli.Index-1] = edli.LineItem
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := convertDatabaseInvoiceDraftToDraft(dbDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.EditDraftLineItemReply{
+		Draft: *draft,
+	}, nil
+}
+
+// HandleDeleteDraftLineItem removes a line item from the user's invoice
+// draft for a month.
+func (c *cmswww) HandleDeleteDraftLineItem(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ddli := req.(*v1.DeleteDraftLineItem)
+
+	dbDraft, err := c.updateInvoiceDraft(user, ddli.Month, ddli.Year,
+		func(dbDraft *database.InvoiceDraft) error {
+			err := validateDraftLineItemIndex(dbDraft, ddli.Index)
+			if err != nil {
+				return err
+			}
+
+			dbDraft.LineItems = append(dbDraft.LineItems[:ddli.Index-1],
+				dbDraft.LineItems[ddli.Index:]...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := convertDatabaseInvoiceDraftToDraft(dbDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.DeleteDraftLineItemReply{
+		Draft: *draft,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// getInvoiceDatePaid returns the time at which the invoice was marked as
+// paid, falling back to the submission time if the change isn't recorded.
+func (c *cmswww) getInvoiceDatePaid(dbInvoice *database.Invoice) (int64, error) {
+	changes, err := c.db.GetInvoiceChanges(dbInvoice.Token)
+	if err != nil {
+		return 0, err
+	}
+
+	datePaid := dbInvoice.Timestamp
+	for _, change := range changes {
+		if change.NewStatus == v1.InvoiceStatusPaid {
+			datePaid = change.Timestamp
+		}
+	}
+
+	return datePaid, nil
+}
+
+// createEarningsInvoice returns the paid invoice as it's listed in an
+// earnings statement. The amounts and the rate are derived from the
+// invoice's payments.
+func (c *cmswww) createEarningsInvoice(dbInvoice *database.Invoice, datePaid int64) (*v1.EarningsInvoice, error) {
+	err := c.fetchInvoiceFileIfNecessary(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	invoiceReview, err := c.createInvoiceReview(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	ei := v1.EarningsInvoice{
+		Token:        dbInvoice.Token,
+		Month:        dbInvoice.Month,
+		Year:         dbInvoice.Year,
+		DatePaid:     datePaid,
+		TotalCostUSD: invoiceReview.TotalCostUSD,
+		TotalCostDCR: getInvoicePaidDCR(dbInvoice),
+		TxIDs:        make([]string, 0, len(dbInvoice.Payments)),
+	}
+	if ei.TotalCostDCR != 0 {
+		ei.USDDCRRate = float64(ei.TotalCostUSD) / ei.TotalCostDCR
+	}
+	for _, payment := range dbInvoice.Payments {
+		if payment.TxID != "" {
+			ei.TxIDs = append(ei.TxIDs, payment.TxID)
+		}
+	}
+
+	return &ei, nil
+}
+
+// HandleUserEarnings returns a signed earnings statement of the invoices of
+// a user which were paid in the given year.
+func (c *cmswww) HandleUserEarnings(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ue := req.(*v1.UserEarnings)
+
+	if ue.Year == 0 {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid year"},
+		}
+	}
+
+	// Only users who can view all invoices can fetch the statements of
+	// other users.
+	targetUser := user
+	if ue.UserID != "" && ue.UserID != strconv.FormatUint(user.ID, 10) {
+		if !hasPermission(user, permissionViewInvoices) {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusUserNotFound,
+			}
+		}
+
+		var err error
+		targetUser, err = c.findUser(ue.UserID, "", "", true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Invoices can be paid in the year after the month they're for, so all
+	// paid invoices are checked, including those whose payment is disputed.
+	invoices, _, err := c.db.GetInvoices(database.InvoicesRequest{
+		UserID: strconv.FormatUint(targetUser.ID, 10),
+		StatusMap: map[v1.InvoiceStatusT]bool{
+			v1.InvoiceStatusPaid:     true,
+			v1.InvoiceStatusDisputed: true,
+		},
+		Page:            -1,
+		IncludePayments: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := v1.EarningsStatement{
+		UserID:    strconv.FormatUint(targetUser.ID, 10),
+		Username:  targetUser.Username,
+		Year:      ue.Year,
+		Timestamp: time.Now().Unix(),
+		Invoices:  make([]v1.EarningsInvoice, 0),
+	}
+	for i := range invoices {
+		invoice := &invoices[i]
+		datePaid, err := c.getInvoiceDatePaid(invoice)
+		if err != nil {
+			return nil, err
+		}
+		if time.Unix(datePaid, 0).UTC().Year() != int(ue.Year) {
+			continue
+		}
+
+		ei, err := c.createEarningsInvoice(invoice, datePaid)
+		if err != nil {
+			return nil, err
+		}
+
+		statement.TotalCostUSD += ei.TotalCostUSD
+		statement.TotalCostDCR += ei.TotalCostDCR
+		statement.Invoices = append(statement.Invoices, *ei)
+	}
+
+	// Sign the statement so that it can be verified independently.
+	b, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+	signature := c.cfg.SigningIdentity.SignMessage(b)
+
+	return &v1.UserEarningsReply{
+		Statement: statement,
+		PublicKey: hex.EncodeToString(c.cfg.SigningIdentity.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}, nil
+}
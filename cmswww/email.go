@@ -20,7 +20,7 @@ type newIdentityEmailTemplateData struct {
 	Email     string
 	PublicKey string
 }
-type userLockedResetPasswordEmailTemplateData struct {
+type failedLoginAttemptsEmailTemplateData struct {
 	Email string
 }
 type resetPasswordEmailTemplateData struct {
@@ -31,6 +31,11 @@ type updateExtendedPublicKeyEmailTemplateData struct {
 	Token string
 	Email string
 }
+type changeEmailEmailTemplateData struct {
+	Token    string
+	Email    string
+	NewEmail string
+}
 type invoiceApprovedEmailTemplateData struct {
 	Date  string
 	Token string
@@ -40,11 +45,29 @@ type invoiceRejectedEmailTemplateData struct {
 	Token  string
 	Reason string
 }
+type invoiceHeldEmailTemplateData struct {
+	Date   string
+	Token  string
+	Reason string
+}
 type invoicePaidEmailTemplateData struct {
 	Date  string
 	Token string
 	TxID  string
 }
+type invoiceCommentEmailTemplateData struct {
+	Date     string
+	Token    string
+	Username string
+	Comment  string
+	Reply    bool
+}
+type invoiceReminderEmailTemplateData struct {
+	Date     string
+	Deadline string
+	Month    uint16
+	Year     uint16
+}
 
 const (
 	fromAddress = "noreply@decred.org"
@@ -56,18 +79,30 @@ var (
 		template.New("register_email_template").Parse(templateRegisterEmailRaw))
 	templateNewIdentityEmail = template.Must(
 		template.New("new_identity_email_template").Parse(templateNewIdentityEmailRaw))
-	templateUserLockedResetPasswordEmail = template.Must(
-		template.New("user_locked_reset_password_email_template").Parse(templateUserLockedResetPasswordRaw))
+	templateFailedLoginAttemptsEmail = template.Must(
+		template.New("failed_login_attempts_email_template").Parse(templateFailedLoginAttemptsRaw))
 	templateResetPasswordEmail = template.Must(
 		template.New("reset_password_email_template").Parse(templateResetPasswordEmailRaw))
 	templateUpdateExtendedPublicKeyEmail = template.Must(
 		template.New("update_extended_public_key_email_template").Parse(templateUpdateExtendedPublicKeyEmailRaw))
+	templateChangeEmailOldAddressEmail = template.Must(
+		template.New("change_email_old_address_email_template").Parse(templateChangeEmailOldAddressEmailRaw))
+	templateChangeEmailNewAddressEmail = template.Must(
+		template.New("change_email_new_address_email_template").Parse(templateChangeEmailNewAddressEmailRaw))
 	templateInvoiceApprovedEmail = template.Must(
 		template.New("invoice_approved_email_template").Parse(templateInvoiceApprovedEmailRaw))
 	templateInvoiceRejectedEmail = template.Must(
 		template.New("invoice_rejected_email_template").Parse(templateInvoiceRejectedEmailRaw))
+	templateInvoiceOnHoldEmail = template.Must(
+		template.New("invoice_on_hold_email_template").Parse(templateInvoiceOnHoldEmailRaw))
+	templateInvoiceDisputedEmail = template.Must(
+		template.New("invoice_disputed_email_template").Parse(templateInvoiceDisputedEmailRaw))
 	templateInvoicePaidEmail = template.Must(
 		template.New("invoice_paid_email_template").Parse(templateInvoicePaidEmailRaw))
+	templateInvoiceCommentEmail = template.Must(
+		template.New("invoice_comment_email_template").Parse(templateInvoiceCommentEmailRaw))
+	templateInvoiceReminderEmail = template.Must(
+		template.New("invoice_reminder_email_template").Parse(templateInvoiceReminderEmailRaw))
 )
 
 func createBody(tpl *template.Template, tplData interface{}) (string, error) {
@@ -154,20 +189,20 @@ func (c *cmswww) emailUpdateIdentityVerificationLink(email, publicKey, token str
 	return c.sendEmailTo(subject, body, email)
 }
 
-// emailUserLocked notifies the user its account has been locked and
-// emails the link with the reset password verification token
+// emailFailedLoginAttempts notifies the user that there have been too many
+// failed login attempts for its account, and how to reset its password,
 // if the email server is set up.
-func (c *cmswww) emailUserLocked(email string) error {
+func (c *cmswww) emailFailedLoginAttempts(email string) error {
 	if c.cfg.SMTP == nil {
 		return nil
 	}
 
-	tplData := userLockedResetPasswordEmailTemplateData{
+	tplData := failedLoginAttemptsEmailTemplateData{
 		Email: email,
 	}
 
-	subject := "Locked Account - Reset Your Password"
-	body, err := createBody(templateUserLockedResetPasswordEmail, &tplData)
+	subject := "Failed Login Attempts"
+	body, err := createBody(templateFailedLoginAttemptsEmail, &tplData)
 	if err != nil {
 		return err
 	}
@@ -213,6 +248,39 @@ func (c *cmswww) emailUpdateExtendedPublicKeyVerificationLink(email, token strin
 	return c.sendEmailTo(subject, body, email)
 }
 
+// emailChangeEmailVerificationLinks sends the verification tokens for an
+// email address change to the current and the new email addresses.
+func (c *cmswww) emailChangeEmailVerificationLinks(
+	email, newEmail, oldToken, newToken string,
+) error {
+	if c.cfg.SMTP == nil {
+		return nil
+	}
+
+	subject := "Verify Your Email Address Change"
+
+	tplData := changeEmailEmailTemplateData{
+		Token:    oldToken,
+		Email:    email,
+		NewEmail: newEmail,
+	}
+	body, err := createBody(templateChangeEmailOldAddressEmail, &tplData)
+	if err != nil {
+		return err
+	}
+	err = c.sendEmailTo(subject, body, email)
+	if err != nil {
+		return err
+	}
+
+	tplData.Token = newToken
+	body, err = createBody(templateChangeEmailNewAddressEmail, &tplData)
+	if err != nil {
+		return err
+	}
+	return c.sendEmailTo(subject, body, newEmail)
+}
+
 func (c *cmswww) emailInvoiceApprovedNotification(
 	contractor *database.User,
 	dbInvoice *database.Invoice,
@@ -266,6 +334,40 @@ func (c *cmswww) emailInvoiceRejectedNotification(
 	return c.sendEmailTo(subject, body, contractor.Email)
 }
 
+// emailInvoiceHeldNotification notifies the contractor that their invoice
+// has been put on hold or that its payment has been disputed.
+func (c *cmswww) emailInvoiceHeldNotification(
+	contractor *database.User,
+	dbInvoice *database.Invoice,
+) error {
+	if c.cfg.SMTP == nil {
+		return nil
+	}
+	if contractor.EmailNotifications&
+		uint64(v1.NotificationEmailMyInvoiceHeld) == 0 {
+		return nil
+	}
+
+	tplData := invoiceHeldEmailTemplateData{
+		Token:  dbInvoice.Token,
+		Date:   getInvoiceDateStr(dbInvoice),
+		Reason: dbInvoice.StatusChangeReason,
+	}
+
+	subject := "Your invoice has been put on hold"
+	tpl := templateInvoiceOnHoldEmail
+	if dbInvoice.Status == v1.InvoiceStatusDisputed {
+		subject = "The payment of your invoice has been disputed"
+		tpl = templateInvoiceDisputedEmail
+	}
+	body, err := createBody(tpl, &tplData)
+	if err != nil {
+		return err
+	}
+
+	return c.sendEmailTo(subject, body, contractor.Email)
+}
+
 func (c *cmswww) emailInvoicePaidNotification(
 	contractor *database.User,
 	dbInvoice *database.Invoice,
@@ -293,3 +395,63 @@ func (c *cmswww) emailInvoicePaidNotification(
 
 	return c.sendEmailTo(subject, body, contractor.Email)
 }
+
+func (c *cmswww) emailInvoiceCommentNotification(
+	user *database.User,
+	dbInvoice *database.Invoice,
+	dbComment *database.InvoiceComment,
+	commenter string,
+	reply bool,
+) error {
+	if c.cfg.SMTP == nil {
+		return nil
+	}
+	if user.EmailNotifications&
+		uint64(v1.NotificationEmailInvoiceComment) == 0 {
+		return nil
+	}
+
+	tplData := invoiceCommentEmailTemplateData{
+		Date:     getInvoiceDateStr(dbInvoice),
+		Token:    dbInvoice.Token,
+		Username: commenter,
+		Comment:  dbComment.Comment,
+		Reply:    reply,
+	}
+
+	subject := "New comment on your invoice"
+	if reply {
+		subject = "New reply to your invoice comment"
+	}
+	body, err := createBody(templateInvoiceCommentEmail, &tplData)
+	if err != nil {
+		return err
+	}
+
+	return c.sendEmailTo(subject, body, user.Email)
+}
+
+func (c *cmswww) emailInvoiceReminder(
+	contractor *database.User,
+	month, year uint16,
+	deadline time.Time,
+) error {
+	if c.cfg.SMTP == nil {
+		return nil
+	}
+
+	tplData := invoiceReminderEmailTemplateData{
+		Date:     getInvoiceDateStr(&database.Invoice{Month: month, Year: year}),
+		Deadline: deadline.Add(-time.Second).Format("Jan 2, 2006 15:04 MST"),
+		Month:    month,
+		Year:     year,
+	}
+
+	subject := "Reminder: submit your invoice"
+	body, err := createBody(templateInvoiceReminderEmail, &tplData)
+	if err != nil {
+		return err
+	}
+
+	return c.sendEmailTo(subject, body, contractor.Email)
+}
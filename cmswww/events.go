@@ -20,6 +20,7 @@ const (
 	EventTypeInvoiceStatusChange
 	EventTypeInvoicePaid
 	EventTypeUserManage
+	EventTypeInvoiceComment
 )
 
 type EventDataInvoiceStatusChange struct {
@@ -32,6 +33,13 @@ type EventDataInvoicePaid struct {
 	TxID    string
 }
 
+type EventDataInvoiceComment struct {
+	Invoice      *database.Invoice
+	Comment      *database.InvoiceComment
+	User         *database.User // Author of the comment
+	ParentUserID uint64         // Author of the parent comment, if it's a reply
+}
+
 type EventDataUserManage struct {
 	AdminUser  *database.User
 	User       *database.User
@@ -73,6 +81,7 @@ func (c *cmswww) initEventManager() {
 
 	c._setupInvoiceStatusChangeEmailNotification()
 	c._setupInvoicePaidEmailNotification()
+	c._setupInvoiceCommentEmailNotification()
 }
 
 func (c *cmswww) _setupInvoiceStatusChangeEmailNotification() {
@@ -106,6 +115,13 @@ func (c *cmswww) _setupInvoiceStatusChangeEmailNotification() {
 					log.Errorf("email contractor for rejected invoice %v: %v",
 						data.Invoice.Token, err)
 				}
+			case v1.InvoiceStatusOnHold, v1.InvoiceStatusDisputed:
+				err := c.emailInvoiceHeldNotification(contractor,
+					data.Invoice)
+				if err != nil {
+					log.Errorf("email contractor for held invoice %v: %v",
+						data.Invoice.Token, err)
+				}
 			default:
 			}
 		}
@@ -140,6 +156,56 @@ func (c *cmswww) _setupInvoicePaidEmailNotification() {
 	c.eventManager._register(EventTypeInvoicePaid, ch)
 }
 
+func (c *cmswww) _setupInvoiceCommentEmailNotification() {
+	ch := make(chan interface{})
+	go func() {
+		for d := range ch {
+			data, ok := d.(EventDataInvoiceComment)
+			if !ok {
+				log.Errorf("invalid event data")
+				continue
+			}
+
+			// Notify the invoice's author, unless they wrote the comment.
+			if data.Invoice.UserID != data.User.ID {
+				contractor, err := c.getInvoiceContractor(data.Invoice)
+				if err != nil {
+					log.Errorf("cannot fetch contractor for invoice: %v", err)
+				} else {
+					err = c.emailInvoiceCommentNotification(contractor,
+						data.Invoice, data.Comment, data.User.Username, false)
+					if err != nil {
+						log.Errorf("email contractor for comment on invoice "+
+							"%v: %v", data.Invoice.Token, err)
+					}
+				}
+			}
+
+			// Notify the author of the parent comment, unless they were
+			// already notified as the invoice's author.
+			if data.Comment.ParentID == 0 ||
+				data.ParentUserID == data.User.ID ||
+				data.ParentUserID == data.Invoice.UserID {
+				continue
+			}
+
+			parentUser, err := c.db.GetUserById(data.ParentUserID)
+			if err != nil {
+				log.Errorf("cannot fetch author of parent comment: %v", err)
+				continue
+			}
+
+			err = c.emailInvoiceCommentNotification(parentUser, data.Invoice,
+				data.Comment, data.User.Username, true)
+			if err != nil {
+				log.Errorf("email user for reply on invoice %v: %v",
+					data.Invoice.Token, err)
+			}
+		}
+	}()
+	c.eventManager._register(EventTypeInvoiceComment, ch)
+}
+
 func (c *cmswww) _setupInvoiceStatusChangeLogging() {
 	ch := make(chan interface{})
 	go func() {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/decred/politeia/politeiad/api/v1/identity"
 	"github.com/decred/politeia/util"
 )
 
@@ -45,3 +46,25 @@ func (c *cmswww) RemoteIdentity() error {
 
 	return nil
 }
+
+// CreateSigningIdentity creates the identity which cmswww signs documents
+// with and saves it to the configured file.
+func (c *cmswww) CreateSigningIdentity() error {
+	id, err := identity.New()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.cfg.SigningIdentityFile), 0700)
+	if err != nil {
+		return err
+	}
+	err = id.Save(c.cfg.SigningIdentityFile)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Signing identity created at: %v", c.cfg.SigningIdentityFile)
+	log.Infof("Public key : %x", id.Public.Key)
+	return nil
+}
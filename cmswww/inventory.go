@@ -40,14 +40,31 @@ func (c *cmswww) newInventoryRecord(record pd.Record) error {
 		return err
 	}
 
-	return c.db.CreateInvoice(dbInvoice)
+	err = c.db.CreateInvoice(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	if dbInvoice.File == nil {
+		return nil
+	}
+	return c.updateInvoiceProposalCosts(dbInvoice)
 }
 
 // initializeInventory loads the database with the current inventory of Politeia records.
 //
 // This function must be called WITH the mutex held.
 func (c *cmswww) initializeInventory(inv *pd.InventoryReply) error {
-	for _, v := range append(inv.Vetted, inv.Branches...) {
+	// The inventory includes the files of the records, which are stored
+	// in the database along with the amounts they bill against proposals.
+	for _, v := range inv.Vetted {
+		err := c.newInventoryRecord(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range inv.Branches {
 		err := c.newInventoryRecord(v)
 		if err != nil {
 			return err
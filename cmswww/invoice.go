@@ -19,26 +19,6 @@ import (
 	"github.com/decred/contractor-mgmt/cmswww/database"
 )
 
-var (
-	validStatusTransitions = map[v1.InvoiceStatusT][]v1.InvoiceStatusT{
-		v1.InvoiceStatusNotReviewed: {
-			v1.InvoiceStatusApproved,
-			v1.InvoiceStatusRejected,
-		},
-		v1.InvoiceStatusRejected: {
-			v1.InvoiceStatusApproved,
-			v1.InvoiceStatusUnreviewedChanges,
-		},
-		v1.InvoiceStatusUnreviewedChanges: {
-			v1.InvoiceStatusApproved,
-			v1.InvoiceStatusRejected,
-		},
-		v1.InvoiceStatusApproved: {
-			v1.InvoiceStatusPaid,
-		},
-	}
-)
-
 func statusInSlice(arr []v1.InvoiceStatusT, status v1.InvoiceStatusT) bool {
 	for _, s := range arr {
 		if status == s {
@@ -49,34 +29,6 @@ func statusInSlice(arr []v1.InvoiceStatusT, status v1.InvoiceStatusT) bool {
 	return false
 }
 
-func validateStatusTransition(
-	dbInvoice *database.Invoice,
-	newStatus v1.InvoiceStatusT,
-	reason *string,
-) error {
-	validStatuses, ok := validStatusTransitions[dbInvoice.Status]
-	if !ok {
-		log.Errorf("status not supported: %v", dbInvoice.Status)
-		return v1.UserError{
-			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
-		}
-	}
-
-	if !statusInSlice(validStatuses, newStatus) {
-		return v1.UserError{
-			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
-		}
-	}
-
-	if newStatus == v1.InvoiceStatusRejected && reason == nil {
-		return v1.UserError{
-			ErrorCode: v1.ErrorStatusReasonNotProvided,
-		}
-	}
-
-	return nil
-}
-
 func (c *cmswww) refreshExistingInvoicePayments(dbInvoice *database.Invoice) error {
 	for _, dbInvoicePayment := range dbInvoice.Payments {
 		if dbInvoicePayment.TxID != "" {
@@ -97,46 +49,19 @@ func (c *cmswww) refreshExistingInvoicePayments(dbInvoice *database.Invoice) err
 	return nil
 }
 
+// deriveTotalCostFromInvoice adds the hours and total cost of the invoice's
+// line items to the payment.
 func (c *cmswww) deriveTotalCostFromInvoice(
 	dbInvoice *database.Invoice,
 	invoicePayment *v1.InvoicePayment,
 ) error {
-	b, err := base64.StdEncoding.DecodeString(dbInvoice.File.Payload)
+	invoiceReview, err := c.createInvoiceReview(dbInvoice)
 	if err != nil {
 		return err
 	}
 
-	csvReader := csv.NewReader(strings.NewReader(string(b)))
-	csvReader.Comma = v1.PolicyInvoiceFieldDelimiterChar
-	csvReader.Comment = v1.PolicyInvoiceCommentChar
-	csvReader.TrimLeadingSpace = true
-
-	records, err := csvReader.ReadAll()
-	if err != nil {
-		return err
-	}
-
-	for _, record := range records {
-		for idx := range v1.InvoiceFields {
-			switch idx {
-			case 4:
-				hours, err := strconv.ParseUint(record[idx], 10, 64)
-				if err != nil {
-					return err
-				}
-
-				invoicePayment.TotalHours += hours
-			case 5:
-				totalCost, err := strconv.ParseUint(record[idx], 10, 64)
-				if err != nil {
-					return err
-				}
-
-				invoicePayment.TotalCostUSD += totalCost
-			}
-		}
-	}
-
+	invoicePayment.TotalHours += invoiceReview.TotalHours
+	invoicePayment.TotalCostUSD += invoiceReview.TotalCostUSD
 	return nil
 }
 
@@ -164,7 +89,13 @@ func (c *cmswww) createInvoiceReview(invoice *database.Invoice) (*v1.InvoiceRevi
 	}
 
 	for _, record := range records {
-		lineItem := v1.InvoiceReviewLineItem{}
+		lineItem := v1.InvoiceReviewLineItem{
+			Kind: v1.LineItemKindLabor,
+		}
+		if isExpenseLineItem(record) {
+			lineItem.Kind = v1.LineItemKindExpense
+		}
+
 		for idx := range v1.InvoiceFields {
 			var err error
 			switch idx {
@@ -177,19 +108,26 @@ func (c *cmswww) createInvoiceReview(invoice *database.Invoice) (*v1.InvoiceRevi
 			case 3:
 				lineItem.Proposal = record[idx]
 			case 4:
-				lineItem.Hours, err = strconv.ParseUint(record[idx], 10, 64)
+				lineItem.Hours, err = strconv.ParseUint(
+					strings.TrimSpace(record[idx]), 10, 64)
 				if err != nil {
 					return nil, err
 				}
 
 				invoiceReview.TotalHours += lineItem.Hours
 			case 5:
-				lineItem.TotalCost, err = strconv.ParseUint(record[idx], 10, 64)
+				lineItem.TotalCost, err = strconv.ParseUint(
+					strings.TrimSpace(record[idx]), 10, 64)
 				if err != nil {
 					return nil, err
 				}
 
 				invoiceReview.TotalCostUSD += lineItem.TotalCost
+				if lineItem.Kind == v1.LineItemKindExpense {
+					invoiceReview.TotalExpensesUSD += lineItem.TotalCost
+				} else {
+					invoiceReview.TotalLaborUSD += lineItem.TotalCost
+				}
 			}
 		}
 
@@ -263,6 +201,52 @@ func (c *cmswww) updateMDPayments(
 	return util.VerifyChallenge(c.cfg.Identity, challenge, pdReply.Response)
 }
 
+// appendInvoiceMetadata appends a JSON encoded record to one of the invoice's
+// metadata streams in politeiad.
+func (c *cmswww) appendInvoiceMetadata(
+	token string,
+	streamID uint64,
+	md interface{},
+) error {
+	blob, err := json.Marshal(md)
+	if err != nil {
+		return fmt.Errorf("cannot marshal metadata stream %v: %v", streamID,
+			err)
+	}
+
+	challenge, err := util.Random(pd.ChallengeSize)
+	if err != nil {
+		return fmt.Errorf("could not create challenge: %v", err)
+	}
+
+	pdCommand := pd.UpdateVettedMetadata{
+		Challenge: hex.EncodeToString(challenge),
+		Token:     token,
+		MDAppend: []pd.MetadataStream{
+			{
+				ID:      streamID,
+				Payload: string(blob),
+			},
+		},
+	}
+
+	responseBody, err := c.rpc(http.MethodPost, pd.UpdateVettedMetadataRoute,
+		pdCommand)
+	if err != nil {
+		return err
+	}
+
+	var pdReply pd.UpdateVettedMetadataReply
+	err = json.Unmarshal(responseBody, &pdReply)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal UpdateVettedMetadataReply: %v",
+			err)
+	}
+
+	// Verify the challenge.
+	return util.VerifyChallenge(c.cfg.Identity, challenge, pdReply.Response)
+}
+
 func (c *cmswww) createInvoicePayment(
 	dbInvoice *database.Invoice,
 	usdDCRRate float64,
@@ -355,6 +339,21 @@ func (c *cmswww) updateInvoicePayment(
 	amount uint64,
 	txID string,
 ) error {
+	// Only invoices which are ready to be paid are marked as paid; the
+	// payments of invoices which are already paid can still be recorded.
+	switch dbInvoice.Status {
+	case c.cfg.InvoiceWorkflow.PayableStatus, v1.InvoiceStatusPaid:
+	case v1.InvoiceStatusOnHold:
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvoiceOnHold,
+			ErrorContext: []string{dbInvoice.StatusChangeReason},
+		}
+	default:
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+
 	var dbInvoicePayment *database.InvoicePayment
 	for idx, payment := range dbInvoice.Payments {
 		if payment.Amount == amount && payment.Address == address {
@@ -394,39 +393,49 @@ func (c *cmswww) updateInvoicePayment(
 	return nil
 }
 
-func (c *cmswww) fetchInvoiceFileIfNecessary(invoice *database.Invoice) error {
-	if invoice.File != nil {
-		return nil
-	}
-
+// fetchVettedRecord fetches the full record of an invoice from politeiad.
+func (c *cmswww) fetchVettedRecord(token string) (*pd.Record, error) {
 	challenge, err := util.Random(pd.ChallengeSize)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	responseBody, err := c.rpc(http.MethodPost, pd.GetVettedRoute,
 		pd.GetVetted{
-			Token:     invoice.Token,
+			Token:     token,
 			Challenge: hex.EncodeToString(challenge),
 		})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var pdReply pd.GetVettedReply
 	err = json.Unmarshal(responseBody, &pdReply)
 	if err != nil {
-		return fmt.Errorf("Could not unmarshal "+
+		return nil, fmt.Errorf("Could not unmarshal "+
 			"GetVettedReply: %v", err)
 	}
 
 	// Verify the challenge.
 	err = util.VerifyChallenge(c.cfg.Identity, challenge, pdReply.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pdReply.Record, nil
+}
+
+func (c *cmswww) fetchInvoiceFileIfNecessary(invoice *database.Invoice) error {
+	if invoice.File != nil {
+		return nil
+	}
+
+	record, err := c.fetchVettedRecord(invoice.Token)
 	if err != nil {
 		return err
 	}
 
-	invoice.File = convertRecordFilesToDatabaseInvoiceFile(pdReply.Record.Files)
+	invoice.File = convertRecordFilesToDatabaseInvoiceFile(record.Files)
 	return nil
 }
 
@@ -483,7 +492,8 @@ func (c *cmswww) HandleReviewInvoices(
 	}
 
 	var invoiceReviews []v1.InvoiceReview
-
+	var tokens []string
+	referenced := make(map[string]bool)
 	for _, invoice := range invoices {
 		err := c.fetchInvoiceFileIfNecessary(&invoice)
 		if err != nil {
@@ -495,9 +505,53 @@ func (c *cmswww) HandleReviewInvoices(
 			return nil, err
 		}
 
+		// Include the domain lead reviews of the version being reviewed.
+		invoiceReview.LeadReviews, err = c.getInvoiceLeadReviews(
+			invoice.Token, invoice.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, lineItem := range invoiceReview.LineItems {
+			if lineItem.Proposal != "" && !referenced[lineItem.Proposal] {
+				referenced[lineItem.Proposal] = true
+				tokens = append(tokens, lineItem.Proposal)
+			}
+		}
+
 		invoiceReviews = append(invoiceReviews, *invoiceReview)
 	}
 
+	if len(tokens) == 0 {
+		return &v1.ReviewInvoicesReply{
+			Invoices: invoiceReviews,
+		}, nil
+	}
+
+	// Warn about invoices which push a proposal over its budget, given the
+	// spending already committed by approved and paid invoices to the
+	// proposals they bill against.
+	proposals, err := c.getProposals()
+	if err != nil {
+		return nil, err
+	}
+	committed, err := c.getProposalSpending(map[v1.InvoiceStatusT]bool{
+		v1.InvoiceStatusApproved:         true,
+		v1.InvoiceStatusAwaitingApproval: true,
+		v1.InvoiceStatusOnHold:           true,
+		v1.InvoiceStatusReadyForPayment:  true,
+		v1.InvoiceStatusPaid:             true,
+		v1.InvoiceStatusDisputed:         true,
+	}, proposals, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range invoiceReviews {
+		invoiceReviews[idx].BudgetWarnings = getProposalBudgetWarnings(
+			&invoiceReviews[idx], committed)
+	}
+
 	return &v1.ReviewInvoicesReply{
 		Invoices: invoiceReviews,
 	}, nil
@@ -515,8 +569,9 @@ func (c *cmswww) HandlePayInvoices(
 	invoices, _, err := c.db.GetInvoices(database.InvoicesRequest{
 		Month: pi.Month,
 		Year:  pi.Year,
+		// Invoices which are on hold aren't paid until they're released.
 		StatusMap: map[v1.InvoiceStatusT]bool{
-			v1.InvoiceStatusApproved: true,
+			c.cfg.InvoiceWorkflow.PayableStatus: true,
 		},
 		Page: -1,
 	})
@@ -569,6 +624,20 @@ func (c *cmswww) HandlePayInvoice(
 		return nil, err
 	}
 
+	// Only invoices which are ready to be paid get a new payment.
+	switch invoice.Status {
+	case c.cfg.InvoiceWorkflow.PayableStatus:
+	case v1.InvoiceStatusOnHold:
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvoiceOnHold,
+			ErrorContext: []string{invoice.StatusChangeReason},
+		}
+	default:
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+
 	err = c.fetchInvoiceFileIfNecessary(invoice)
 	if err != nil {
 		return nil, err
@@ -648,16 +717,12 @@ func (c *cmswww) HandleUserInvoices(
 	}, nil
 }
 
-// HandleSetInvoiceStatus changes the status of an existing invoice
-// from unreviewed to either published or rejected.
-func (c *cmswww) HandleSetInvoiceStatus(
-	req interface{},
+// setInvoiceStatus validates a signed status change of an invoice and
+// records it in politeiad and the database.
+func (c *cmswww) setInvoiceStatus(
+	sis *v1.SetInvoiceStatus,
 	user *database.User,
-	w http.ResponseWriter,
-	r *http.Request,
-) (interface{}, error) {
-	sis := req.(*v1.SetInvoiceStatus)
-
+) (*v1.SetInvoiceStatusReply, error) {
 	err := checkPublicKeyAndSignature(user, sis.PublicKey, sis.Signature,
 		sis.Token, strconv.FormatUint(uint64(sis.Status), 10))
 	if err != nil {
@@ -675,17 +740,39 @@ func (c *cmswww) HandleSetInvoiceStatus(
 		return nil, err
 	}
 
-	err = validateStatusTransition(dbInvoice, sis.Status, sis.Reason)
+	// An invoice that is awaiting additional approval can move to any status
+	// that was valid before the approvals were collected.
+	var pa *pendingApproval
+	oldStatus := dbInvoice.Status
+	if dbInvoice.Status == v1.InvoiceStatusAwaitingApproval {
+		pa, err = c.getPendingApproval(dbInvoice)
+		if err != nil {
+			return nil, err
+		}
+		oldStatus = pa.previousStatus
+	}
+
+	transition, err := c.cfg.InvoiceWorkflow.validateStatusTransition(
+		oldStatus, sis.Status, sis.Reason)
 	if err != nil {
 		return nil, err
 	}
 
+	// The workflow determines which role makes each status change.
+	if !user.HasRole(transition.Role) {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusRoleRequired,
+			ErrorContext: []string{v1.UserRole[transition.Role]},
+		}
+	}
+
 	// Create the change record.
 	changes := BackendInvoiceMDChange{
-		Version:   VersionBackendInvoiceMDChange,
-		Timestamp: time.Now().Unix(),
-		NewStatus: sis.Status,
-		Reason:    sis.Reason,
+		Version:        VersionBackendInvoiceMDChange,
+		Timestamp:      time.Now().Unix(),
+		AdminSignature: sis.Signature,
+		NewStatus:      sis.Status,
+		Reason:         sis.Reason,
 	}
 
 	var ok bool
@@ -695,42 +782,36 @@ func (c *cmswww) HandleSetInvoiceStatus(
 			user.ID)
 	}
 
-	blob, err := json.Marshal(changes)
-	if err != nil {
-		return nil, err
-	}
-
-	challenge, err := util.Random(pd.ChallengeSize)
-	if err != nil {
-		return nil, err
-	}
-
-	pdCommand := pd.UpdateVettedMetadata{
-		Challenge: hex.EncodeToString(challenge),
-		Token:     sis.Token,
-		MDAppend: []pd.MetadataStream{
-			{
-				ID:      mdStreamChanges,
-				Payload: string(blob),
-			},
-		},
-	}
+	// Approving or paying an invoice requires the approval of a number of
+	// distinct admins which depends on the invoice's total cost; until
+	// enough admins have approved, the invoice is awaiting approval.
+	if requiresApproval(oldStatus, sis.Status) {
+		required, err := c.getRequiredApprovals(dbInvoice)
+		if err != nil {
+			return nil, err
+		}
 
-	responseBody, err := c.rpc(http.MethodPost, pd.UpdateVettedMetadataRoute,
-		pdCommand)
-	if err != nil {
-		return nil, err
-	}
+		approvals := uint(0)
+		if pa != nil && pa.status == sis.Status {
+			approved, err := c.hasApproved(pa, user)
+			if err != nil {
+				return nil, err
+			}
+			if approved {
+				return nil, v1.UserError{
+					ErrorCode: v1.ErrorStatusDuplicateApproval,
+				}
+			}
+			approvals = pa.approvalsFor(sis.Status)
+		}
 
-	var pdReply pd.UpdateVettedMetadataReply
-	err = json.Unmarshal(responseBody, &pdReply)
-	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal UpdateVettedMetadataReply: %v",
-			err)
+		if approvals+1 < required {
+			changes.NewStatus = v1.InvoiceStatusAwaitingApproval
+			changes.ApprovalFor = sis.Status
+		}
 	}
 
-	// Verify the challenge.
-	err = util.VerifyChallenge(c.cfg.Identity, challenge, pdReply.Response)
+	err = c.appendInvoiceMetadata(sis.Token, mdStreamChanges, changes)
 	if err != nil {
 		return nil, err
 	}
@@ -739,7 +820,9 @@ func (c *cmswww) HandleSetInvoiceStatus(
 	dbInvoice.Changes = append(dbInvoice.Changes, database.InvoiceChange{
 		Timestamp:      changes.Timestamp,
 		AdminPublicKey: changes.AdminPublicKey,
+		AdminSignature: changes.AdminSignature,
 		NewStatus:      changes.NewStatus,
+		ApprovalFor:    changes.ApprovalFor,
 	})
 	dbInvoice.Status = changes.NewStatus
 	if changes.Reason != nil {
@@ -753,6 +836,12 @@ func (c *cmswww) HandleSetInvoiceStatus(
 		return nil, err
 	}
 
+	// Payments of invoices which can't be paid anymore, such as those put
+	// on hold, are no longer watched for.
+	if dbInvoice.Status != c.cfg.InvoiceWorkflow.PayableStatus {
+		c.removeInvoiceFromPolling(dbInvoice.Token)
+	}
+
 	c.fireEvent(EventTypeInvoiceStatusChange,
 		EventDataInvoiceStatusChange{
 			Invoice:   dbInvoice,
@@ -764,9 +853,72 @@ func (c *cmswww) HandleSetInvoiceStatus(
 	sisr := v1.SetInvoiceStatusReply{
 		Invoice: *convertDatabaseInvoiceToInvoice(dbInvoice),
 	}
+	err = c.setInvoiceApprovals(&sisr.Invoice, dbInvoice)
+	if err != nil {
+		return nil, err
+	}
 	return &sisr, nil
 }
 
+// HandleSetInvoiceStatus changes the status of an existing invoice
+// from unreviewed to either published or rejected.
+func (c *cmswww) HandleSetInvoiceStatus(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	sis := req.(*v1.SetInvoiceStatus)
+	return c.setInvoiceStatus(sis, user)
+}
+
+// HandleSetInvoiceStatuses applies many status changes in a single request.
+// Each change is validated and applied on its own, so a failed change is
+// reported in its result without affecting the others.
+func (c *cmswww) HandleSetInvoiceStatuses(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	siss := req.(*v1.SetInvoiceStatuses)
+
+	if len(siss.StatusChanges) == 0 {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"no status changes"},
+		}
+	}
+	if len(siss.StatusChanges) > v1.PolicyMaxBatchStatusChanges {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusMaxStatusChangesExceededPolicy,
+		}
+	}
+
+	sissr := v1.SetInvoiceStatusesReply{
+		Results: make([]v1.SetInvoiceStatusResult, 0,
+			len(siss.StatusChanges)),
+	}
+	for idx := range siss.StatusChanges {
+		sis := &siss.StatusChanges[idx]
+		result := v1.SetInvoiceStatusResult{
+			Token: sis.Token,
+		}
+
+		sisr, err := c.setInvoiceStatus(sis, user)
+		if err != nil {
+			result.ErrorCode, result.ErrorContext = convertErrorToErrorReply(
+				r, err)
+		} else {
+			result.Invoice = &sisr.Invoice
+		}
+
+		sissr.Results = append(sissr.Results, result)
+	}
+
+	return &sissr, nil
+}
+
 // HandleInvoiceDetails tries to fetch the full details of an invoice from
 // politeiad.
 func (c *cmswww) HandleInvoiceDetails(
@@ -778,11 +930,6 @@ func (c *cmswww) HandleInvoiceDetails(
 	id := req.(*v1.InvoiceDetails)
 
 	var idr v1.InvoiceDetailsReply
-	challenge, err := util.Random(pd.ChallengeSize)
-	if err != nil {
-		return nil, err
-	}
-
 	dbInvoice, err := c.db.GetInvoiceByToken(id.Token)
 	if err != nil {
 		if err == database.ErrInvoiceNotFound {
@@ -795,36 +942,31 @@ func (c *cmswww) HandleInvoiceDetails(
 
 	invoice := convertDatabaseInvoiceToInvoice(dbInvoice)
 
-	err = validateUserCanSeeInvoice(invoice, user)
+	err = c.validateUserCanAccessInvoice(invoice, user)
 	if err != nil {
 		return nil, err
 	}
 
-	responseBody, err := c.rpc(http.MethodPost, pd.GetVettedRoute,
-		pd.GetVetted{
-			Token:     id.Token,
-			Challenge: hex.EncodeToString(challenge),
-		},
-	)
+	record, err := c.fetchVettedRecord(id.Token)
 	if err != nil {
 		return nil, err
 	}
 
-	var pdReply pd.GetVettedReply
-	err = json.Unmarshal(responseBody, &pdReply)
+	invoice.File = convertInvoiceFileFromPD(record.Files)
+	invoice.Attachments = convertInvoiceAttachmentsFromPD(record.Files)
+	invoice.Username = c.getUsernameByID(invoice.UserID)
+
+	dbInvoice.File = convertRecordFilesToDatabaseInvoiceFile(record.Files)
+	err = c.setInvoiceApprovals(invoice, dbInvoice)
 	if err != nil {
-		return nil, fmt.Errorf("Could not unmarshal "+
-			"GetVettedReply: %v", err)
+		return nil, err
 	}
 
-	// Verify the challenge.
-	err = util.VerifyChallenge(c.cfg.Identity, challenge, pdReply.Response)
+	invoice.LeadReviews, err = c.getInvoiceLeadReviews(id.Token, "")
 	if err != nil {
 		return nil, err
 	}
 
-	invoice.File = convertInvoiceFileFromPD(pdReply.Record.Files)
-	invoice.Username = c.getUsernameByID(invoice.UserID)
 	idr.Invoice = *invoice
 	return &idr, nil
 }
@@ -837,9 +979,31 @@ func (c *cmswww) HandleSubmitInvoice(
 	r *http.Request,
 ) (interface{}, error) {
 	ni := req.(*v1.SubmitInvoice)
-	fmt.Println(ni)
-	err := validateInvoice(ni.Signature, ni.PublicKey, ni.File.Payload,
-		int(ni.Month), int(ni.Year), user)
+
+	// Deactivated contractors can only submit invoices up to the month of
+	// their end date, until the deadline for that month's invoice.
+	err := c.checkUserNotDeactivated(user)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveInMonth(user, ni.Month, ni.Year) {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusUserDeactivated,
+		}
+	}
+	if !isInContractPeriod(user, ni.Month, ni.Year) {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvoiceOutsideContract,
+		}
+	}
+
+	err = validateInvoice(ni.Signature, ni.PublicKey, ni.File.Payload,
+		ni.Attachments, int(ni.Month), int(ni.Year), user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.validateInvoiceLineItems(user, ni.File.Payload)
 	if err != nil {
 		return nil, err
 	}
@@ -853,10 +1017,13 @@ func (c *cmswww) HandleSubmitInvoice(
 		return nil, err
 	}
 
-	if len(invoices) > 0 {
-		return nil, v1.UserError{
-			ErrorCode:    v1.ErrorStatusDuplicateInvoice,
-			ErrorContext: []string{invoices[0].Token},
+	// Withdrawn invoices don't prevent a new invoice for the month.
+	for _, invoice := range invoices {
+		if invoice.Status != v1.InvoiceStatusWithdrawn {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusDuplicateInvoice,
+				ErrorContext: []string{invoice.Token},
+			}
 		}
 	}
 
@@ -886,7 +1053,7 @@ func (c *cmswww) HandleSubmitInvoice(
 			ID:      mdStreamGeneral,
 			Payload: string(md),
 		}},
-		Files: convertInvoiceFileFromWWW(&ni.File),
+		Files: convertInvoiceFileFromWWW(&ni.File, ni.Attachments),
 	}
 
 	var pdNewRecordReply pd.NewRecordReply
@@ -968,6 +1135,12 @@ func (c *cmswww) HandleSubmitInvoice(
 		return nil, err
 	}
 
+	// The invoice replaces the user's draft for the month, if there is one.
+	err = c.db.DeleteInvoiceDraft(user.ID, ni.Month, ni.Year)
+	if err != nil && err != database.ErrInvoiceDraftNotFound {
+		log.Errorf("cannot delete invoice draft of %v: %v", user.Email, err)
+	}
+
 	nir.CensorshipRecord = convertInvoiceCensorFromPD(
 		pdNewRecordReply.CensorshipRecord)
 	return &nir, nil
@@ -987,11 +1160,53 @@ func (c *cmswww) HandleEditInvoice(
 		return nil, err
 	}
 
+	if dbInvoice.UserID != user.ID {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvoiceNotFound,
+		}
+	}
+
+	// Only invoices which haven't been approved can be edited; a withdrawn
+	// invoice is replaced by submitting a new one.
+	if !c.cfg.InvoiceWorkflow.isEditable(dbInvoice.Status) {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+
+	err = c.checkUserNotDeactivated(user)
+	if err != nil {
+		return nil, err
+	}
+
 	err = validateInvoice(ei.Signature, ei.PublicKey, ei.File.Payload,
-		int(dbInvoice.Month), int(dbInvoice.Year), user)
+		ei.Attachments, int(dbInvoice.Month), int(dbInvoice.Year), user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.validateInvoiceLineItems(user, ei.File.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new attachments replace the existing ones, so any existing
+	// attachment which isn't being re-added is deleted.
+	record, err := c.fetchVettedRecord(ei.Token)
 	if err != nil {
 		return nil, err
 	}
+	newAttachments := make(map[string]bool, len(ei.Attachments))
+	for _, attachment := range ei.Attachments {
+		newAttachments[attachment.Name] = true
+	}
+	var filesDel []string
+	for _, attachment := range convertInvoiceAttachmentsFromPD(record.Files) {
+		if !newAttachments[attachment.Name] {
+			filesDel = append(filesDel, attachment.Name)
+		}
+	}
+
 	challenge, err := util.Random(pd.ChallengeSize)
 	if err != nil {
 		return nil, err
@@ -1032,7 +1247,8 @@ func (c *cmswww) HandleEditInvoice(
 			ID:      mdStreamChanges,
 			Payload: string(changes),
 		}},
-		FilesAdd: convertInvoiceFileFromWWW(&ei.File),
+		FilesAdd: convertInvoiceFileFromWWW(&ei.File, ei.Attachments),
+		FilesDel: filesDel,
 	}
 
 	var pdUpdateRecordReply pd.UpdateRecordReply
@@ -1060,12 +1276,20 @@ func (c *cmswww) HandleEditInvoice(
 		NewStatus: v1.InvoiceStatusUnreviewedChanges,
 	})
 	dbInvoice.Version = pdUpdateRecordReply.Record.Version
+	dbInvoice.Merkle = pdUpdateRecordReply.Record.CensorshipRecord.Merkle
+	dbInvoice.File = convertRecordFilesToDatabaseInvoiceFile(
+		pdUpdateRecordReply.Record.Files)
 	dbInvoice.Status = v1.InvoiceStatusUnreviewedChanges
 	err = c.db.UpdateInvoice(dbInvoice)
 	if err != nil {
 		return nil, err
 	}
 
+	err = c.updateInvoiceProposalCosts(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
 	c.fireEvent(EventTypeInvoiceStatusChange,
 		EventDataInvoiceStatusChange{
 			Invoice:   dbInvoice,
@@ -1078,3 +1302,93 @@ func (c *cmswww) HandleEditInvoice(
 	}
 	return reply, nil
 }
+
+// HandleWithdrawInvoice withdraws an invoice on behalf of the contractor who
+// submitted it, before it's approved. The contractor's signed withdrawal is
+// recorded in the invoice's changes, and a new invoice can then be
+// submitted for the same month.
+func (c *cmswww) HandleWithdrawInvoice(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	wi := req.(*v1.WithdrawInvoice)
+
+	dbInvoice, err := c.db.GetInvoiceByToken(wi.Token)
+	if err != nil {
+		if err == database.ErrInvoiceNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvoiceNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	// Only the contractor who submitted the invoice can withdraw it.
+	if dbInvoice.UserID != user.ID {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvoiceNotFound,
+		}
+	}
+
+	err = checkPublicKeyAndSignature(user, wi.PublicKey, wi.Signature,
+		wi.Token, strconv.FormatUint(uint64(v1.InvoiceStatusWithdrawn), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	transition, ok := c.cfg.InvoiceWorkflow.transition(dbInvoice.Status,
+		v1.InvoiceStatusWithdrawn)
+	if !ok {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+	if transition.ReasonRequired && wi.Reason == "" {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusReasonNotProvided,
+		}
+	}
+
+	// Create the change record.
+	changes := BackendInvoiceMDChange{
+		Version:       VersionBackendInvoiceMDChange,
+		Timestamp:     time.Now().Unix(),
+		UserPublicKey: wi.PublicKey,
+		UserSignature: wi.Signature,
+		NewStatus:     v1.InvoiceStatusWithdrawn,
+	}
+	if wi.Reason != "" {
+		changes.Reason = &wi.Reason
+	}
+
+	err = c.appendInvoiceMetadata(wi.Token, mdStreamChanges, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the database with the metadata changes.
+	dbInvoice.Changes = append(dbInvoice.Changes, database.InvoiceChange{
+		Timestamp: changes.Timestamp,
+		NewStatus: changes.NewStatus,
+		Reason:    wi.Reason,
+	})
+	dbInvoice.Status = v1.InvoiceStatusWithdrawn
+	dbInvoice.StatusChangeReason = wi.Reason
+	err = c.db.UpdateInvoice(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	c.fireEvent(EventTypeInvoiceStatusChange,
+		EventDataInvoiceStatusChange{
+			Invoice:   dbInvoice,
+			AdminUser: nil,
+		},
+	)
+
+	return &v1.WithdrawInvoiceReply{
+		Invoice: *convertDatabaseInvoiceToInvoice(dbInvoice),
+	}, nil
+}
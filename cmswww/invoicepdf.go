@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/decred/politeia/util"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// Column positions of the line item table, in points from the left edge.
+const (
+	pdfColumnSubtype     = 125.0
+	pdfColumnDescription = 195.0
+	pdfColumnProposal    = 375.0
+	pdfColumnHours       = 430.0
+	pdfColumnCost        = 480.0
+)
+
+// formatPDFDate formats a Unix timestamp as a date in UTC.
+func formatPDFDate(timestamp int64) string {
+	return time.Unix(timestamp, 0).UTC().Format("January 2, 2006")
+}
+
+// writeInvoicePDFLineItems writes the line items of the invoice as a table,
+// wrapping long descriptions.
+func writeInvoicePDFLineItems(d *pdfDocument, invoiceReview *v1.InvoiceReview) {
+	const size = 9.0
+
+	d.line(size, true, "Type")
+	d.text(pdfColumnSubtype, size, true, "Subtype")
+	d.text(pdfColumnDescription, size, true, "Description")
+	d.text(pdfColumnProposal, size, true, "Proposal")
+	d.text(pdfColumnHours, size, true, "Hours")
+	d.text(pdfColumnCost, size, true, "Cost (USD)")
+	d.rule()
+
+	for _, lineItem := range invoiceReview.LineItems {
+		// Proposals are identified by the short form of their token.
+		proposal := lineItem.Proposal
+		if len(proposal) > 7 {
+			proposal = proposal[:7]
+		}
+
+		descriptions := wrapText(lineItem.Description,
+			pdfColumnProposal-pdfColumnDescription-10, size)
+		d.line(size, false, lineItem.Type)
+		d.text(pdfColumnSubtype, size, false, lineItem.Subtype)
+		d.text(pdfColumnDescription, size, false, descriptions[0])
+		d.text(pdfColumnProposal, size, false, proposal)
+		d.text(pdfColumnHours, size, false, fmt.Sprintf("%v", lineItem.Hours))
+		d.text(pdfColumnCost, size, false, fmt.Sprintf("%v",
+			lineItem.TotalCost))
+		for _, description := range descriptions[1:] {
+			d.advance(size * 1.4)
+			d.text(pdfColumnDescription, size, false, description)
+		}
+	}
+	d.rule()
+
+	d.line(size, false, "Labor")
+	d.text(pdfColumnHours, size, false, fmt.Sprintf("%v",
+		invoiceReview.TotalHours))
+	d.text(pdfColumnCost, size, false, fmt.Sprintf("%v",
+		invoiceReview.TotalLaborUSD))
+	d.line(size, false, "Expenses")
+	d.text(pdfColumnCost, size, false, fmt.Sprintf("%v",
+		invoiceReview.TotalExpensesUSD))
+	d.line(size, true, "Total")
+	d.text(pdfColumnCost, size, true, fmt.Sprintf("%v",
+		invoiceReview.TotalCostUSD))
+}
+
+// writeInvoicePDFSignature writes a value which is too long for a single
+// line, such as a signature, over as many lines as it needs.
+func writeInvoicePDFSignature(d *pdfDocument, label, value string) {
+	const size = 8.0
+
+	d.line(size, true, label)
+	for _, line := range wrapText(value, pdfPageWidth-2*pdfMargin, size) {
+		d.line(size, false, line)
+	}
+}
+
+// renderInvoicePDF renders the invoice as a PDF document. The receipt, if
+// any, is rendered on a page of its own after the invoice.
+func renderInvoicePDF(
+	dbInvoice *database.Invoice,
+	contractor *database.User,
+	invoiceReview *v1.InvoiceReview,
+	receipt *v1.EarningsInvoice,
+) []byte {
+	month := time.Date(int(dbInvoice.Year), time.Month(dbInvoice.Month), 1,
+		0, 0, 0, 0, time.UTC).Format("January 2006")
+
+	d := newPDFDocument(fmt.Sprintf("Invoice for %v", month))
+	d.line(20, true, "Invoice")
+	d.line(12, false, month)
+	d.space(10)
+
+	name := contractor.Name
+	if name == "" {
+		name = contractor.Username
+	}
+	d.line(10, true, name)
+	if contractor.Location != "" {
+		d.line(10, false, contractor.Location)
+	}
+	d.space(10)
+
+	d.line(10, false, fmt.Sprintf("Submitted: %v",
+		formatPDFDate(dbInvoice.Timestamp)))
+	d.line(10, false, fmt.Sprintf("Version: %v", dbInvoice.Version))
+	d.line(10, false, fmt.Sprintf("Status: %v",
+		v1.InvoiceStatus[dbInvoice.Status]))
+	if dbInvoice.StatusChangeReason != "" {
+		for _, line := range wrapText("Reason: "+
+			dbInvoice.StatusChangeReason, pdfPageWidth-2*pdfMargin, 10) {
+			d.line(10, false, line)
+		}
+	}
+	d.space(10)
+
+	writeInvoicePDFLineItems(d, invoiceReview)
+	d.space(20)
+
+	writeInvoicePDFSignature(d, "Censorship token", dbInvoice.Token)
+	writeInvoicePDFSignature(d, "Merkle root", dbInvoice.Merkle)
+	writeInvoicePDFSignature(d, "Server signature", dbInvoice.ServerSignature)
+	writeInvoicePDFSignature(d, "Contractor public key", dbInvoice.PublicKey)
+	writeInvoicePDFSignature(d, "Contractor signature",
+		dbInvoice.UserSignature)
+
+	if receipt == nil {
+		return d.bytes()
+	}
+
+	d.newPage()
+	d.line(20, true, "Payment receipt")
+	d.line(12, false, month)
+	d.space(10)
+
+	d.line(10, true, name)
+	d.line(10, false, fmt.Sprintf("Date paid: %v",
+		formatPDFDate(receipt.DatePaid)))
+	d.line(10, false, fmt.Sprintf("Amount: %v USD", receipt.TotalCostUSD))
+	d.line(10, false, fmt.Sprintf("Amount paid: %.8f DCR",
+		receipt.TotalCostDCR))
+	if receipt.USDDCRRate != 0 {
+		d.line(10, false, fmt.Sprintf("Rate: %.2f USD/DCR",
+			receipt.USDDCRRate))
+	}
+	if dbInvoice.Status == v1.InvoiceStatusDisputed {
+		d.line(10, true, "The payment of this invoice is disputed.")
+	}
+	d.space(10)
+
+	writeInvoicePDFSignature(d, "Censorship token", dbInvoice.Token)
+	for _, txID := range receipt.TxIDs {
+		writeInvoicePDFSignature(d, "Transaction", txID)
+	}
+
+	return d.bytes()
+}
+
+// HandleInvoicePDF returns the invoice rendered as a PDF document, signed
+// by the server. Paid invoices include a payment receipt.
+func (c *cmswww) HandleInvoicePDF(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ip := req.(*v1.InvoicePDF)
+
+	// The invoice is fetched by token to include its payments.
+	dbInvoice, err := c.db.GetInvoiceByToken(ip.Token)
+	if err != nil {
+		if err == database.ErrInvoiceNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvoiceNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	err = c.validateUserCanAccessInvoice(
+		convertDatabaseInvoiceToInvoice(dbInvoice), user)
+	if err != nil {
+		return nil, err
+	}
+
+	contractor, err := c.db.GetUserById(dbInvoice.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.fetchInvoiceFileIfNecessary(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	invoiceReview, err := c.createInvoiceReview(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt *v1.EarningsInvoice
+	if dbInvoice.Status == v1.InvoiceStatusPaid ||
+		dbInvoice.Status == v1.InvoiceStatusDisputed {
+		datePaid, err := c.getInvoiceDatePaid(dbInvoice)
+		if err != nil {
+			return nil, err
+		}
+
+		receipt, err = c.createEarningsInvoice(dbInvoice, datePaid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pdf := renderInvoicePDF(dbInvoice, contractor, invoiceReview, receipt)
+
+	// Sign the document's digest so that it can be verified independently.
+	digest := hex.EncodeToString(util.Digest(pdf))
+	signature := c.cfg.SigningIdentity.SignMessage([]byte(digest))
+
+	token := dbInvoice.Token
+	if len(token) > 7 {
+		token = token[:7]
+	}
+	return &v1.InvoicePDFReply{
+		File: v1.File{
+			Name: fmt.Sprintf("invoice_%v-%02v_%v.pdf", dbInvoice.Year,
+				dbInvoice.Month, token),
+			MIME:    "application/pdf",
+			Digest:  digest,
+			Payload: base64.StdEncoding.EncodeToString(pdf),
+		},
+		PublicKey: hex.EncodeToString(c.cfg.SigningIdentity.Public.Key[:]),
+		Signature: hex.EncodeToString(signature[:]),
+	}, nil
+}
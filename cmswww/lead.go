@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// isDomainLeadOf returns whether the user is a domain lead of the contract
+// domain of the user with the given id.
+func (c *cmswww) isDomainLeadOf(lead *database.User, userID uint64) (bool, error) {
+	if !hasPermission(lead, permissionLeadReview) ||
+		lead.ContractDomain == v1.ContractDomainInvalid {
+		return false, nil
+	}
+
+	user, err := c.db.GetUserById(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.ContractDomain == lead.ContractDomain, nil
+}
+
+// validateUserCanAccessInvoice returns an error if the user can't see the
+// invoice. Besides the users who can see any invoice and the invoice's
+// author, domain leads can see the invoices of the contractors in their
+// domain.
+func (c *cmswww) validateUserCanAccessInvoice(invoice *v1.InvoiceRecord, user *database.User) error {
+	err := validateUserCanSeeInvoice(invoice, user)
+	if err == nil {
+		return nil
+	}
+
+	authorID, parseErr := strconv.ParseUint(invoice.UserID, 10, 64)
+	if parseErr != nil {
+		return parseErr
+	}
+	isLead, leadErr := c.isDomainLeadOf(user, authorID)
+	if leadErr != nil {
+		return leadErr
+	}
+	if !isLead {
+		return err
+	}
+
+	return nil
+}
+
+// getInvoiceLeadReviews returns the domain lead reviews of an invoice,
+// oldest first. If a version is given, only the reviews of that version of
+// the invoice are returned.
+func (c *cmswww) getInvoiceLeadReviews(token, version string) ([]v1.InvoiceLeadReview, error) {
+	dbLeadReviews, err := c.db.GetInvoiceLeadReviews(token)
+	if err != nil {
+		return nil, err
+	}
+
+	leadReviews := make([]v1.InvoiceLeadReview, 0, len(dbLeadReviews))
+	for _, dbLeadReview := range dbLeadReviews {
+		if version != "" && dbLeadReview.InvoiceVersion != version {
+			continue
+		}
+
+		userID, err := c.db.GetUserIdByPublicKey(dbLeadReview.LeadPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		userIDStr := strconv.FormatUint(userID, 10)
+
+		leadReviews = append(leadReviews, v1.InvoiceLeadReview{
+			UserID:    userIDStr,
+			Username:  c.getUsernameByID(userIDStr),
+			PublicKey: dbLeadReview.LeadPublicKey,
+			Signature: dbLeadReview.LeadSignature,
+			Action:    dbLeadReview.Action,
+			Reason:    dbLeadReview.Reason,
+			Version:   dbLeadReview.InvoiceVersion,
+			Timestamp: dbLeadReview.Timestamp,
+		})
+	}
+
+	return leadReviews, nil
+}
+
+// HandleTeamInvoices returns the invoices of the contractors in the domain
+// lead's contract domain.
+func (c *cmswww) HandleTeamInvoices(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ti := req.(*v1.TeamInvoices)
+
+	if user.ContractDomain == v1.ContractDomainInvalid {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"user has no contract domain"},
+		}
+	}
+
+	statusMap := make(map[v1.InvoiceStatusT]bool)
+	if ti.Status != v1.InvoiceStatusInvalid {
+		statusMap[ti.Status] = true
+	}
+
+	invoices, numMatches, err := c.getInvoices(database.InvoicesRequest{
+		Domain:    user.ContractDomain,
+		Month:     ti.Month,
+		Year:      ti.Year,
+		StatusMap: statusMap,
+		Page:      int(ti.Page),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range invoices {
+		invoices[i].LeadReviews, err = c.getInvoiceLeadReviews(
+			invoices[i].CensorshipRecord.Token, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &v1.TeamInvoicesReply{
+		Invoices:     invoices,
+		TotalMatches: uint64(numMatches),
+	}, nil
+}
+
+// HandleLeadReviewInvoice records a domain lead's first-level review of an
+// unreviewed invoice from a contractor in their domain. The review doesn't
+// change the invoice status; it's shown to admins when they review the
+// invoice.
+func (c *cmswww) HandleLeadReviewInvoice(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	lri := req.(*v1.LeadReviewInvoice)
+
+	if lri.Action != v1.LeadReviewEndorse &&
+		lri.Action != v1.LeadReviewRequestChanges {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid review action"},
+		}
+	}
+
+	lri.Reason = strings.TrimSpace(lri.Reason)
+	if lri.Action == v1.LeadReviewRequestChanges && lri.Reason == "" {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusReasonNotProvided,
+		}
+	}
+
+	err := checkPublicKeyAndSignature(user, lri.PublicKey, lri.Signature,
+		lri.Token, strconv.FormatUint(uint64(lri.Action), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	dbInvoice, err := c.db.GetInvoiceByToken(lri.Token)
+	if err != nil {
+		if err == database.ErrInvoiceNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvoiceNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	// Invoices from other domains are treated as not found, so leads
+	// can't probe for them.
+	isLead, err := c.isDomainLeadOf(user, dbInvoice.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isLead {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvoiceNotFound,
+		}
+	}
+
+	if dbInvoice.UserID == user.ID {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"cannot review your own invoice"},
+		}
+	}
+
+	// Only invoices which are waiting for an admin review can be reviewed
+	// by a domain lead.
+	if dbInvoice.Status != v1.InvoiceStatusNotReviewed &&
+		dbInvoice.Status != v1.InvoiceStatusUnreviewedChanges {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+
+	mdLeadReview := BackendInvoiceMDLeadReview{
+		Version:        VersionBackendInvoiceMDLeadReview,
+		LeadSignature:  lri.Signature,
+		Action:         lri.Action,
+		Reason:         lri.Reason,
+		InvoiceVersion: dbInvoice.Version,
+		Timestamp:      time.Now().Unix(),
+	}
+
+	var ok bool
+	mdLeadReview.LeadPublicKey, ok = database.ActiveIdentityString(user.Identities)
+	if !ok {
+		return nil, fmt.Errorf("invalid domain lead identity: %v", user.ID)
+	}
+
+	err = c.appendInvoiceMetadata(lri.Token, mdStreamLeadReviews, mdLeadReview)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the database with the metadata changes.
+	dbInvoice.LeadReviews = append(dbInvoice.LeadReviews,
+		convertStreamLeadReviewToDatabaseInvoiceLeadReview(mdLeadReview))
+	err = c.db.UpdateInvoice(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the reply.
+	lrir := v1.LeadReviewInvoiceReply{
+		Invoice: *convertDatabaseInvoiceToInvoice(dbInvoice),
+	}
+	lrir.Invoice.LeadReviews, err = c.getInvoiceLeadReviews(lri.Token, "")
+	if err != nil {
+		return nil, err
+	}
+	return &lrir, nil
+}
@@ -36,26 +36,37 @@ func (c *cmswww) isLoggedIn(f http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// isLoggedInAsAdmin ensures that a user is logged in as an admin user
-// before calling the next function.
-func (c *cmswww) isLoggedInAsAdmin(f http.HandlerFunc) http.HandlerFunc {
+// isLoggedInWithPermission ensures that a user is logged in as an admin or
+// as a user with a role that grants the permission before calling the next
+// function.
+func (c *cmswww) isLoggedInWithPermission(perm permission, f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check if user is admin
-		isAdmin, err := c.isAdmin(r)
-		log.Debugf("isLoggedInAsAdmin: %v %v %v %v %v", isAdmin, remoteAddr(r),
-			r.Method, r.URL, r.Proto)
+		// Check if user has the permission
+		user, err := c.GetSessionUser(r)
+		granted := err == nil && hasPermission(user, perm)
+		log.Debugf("isLoggedInWithPermission: %v %v %v %v %v", granted,
+			remoteAddr(r), r.Method, r.URL, r.Proto)
 		if err != nil {
-			log.Errorf("isLoggedInAsAdmin: isAdmin %v", err)
+			log.Errorf("isLoggedInWithPermission: GetSessionUser %v", err)
 			util.RespondWithJSON(w, http.StatusUnauthorized, v1.ErrorReply{
 				ErrorCode: int64(v1.ErrorStatusNotLoggedIn),
 			})
 			return
 		}
-		if !isAdmin {
+		if !granted {
 			util.RespondWithJSON(w, http.StatusForbidden, v1.ErrorReply{})
 			return
 		}
 
+		// Check that the user has enabled two-factor authentication,
+		// if it's required.
+		if c.cfg.RequireAdminTOTP && !user.TOTPEnabled() {
+			util.RespondWithJSON(w, http.StatusForbidden, v1.ErrorReply{
+				ErrorCode: int64(v1.ErrorStatusTOTPNotEnabled),
+			})
+			return
+		}
+
 		f(w, r)
 	}
 }
@@ -70,6 +70,20 @@ func (c *cmswww) addInvoicePaymentForPolling(token string, invoicePayment *datab
 	c._addInvoicePaymentForPolling(token, invoicePayment)
 }
 
+// removeInvoiceFromPolling stops polling the payments of an invoice.
+//
+// This function must be called WITHOUT the mutex held.
+func (c *cmswww) removeInvoiceFromPolling(token string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for address, polledPayment := range c.polledPayments {
+		if polledPayment.token == token {
+			delete(c.polledPayments, address)
+		}
+	}
+}
+
 func (c *cmswww) addInvoicePaymentsForPolling() error {
 	c.Lock()
 	defer c.Unlock()
@@ -77,7 +91,7 @@ func (c *cmswww) addInvoicePaymentsForPolling() error {
 	// Create the in-memory pool of all invoices that need to be paid.
 	invoices, _, err := c.db.GetInvoices(database.InvoicesRequest{
 		StatusMap: map[v1.InvoiceStatusT]bool{
-			v1.InvoiceStatusApproved: true,
+			c.cfg.InvoiceWorkflow.PayableStatus: true,
 		},
 		Page: -1,
 	})
@@ -139,11 +153,12 @@ func (c *cmswww) checkForInvoicePayments(polledPayments map[string]polledPayment
 		log.Tracef("Checking the payment address for invoice %v...",
 			token)
 
-		if dbInvoice.Status == v1.InvoiceStatusPaid {
+		if dbInvoice.Status != c.cfg.InvoiceWorkflow.PayableStatus {
 			// The invoice could have been marked as paid by some external
-			// mechanism, so just remove it from polling.
+			// mechanism, or put on hold, so just remove it from polling.
 			addressesToRemove = append(addressesToRemove, token)
-			log.Tracef("  removing from polling, invoice already paid")
+			log.Tracef("  removing from polling, invoice is %v",
+				v1.InvoiceStatus[dbInvoice.Status])
 			continue
 		}
 
@@ -162,6 +177,15 @@ func (c *cmswww) checkForInvoicePayments(polledPayments map[string]polledPayment
 		}
 
 		if txID != "" {
+			// Fetch the invoice again, since its status may have changed
+			// while the payment was looked up.
+			dbInvoice, err = c.db.GetInvoiceByToken(polledPayment.token)
+			if err != nil {
+				log.Errorf("cannot fetch invoice by token %v: %v\n", token,
+					err)
+				continue
+			}
+
 			err := c.updateInvoicePayment(dbInvoice, polledPayment.address,
 				polledPayment.amount, txID)
 			if err != nil {
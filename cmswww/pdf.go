@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// A4 page size and margins, in points.
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+
+	// pdfCharWidth is the width of Helvetica digits relative to the font
+	// size, which is wider than most lowercase letters and is used to wrap
+	// text.
+	pdfCharWidth = 0.556
+)
+
+// pdfDocument is a minimal writer of text documents in PDF format. It uses
+// the standard Helvetica fonts, which every PDF reader provides, so that no
+// fonts need to be embedded. Text is laid out from the top of the page
+// down, and a new page is started whenever the current one is full.
+type pdfDocument struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64 // Vertical position on the current page
+}
+
+// newPDFDocument returns a document with a single empty page.
+func newPDFDocument(title string) *pdfDocument {
+	d := pdfDocument{
+		title: title,
+	}
+	d.newPage()
+	return &d
+}
+
+// newPage starts a new page.
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// advance moves down by the given height, starting a new page if there's
+// no room left for it on the current one.
+func (d *pdfDocument) advance(height float64) {
+	if d.y-height < pdfMargin {
+		d.newPage()
+	}
+	d.y -= height
+}
+
+// pdfString encodes a string as a PDF string literal. Characters outside of
+// Latin-1 can't be shown with the standard fonts and are replaced.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		case r >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// text writes the text at the horizontal position on the current line.
+func (d *pdfDocument) text(x, size float64, bold bool, s string) {
+	if s == "" {
+		return
+	}
+
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%v %.1f Tf %.2f %.2f Td %v Tj ET\n",
+		font, size, x, d.y, pdfString(s))
+}
+
+// line writes a line of text at the left margin and moves below it.
+func (d *pdfDocument) line(size float64, bold bool, s string) {
+	d.advance(size * 1.4)
+	d.text(pdfMargin, size, bold, s)
+}
+
+// rule draws a horizontal line across the page and moves below it.
+func (d *pdfDocument) rule() {
+	d.advance(8)
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		pdfMargin, d.y+4, pdfPageWidth-pdfMargin, d.y+4)
+}
+
+// space leaves an empty space of the given height.
+func (d *pdfDocument) space(height float64) {
+	d.advance(height)
+}
+
+// wrapText splits the text into lines which fit in the given width when
+// written in the given font size. Words which are too long are split.
+func wrapText(s string, width, size float64) []string {
+	maxChars := int(width / (size * pdfCharWidth))
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	lines := make([]string, 0, 1)
+	var current []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(current) != 0 && len(current)+1+len(w) > maxChars {
+			lines = append(lines, string(current))
+			current = nil
+		}
+		for len(w) > maxChars {
+			lines = append(lines, string(w[:maxChars]))
+			w = w[maxChars:]
+		}
+		if len(current) != 0 {
+			current = append(current, ' ')
+		}
+		current = append(current, w...)
+	}
+	if len(current) != 0 || len(lines) == 0 {
+		lines = append(lines, string(current))
+	}
+
+	return lines
+}
+
+// bytes returns the document encoded in PDF format.
+func (d *pdfDocument) bytes() []byte {
+	var buf bytes.Buffer
+	offsets := make([]int, 0)
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%v 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// The catalog, page tree, fonts and document information come first,
+	// followed by each page and its content stream.
+	const firstPageObject = 6
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%v 0 R", firstPageObject+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%v] /Count %v >>",
+		strings.Join(kids, " "), len(d.pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica " +
+		"/Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold " +
+		"/Encoding /WinAnsiEncoding >>")
+	object("<< /Title %v /Producer (cmswww) >>", pdfString(d.title))
+	for i, page := range d.pages {
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> "+
+			"/Contents %v 0 R >>", pdfPageWidth, pdfPageHeight,
+			firstPageObject+2*i+1)
+		object("<< /Length %v >>\nstream\n%v\nendstream", page.Len(),
+			page.String())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %v\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %v /Root 1 0 R /Info 5 0 R >>\n"+
+		"startxref\n%v\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/decred/politeia/util"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// parseProposals parses proposals of the form <token>[:<name>] into a map of
+// proposal names by token.
+func parseProposals(proposals []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(proposals))
+	for _, proposal := range proposals {
+		var token, name string
+		idx := strings.Index(proposal, ":")
+		if idx == -1 {
+			token = proposal
+		} else {
+			token = proposal[:idx]
+			name = strings.TrimSpace(proposal[idx+1:])
+		}
+
+		token = strings.TrimSpace(token)
+		if _, err := util.ConvertStringToken(token); err != nil {
+			return nil, fmt.Errorf("invalid proposal token in %q: %v",
+				proposal, err)
+		}
+		if _, ok := parsed[token]; ok {
+			return nil, fmt.Errorf("duplicate proposal %v", token)
+		}
+
+		parsed[token] = name
+	}
+
+	return parsed, nil
+}
+
+// validateProposalToken returns an error if the token isn't a well-formed
+// proposal token, or if proposals are configured and it isn't one of them.
+func (c *cmswww) validateProposalToken(token string) error {
+	_, err := util.ConvertStringToken(token)
+	if err != nil {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidProposal,
+			ErrorContext: []string{token},
+		}
+	}
+
+	if len(c.cfg.ProposalNames) == 0 {
+		return nil
+	}
+	if _, ok := c.cfg.ProposalNames[token]; !ok {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidProposal,
+			ErrorContext: []string{token},
+		}
+	}
+
+	return nil
+}
+
+// validateLineItemProposal returns an error if the line item, given as the
+// values of the invoice fields, references an invalid proposal. The
+// proposal is optional. Line items are numbered from 1.
+func (c *cmswww) validateLineItemProposal(lineItem int, record []string) error {
+	// The proposal is the 4th field.
+	if len(record) < len(v1.InvoiceFields) {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusMalformedInvoiceFile,
+		}
+	}
+
+	proposal := strings.TrimSpace(record[3])
+	if proposal == "" {
+		return nil
+	}
+
+	err := c.validateProposalToken(proposal)
+	if err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidProposal,
+			ErrorContext: []string{
+				fmt.Sprintf("line item %v", lineItem),
+				proposal,
+			},
+		}
+	}
+
+	return nil
+}
+
+// getProposals returns the configured proposals and the proposals which
+// have a budget, by token.
+func (c *cmswww) getProposals() (map[string]*v1.Proposal, error) {
+	proposals := make(map[string]*v1.Proposal)
+	for token, name := range c.cfg.ProposalNames {
+		proposals[token] = &v1.Proposal{
+			Token: token,
+			Name:  name,
+		}
+	}
+
+	dbBudgets, err := c.db.GetProposalBudgets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dbBudget := range dbBudgets {
+		proposal, ok := proposals[dbBudget.Token]
+		if !ok {
+			proposal = &v1.Proposal{
+				Token: dbBudget.Token,
+			}
+			proposals[dbBudget.Token] = proposal
+		}
+		proposal.BudgetUSD = dbBudget.BudgetUSD
+		proposal.Timestamp = dbBudget.Timestamp
+	}
+
+	return proposals, nil
+}
+
+// getInvoiceProposalCosts returns the amount billed against each proposal
+// by the line items of the invoice, whose file must be set.
+func (c *cmswww) getInvoiceProposalCosts(dbInvoice *database.Invoice) ([]database.InvoiceProposalCost, error) {
+	invoiceReview, err := c.createInvoiceReview(dbInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make([]database.InvoiceProposalCost, 0)
+	indexes := make(map[string]int)
+	for _, lineItem := range invoiceReview.LineItems {
+		if lineItem.Proposal == "" {
+			continue
+		}
+
+		idx, ok := indexes[lineItem.Proposal]
+		if !ok {
+			idx = len(costs)
+			indexes[lineItem.Proposal] = idx
+			costs = append(costs, database.InvoiceProposalCost{
+				Proposal: lineItem.Proposal,
+			})
+		}
+		costs[idx].TotalCost += lineItem.TotalCost
+	}
+
+	return costs, nil
+}
+
+// updateInvoiceProposalCosts records the amounts billed against proposals by
+// the invoice, so that the spending of proposals can be looked up without
+// reading every invoice.
+func (c *cmswww) updateInvoiceProposalCosts(dbInvoice *database.Invoice) error {
+	err := c.fetchInvoiceFileIfNecessary(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	costs, err := c.getInvoiceProposalCosts(dbInvoice)
+	if err != nil {
+		return err
+	}
+
+	return c.db.SetInvoiceProposalCosts(dbInvoice.Token, costs)
+}
+
+// getProposalSpending returns the amounts billed and paid against the given
+// proposals, or all proposals if none are given, by the invoices with the
+// given statuses, by token. Proposals without a name or budget are included
+// if they're billed against.
+func (c *cmswww) getProposalSpending(
+	statusMap map[v1.InvoiceStatusT]bool,
+	proposals map[string]*v1.Proposal,
+	tokens []string,
+) (map[string]*v1.ProposalSpending, error) {
+	costs, err := c.db.GetProposalCosts(database.ProposalCostsRequest{
+		Proposals: tokens,
+		StatusMap: statusMap,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		requested[token] = true
+	}
+
+	spending := make(map[string]*v1.ProposalSpending)
+	for token, proposal := range proposals {
+		if len(tokens) != 0 && !requested[token] {
+			continue
+		}
+		spending[token] = &v1.ProposalSpending{
+			Proposal: *proposal,
+			Months:   make([]v1.ProposalMonthSpending, 0),
+		}
+	}
+
+	for _, cost := range costs {
+		ps, ok := spending[cost.Proposal]
+		if !ok {
+			ps = &v1.ProposalSpending{
+				Proposal: v1.Proposal{
+					Token: cost.Proposal,
+				},
+				Months: make([]v1.ProposalMonthSpending, 0),
+			}
+			spending[cost.Proposal] = ps
+		}
+
+		var month *v1.ProposalMonthSpending
+		for idx := range ps.Months {
+			if ps.Months[idx].Month == cost.Month &&
+				ps.Months[idx].Year == cost.Year {
+				month = &ps.Months[idx]
+				break
+			}
+		}
+		if month == nil {
+			ps.Months = append(ps.Months, v1.ProposalMonthSpending{
+				Month: cost.Month,
+				Year:  cost.Year,
+			})
+			month = &ps.Months[len(ps.Months)-1]
+		}
+
+		ps.BilledUSD += cost.TotalCost
+		month.BilledUSD += cost.TotalCost
+		if cost.Status == v1.InvoiceStatusPaid ||
+			cost.Status == v1.InvoiceStatusDisputed {
+			ps.PaidUSD += cost.TotalCost
+			month.PaidUSD += cost.TotalCost
+		}
+	}
+
+	for _, ps := range spending {
+		ps.OverBudget = ps.Proposal.BudgetUSD != 0 &&
+			ps.BilledUSD > ps.Proposal.BudgetUSD
+
+		sort.Slice(ps.Months, func(i, j int) bool {
+			if ps.Months[i].Year != ps.Months[j].Year {
+				return ps.Months[i].Year < ps.Months[j].Year
+			}
+			return ps.Months[i].Month < ps.Months[j].Month
+		})
+	}
+
+	return spending, nil
+}
+
+// getProposalBudgetWarnings returns a warning for every proposal whose
+// budget is exceeded by the committed spending together with the line items
+// of the invoice under review.
+func getProposalBudgetWarnings(
+	invoiceReview *v1.InvoiceReview,
+	committed map[string]*v1.ProposalSpending,
+) []v1.ProposalBudgetWarning {
+	billed := make(map[string]uint64)
+	tokens := make([]string, 0)
+	for _, lineItem := range invoiceReview.LineItems {
+		if lineItem.Proposal == "" {
+			continue
+		}
+		if _, ok := billed[lineItem.Proposal]; !ok {
+			tokens = append(tokens, lineItem.Proposal)
+		}
+		billed[lineItem.Proposal] += lineItem.TotalCost
+	}
+
+	var warnings []v1.ProposalBudgetWarning
+	for _, token := range tokens {
+		ps, ok := committed[token]
+		if !ok || ps.Proposal.BudgetUSD == 0 {
+			continue
+		}
+
+		total := ps.BilledUSD + billed[token]
+		if total > ps.Proposal.BudgetUSD {
+			warnings = append(warnings, v1.ProposalBudgetWarning{
+				Token:     token,
+				Name:      ps.Proposal.Name,
+				BudgetUSD: ps.Proposal.BudgetUSD,
+				BilledUSD: total,
+			})
+		}
+	}
+
+	return warnings
+}
+
+// HandleProposals returns the proposals which invoices can be billed
+// against, along with their budgets.
+func (c *cmswww) HandleProposals(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	proposals, err := c.getProposals()
+	if err != nil {
+		return nil, err
+	}
+
+	pr := v1.ProposalsReply{
+		Proposals: make([]v1.Proposal, 0, len(proposals)),
+	}
+	for _, proposal := range proposals {
+		pr.Proposals = append(pr.Proposals, *proposal)
+	}
+	sort.Slice(pr.Proposals, func(i, j int) bool {
+		return pr.Proposals[i].Token < pr.Proposals[j].Token
+	})
+
+	return &pr, nil
+}
+
+// HandleSetProposalBudget sets the budget of a proposal.
+func (c *cmswww) HandleSetProposalBudget(
+	req interface{},
+	adminUser *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	spb := req.(*v1.SetProposalBudget)
+
+	spb.Token = strings.TrimSpace(spb.Token)
+	err := c.validateProposalToken(spb.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	dbBudget := database.ProposalBudget{
+		Token:     spb.Token,
+		BudgetUSD: spb.BudgetUSD,
+	}
+	err = c.db.SaveProposalBudget(&dbBudget)
+	if err != nil {
+		return nil, err
+	}
+
+	// Append this action to the admin log file.
+	err = c.logAdminAction(adminUser, fmt.Sprintf("%v,%v,%v",
+		"set proposal budget", spb.Token, spb.BudgetUSD))
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.SetProposalBudgetReply{
+		Proposal: v1.Proposal{
+			Token:     dbBudget.Token,
+			Name:      c.cfg.ProposalNames[dbBudget.Token],
+			BudgetUSD: dbBudget.BudgetUSD,
+			Timestamp: dbBudget.Timestamp,
+		},
+	}, nil
+}
+
+// HandleProposalReport returns the amounts billed and paid against each
+// proposal, per month, along with their budgets.
+func (c *cmswww) HandleProposalReport(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	pr := req.(*v1.ProposalReport)
+
+	proposals, err := c.getProposals()
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	if pr.Token != "" {
+		tokens = []string{pr.Token}
+	}
+
+	// Rejected invoices aren't billed against the proposals.
+	spending, err := c.getProposalSpending(map[v1.InvoiceStatusT]bool{
+		v1.InvoiceStatusNotReviewed:       true,
+		v1.InvoiceStatusUnreviewedChanges: true,
+		v1.InvoiceStatusApproved:          true,
+		v1.InvoiceStatusAwaitingApproval:  true,
+		v1.InvoiceStatusOnHold:            true,
+		v1.InvoiceStatusReadyForPayment:   true,
+		v1.InvoiceStatusPaid:              true,
+		v1.InvoiceStatusDisputed:          true,
+	}, proposals, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	prr := v1.ProposalReportReply{
+		Proposals: make([]v1.ProposalSpending, 0, len(spending)),
+	}
+	for _, ps := range spending {
+		prr.Proposals = append(prr.Proposals, *ps)
+	}
+	sort.Slice(prr.Proposals, func(i, j int) bool {
+		return prr.Proposals[i].Proposal.Token <
+			prr.Proposals[j].Proposal.Token
+	})
+
+	return &prr, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+const (
+	// rateLimiterPruneInterval is how often buckets which have refilled
+	// are removed from a rate limiter.
+	rateLimiterPruneInterval = 10 * time.Minute
+)
+
+// tokenBucket holds the tokens available to a single client or account.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // Last time tokens were added
+}
+
+// rateLimiter is a token bucket rate limiter keyed on an arbitrary string,
+// such as a client address or an email address. A nil rateLimiter allows
+// everything.
+type rateLimiter struct {
+	sync.Mutex
+
+	rate      float64 // Tokens added per second
+	burst     float64 // Bucket size
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+// newRateLimiter returns a rate limiter which allows perMinute requests per
+// minute for each key, with bursts of up to burst requests. It returns nil
+// if perMinute is 0.
+func newRateLimiter(perMinute, burst uint) *rateLimiter {
+	if perMinute == 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:      float64(perMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// take removes a token from the key's bucket. If the bucket is empty, it
+// returns false and how long to wait until a token is available.
+func (l *rateLimiter) take(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{
+			tokens: l.burst,
+			last:   now,
+		}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// prune removes the buckets which would be full by now, since they're
+// indistinguishable from new buckets. This function must be called with the
+// lock held.
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < rateLimiterPruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// parseTrustedProxies parses the addresses or CIDR ranges of the proxies
+// whose X-Forwarded-For headers are trusted.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", proxy,
+				err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// isTrustedProxy returns whether the address belongs to a trusted proxy.
+func (c *cmswww) isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range c.cfg.TrustedProxyNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns the address of the client which made the request. The
+// X-Forwarded-For header is only honored when the request comes from a
+// trusted proxy, in which case the address closest to the server which
+// isn't a trusted proxy is used.
+func (c *cmswww) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !c.isTrustedProxy(ip) {
+		return host
+	}
+
+	addrs := strings.Split(r.Header.Get(v1.Forward), ",")
+	for i := len(addrs) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addrs[i]))
+		if ip == nil {
+			break
+		}
+
+		host = ip.String()
+		if !c.isTrustedProxy(ip) {
+			break
+		}
+	}
+
+	return host
+}
+
+// newTooManyRequestsError returns the user error for a request which has been
+// rate limited, with the number of seconds to wait before trying again.
+func newTooManyRequestsError(wait time.Duration) v1.UserError {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	return v1.UserError{
+		ErrorCode:    v1.ErrorStatusTooManyRequests,
+		ErrorContext: []string{strconv.FormatInt(seconds, 10)},
+	}
+}
+
+// checkRateLimits takes a token for the client address and for the account
+// which the request is for, and returns an error if either is exhausted.
+func (c *cmswww) checkRateLimits(r *http.Request, email string) error {
+	now := time.Now()
+
+	ip := c.clientIP(r)
+	ok, wait := c.ipRateLimiter.take(ip, now)
+	if !ok {
+		log.Infof("Rate limited client %v: %v %v", ip, r.Method, r.URL)
+		return newTooManyRequestsError(wait)
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return nil
+	}
+	ok, wait = c.accountRateLimiter.take(email, now)
+	if !ok {
+		log.Infof("Rate limited account %v from %v: %v %v", email, ip,
+			r.Method, r.URL)
+		return newTooManyRequestsError(wait)
+	}
+
+	return nil
+}
+
+// loginDelay returns how long the user must wait after their last failed
+// login attempt before trying again. The delay doubles with each failed
+// attempt once LoginDelayAfter attempts have failed, up to MaxLoginDelay.
+func (c *cmswww) loginDelay(failedLoginAttempts uint64) time.Duration {
+	if c.cfg.LoginDelayAfter == 0 ||
+		failedLoginAttempts < c.cfg.LoginDelayAfter {
+		return 0
+	}
+
+	shift := failedLoginAttempts - c.cfg.LoginDelayAfter
+	if shift >= 32 {
+		return c.cfg.MaxLoginDelay
+	}
+
+	delay := time.Second << shift
+	if delay > c.cfg.MaxLoginDelay {
+		delay = c.cfg.MaxLoginDelay
+	}
+	return delay
+}
+
+// loginDelayRemaining returns how long the user must still wait before
+// trying to log in again.
+func (c *cmswww) loginDelayRemaining(user *database.User, now time.Time) time.Duration {
+	delay := c.loginDelay(user.FailedLoginAttempts)
+	if delay == 0 {
+		return 0
+	}
+
+	return time.Unix(user.LastFailedLogin, 0).Add(delay).Sub(now)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+)
+
+func TestClientIP(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", "192.0.2.1", "::1"}
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		ip             string
+	}{
+		{
+			name:         "no trusted proxies",
+			remoteAddr:   "203.0.113.7:5000",
+			forwardedFor: "198.51.100.1",
+			ip:           "203.0.113.7",
+		},
+		{
+			name:           "untrusted remote address",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "203.0.113.7:5000",
+			forwardedFor:   "198.51.100.1",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "trusted proxy without header",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			ip:             "192.0.2.1",
+		},
+		{
+			name:           "single proxy",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			forwardedFor:   "203.0.113.7",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "spoofed address before the client",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			forwardedFor:   "198.51.100.1, 203.0.113.7",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "spoofed trusted address before the client",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			forwardedFor:   "10.0.0.5, 203.0.113.7",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "multiple trusted proxies",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:5000",
+			forwardedFor:   "203.0.113.7, 10.0.0.3, 10.0.0.2",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "multiple proxies with spoofed address",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:5000",
+			forwardedFor:   "198.51.100.1,203.0.113.7,10.0.0.2",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "untrusted proxy between trusted proxies",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:5000",
+			forwardedFor:   "203.0.113.7, 198.51.100.9, 10.0.0.2",
+			ip:             "198.51.100.9",
+		},
+		{
+			name:           "only trusted proxies",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:5000",
+			forwardedFor:   "10.0.0.3, 10.0.0.2",
+			ip:             "10.0.0.3",
+		},
+		{
+			name:           "invalid address before the client",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			forwardedFor:   "unknown, 203.0.113.7",
+			ip:             "203.0.113.7",
+		},
+		{
+			name:           "invalid address after a trusted proxy",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:5000",
+			forwardedFor:   "203.0.113.7, unknown, 10.0.0.2",
+			ip:             "10.0.0.2",
+		},
+		{
+			name:           "invalid header",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1:5000",
+			forwardedFor:   "unknown",
+			ip:             "192.0.2.1",
+		},
+		{
+			name:           "IPv6",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "[::1]:5000",
+			forwardedFor:   "2001:db8::1",
+			ip:             "2001:db8::1",
+		},
+		{
+			name:           "remote address without port",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "192.0.2.1",
+			forwardedFor:   "203.0.113.7",
+			ip:             "203.0.113.7",
+		},
+	}
+
+	for _, test := range tests {
+		nets, err := parseTrustedProxies(test.trustedProxies)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		c := &cmswww{
+			cfg: &config{
+				TrustedProxyNets: nets,
+			},
+		}
+
+		r, err := http.NewRequest(http.MethodPost, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RemoteAddr = test.remoteAddr
+		if test.forwardedFor != "" {
+			r.Header.Set(v1.Forward, test.forwardedFor)
+		}
+
+		ip := c.clientIP(r)
+		if ip != test.ip {
+			t.Errorf("%v: got %v, want %v", test.name, ip, test.ip)
+		}
+	}
+}
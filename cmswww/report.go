@@ -0,0 +1,210 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// spendingGroups accumulates the spending of line items by group name.
+type spendingGroups map[string]*v1.SpendingGroup
+
+// add adds the spending of a line item to its group.
+func (g spendingGroups) add(key, name, typ string, hours, costUSD uint64, costDCR float64) {
+	group, ok := g[key]
+	if !ok {
+		group = &v1.SpendingGroup{
+			Name: name,
+			Type: typ,
+		}
+		g[key] = group
+	}
+
+	group.TotalHours += hours
+	group.TotalCostUSD += costUSD
+	group.TotalCostDCR += costDCR
+}
+
+// sorted returns the groups ordered by cost, largest first.
+func (g spendingGroups) sorted() []v1.SpendingGroup {
+	groups := make([]v1.SpendingGroup, 0, len(g))
+	for _, group := range g {
+		groups = append(groups, *group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].TotalCostUSD != groups[j].TotalCostUSD {
+			return groups[i].TotalCostUSD > groups[j].TotalCostUSD
+		}
+		if groups[i].Type != groups[j].Type {
+			return groups[i].Type < groups[j].Type
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// spendingAggregator accumulates the spending of invoices into a summary.
+type spendingAggregator struct {
+	summary     v1.SpendingSummary
+	types       spendingGroups
+	subtypes    spendingGroups
+	contractors spendingGroups
+	domains     spendingGroups
+	proposals   spendingGroups
+}
+
+func newSpendingAggregator() *spendingAggregator {
+	return &spendingAggregator{
+		types:       make(spendingGroups),
+		subtypes:    make(spendingGroups),
+		contractors: make(spendingGroups),
+		domains:     make(spendingGroups),
+		proposals:   make(spendingGroups),
+	}
+}
+
+// add adds the line items of an invoice to the summary. The cost of each
+// line item in DCR is its share of the DCR paid for the invoice.
+func (a *spendingAggregator) add(
+	invoiceReview *v1.InvoiceReview,
+	domain v1.ContractDomainT,
+	costDCR float64,
+) {
+	a.summary.Invoices++
+	a.summary.TotalHours += invoiceReview.TotalHours
+	a.summary.TotalCostUSD += invoiceReview.TotalCostUSD
+	a.summary.TotalCostDCR += costDCR
+
+	domainName := ""
+	if domain != v1.ContractDomainInvalid {
+		domainName = v1.ContractDomain[domain]
+	}
+
+	for _, lineItem := range invoiceReview.LineItems {
+		var lineItemDCR float64
+		if invoiceReview.TotalCostUSD != 0 {
+			lineItemDCR = costDCR * float64(lineItem.TotalCost) /
+				float64(invoiceReview.TotalCostUSD)
+		}
+
+		a.types.add(lineItem.Type, lineItem.Type, "", lineItem.Hours,
+			lineItem.TotalCost, lineItemDCR)
+		a.subtypes.add(lineItem.Type+"/"+lineItem.Subtype, lineItem.Subtype,
+			lineItem.Type, lineItem.Hours, lineItem.TotalCost, lineItemDCR)
+		a.contractors.add(invoiceReview.UserID, invoiceReview.Username, "",
+			lineItem.Hours, lineItem.TotalCost, lineItemDCR)
+		a.domains.add(domainName, domainName, "", lineItem.Hours,
+			lineItem.TotalCost, lineItemDCR)
+		a.proposals.add(lineItem.Proposal, lineItem.Proposal, "",
+			lineItem.Hours, lineItem.TotalCost, lineItemDCR)
+	}
+}
+
+// result returns the summary with its groups.
+func (a *spendingAggregator) result() v1.SpendingSummary {
+	summary := a.summary
+	summary.ByType = a.types.sorted()
+	summary.BySubtype = a.subtypes.sorted()
+	summary.ByContractor = a.contractors.sorted()
+	summary.ByDomain = a.domains.sorted()
+	summary.ByProposal = a.proposals.sorted()
+	return summary
+}
+
+// getInvoicePaidDCR returns the amount of DCR of the invoice's payments
+// which have been made, which reflects the USD/DCR rate it was paid at.
+// Payments which have been generated but not made don't count, since their
+// rate may not be the one the invoice is paid at.
+func getInvoicePaidDCR(dbInvoice *database.Invoice) float64 {
+	var atoms uint64
+	for _, payment := range dbInvoice.Payments {
+		if payment.TxID == "" {
+			continue
+		}
+		atoms += payment.Amount
+	}
+
+	return dcrutil.Amount(atoms).ToCoin()
+}
+
+// HandleSpendingReport returns the spending of the approved and paid
+// invoices of a month, and of the year up to and including that month.
+// Invoices which are on hold, ready for payment or disputed are included
+// with them.
+func (c *cmswww) HandleSpendingReport(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	sr := req.(*v1.SpendingReport)
+
+	if sr.Month < 1 || sr.Month > 12 || sr.Year == 0 {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid month"},
+		}
+	}
+
+	invoices, _, err := c.db.GetInvoices(database.InvoicesRequest{
+		Year: sr.Year,
+		StatusMap: map[v1.InvoiceStatusT]bool{
+			v1.InvoiceStatusApproved:        true,
+			v1.InvoiceStatusOnHold:          true,
+			v1.InvoiceStatusReadyForPayment: true,
+			v1.InvoiceStatusPaid:            true,
+			v1.InvoiceStatusDisputed:        true,
+		},
+		Page:            -1,
+		IncludePayments: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	month := newSpendingAggregator()
+	yearToDate := newSpendingAggregator()
+	domains := make(map[uint64]v1.ContractDomainT)
+	for i := range invoices {
+		invoice := &invoices[i]
+		if invoice.Month > sr.Month {
+			continue
+		}
+
+		err := c.fetchInvoiceFileIfNecessary(invoice)
+		if err != nil {
+			return nil, err
+		}
+
+		invoiceReview, err := c.createInvoiceReview(invoice)
+		if err != nil {
+			return nil, err
+		}
+
+		domain, ok := domains[invoice.UserID]
+		if !ok {
+			contractor, err := c.db.GetUserById(invoice.UserID)
+			if err != nil {
+				return nil, err
+			}
+			domain = contractor.ContractDomain
+			domains[invoice.UserID] = domain
+		}
+
+		costDCR := getInvoicePaidDCR(invoice)
+		yearToDate.add(invoiceReview, domain, costDCR)
+		if invoice.Month == sr.Month {
+			month.add(invoiceReview, domain, costDCR)
+		}
+	}
+
+	return &v1.SpendingReportReply{
+		Month:      month.result(),
+		YearToDate: yearToDate.result(),
+	}, nil
+}
@@ -33,14 +33,17 @@ func (c *cmswww) addRoute(
 		handler = c.loadInventory(handler)
 	}
 	switch perm {
-	case permissionAdmin:
-		handler = logging(c.isLoggedInAsAdmin(handler))
+	case permissionPublic:
 	case permissionLogin:
-		handler = logging(c.isLoggedIn(handler))
+		handler = c.isLoggedIn(handler)
 	default:
-		handler = logging(handler)
+		handler = c.isLoggedInWithPermission(perm, handler)
 	}
 
+	// Requests with an API token are authenticated before the permission
+	// check.
+	handler = logging(c.withAPIToken(route, handler))
+
 	// All handlers need to close the body
 	handler = closeBody(handler)
 
@@ -132,7 +135,8 @@ func (c *cmswww) SetupRoutes() {
 	c.router = mux.NewRouter()
 
 	// Public routes.
-	c.router.HandleFunc("/", closeBody(logging(c.HandleVersion))).Methods(http.MethodGet)
+	c.router.HandleFunc("/", closeBody(logging(c.withAPIToken(v1.RouteRoot,
+		c.HandleVersion)))).Methods(http.MethodGet)
 	c.router.NotFoundHandler = closeBody(c.HandleNotFound)
 	c.addGetRoute(v1.RoutePolicy, c.HandlePolicy, v1.Policy{},
 		permissionPublic, false)
@@ -140,6 +144,8 @@ func (c *cmswww) SetupRoutes() {
 		permissionPublic, false)
 	c.addPostRoute(v1.RouteLogin, c.HandleLogin, v1.Login{},
 		permissionPublic, false)
+	c.addPostRoute(v1.RouteLoginChallenge, c.HandleLoginChallenge,
+		v1.LoginChallenge{}, permissionPublic, false)
 	c.addRoute(http.MethodPost, v1.RouteLogout, c.HandleLogout,
 		permissionPublic, false)
 	c.addPostRoute(v1.RouteResetPassword, c.HandleResetPassword,
@@ -150,16 +156,50 @@ func (c *cmswww) SetupRoutes() {
 		v1.NewIdentity{}, permissionLogin, false)
 	c.addPostRoute(v1.RouteVerifyNewIdentity, c.HandleVerifyNewIdentity,
 		v1.VerifyNewIdentity{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteSetTOTP, c.HandleSetTOTP,
+		v1.SetTOTP{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteVerifyTOTP, c.HandleVerifyTOTP,
+		v1.VerifyTOTP{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteNewAPIToken, c.HandleNewAPIToken,
+		v1.NewAPIToken{}, permissionLogin, false)
+	c.addGetRoute(v1.RouteAPITokens, c.HandleAPITokens,
+		v1.APITokens{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteRevokeAPIToken, c.HandleRevokeAPIToken,
+		v1.RevokeAPIToken{}, permissionLogin, false)
+	c.addGetRoute(v1.RouteUserSessions, c.HandleUserSessions,
+		v1.UserSessions{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteRevokeSessions, c.HandleRevokeSessions,
+		v1.RevokeSessions{}, permissionLogin, false)
 	c.addPostRoute(v1.RouteChangePassword, c.HandleChangePassword,
 		v1.ChangePassword{}, permissionLogin, false)
 	c.addPostRoute(v1.RouteSubmitInvoice, c.HandleSubmitInvoice,
 		v1.SubmitInvoice{}, permissionLogin, true)
 	c.addPostRoute(v1.RouteEditInvoice, c.HandleEditInvoice,
 		v1.EditInvoice{}, permissionLogin, true)
+	c.addPostRoute(v1.RouteWithdrawInvoice, c.HandleWithdrawInvoice,
+		v1.WithdrawInvoice{}, permissionLogin, true)
 	c.addGetRoute(v1.RouteInvoiceDetails, c.HandleInvoiceDetails,
 		v1.InvoiceDetails{}, permissionLogin, true)
+	c.addGetRoute(v1.RouteInvoicePDF, c.HandleInvoicePDF,
+		v1.InvoicePDF{}, permissionLogin, true)
 	c.addGetRoute(v1.RouteUserInvoices, c.HandleUserInvoices,
 		v1.UserInvoices{}, permissionLogin, true)
+	c.addGetRoute(v1.RouteUserEarnings, c.HandleUserEarnings,
+		v1.UserEarnings{}, permissionLogin, true)
+	c.addGetRoute(v1.RouteInvoiceComments, c.HandleInvoiceComments,
+		v1.InvoiceComments{}, permissionLogin, true)
+	c.addPostRoute(v1.RouteNewInvoiceComment, c.HandleNewInvoiceComment,
+		v1.NewInvoiceComment{}, permissionLogin, true)
+	c.addGetRoute(v1.RouteInvoiceDraft, c.HandleInvoiceDraftDetails,
+		v1.InvoiceDraftDetails{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteNewDraftLineItem, c.HandleNewDraftLineItem,
+		v1.NewDraftLineItem{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteEditDraftLineItem, c.HandleEditDraftLineItem,
+		v1.EditDraftLineItem{}, permissionLogin, false)
+	c.addPostRoute(v1.RouteDeleteDraftLineItem, c.HandleDeleteDraftLineItem,
+		v1.DeleteDraftLineItem{}, permissionLogin, false)
+	c.addGetRoute(v1.RouteProposals, c.HandleProposals, v1.Proposals{},
+		permissionLogin, false)
 	c.addPostRoute(v1.RouteEditUser, c.HandleEditUser, v1.EditUser{},
 		permissionLogin, false)
 	c.addGetRoute(v1.RouteUserDetails, c.HandleUserDetails, v1.UserDetails{},
@@ -167,26 +207,49 @@ func (c *cmswww) SetupRoutes() {
 	c.addPostRoute(v1.RouteEditUserExtendedPublicKey,
 		c.HandleEditUserExtendedPublicKey, v1.EditUserExtendedPublicKey{},
 		permissionLogin, false)
+	c.addPostRoute(v1.RouteChangeEmail, c.HandleChangeEmail,
+		v1.ChangeEmail{}, permissionLogin, false)
 
-	// Routes that require being logged in as an admin user.
+	// Routes that require being logged in as an admin or as a user with
+	// a role that grants the permission.
 	c.addPostRoute(v1.RouteInviteNewUser, c.HandleInviteNewUser,
-		v1.InviteNewUser{}, permissionAdmin, false)
+		v1.InviteNewUser{}, permissionManageUsers, false)
 	c.addPostRoute(v1.RouteManageUser, c.HandleManageUser, v1.ManageUser{},
-		permissionAdmin, false)
+		permissionManageUsers, false)
+	c.addPostRoute(v1.RouteEditUserContract, c.HandleEditUserContract,
+		v1.EditUserContract{}, permissionManageUsers, false)
 	c.addGetRoute(v1.RouteInvoices, c.HandleInvoices,
-		v1.Invoices{}, permissionAdmin, true)
+		v1.Invoices{}, permissionViewInvoices, true)
 	c.addPostRoute(v1.RouteSetInvoiceStatus, c.HandleSetInvoiceStatus,
-		v1.SetInvoiceStatus{}, permissionAdmin, true)
+		v1.SetInvoiceStatus{}, permissionSetStatus, true)
+	c.addPostRoute(v1.RouteSetInvoiceStatuses, c.HandleSetInvoiceStatuses,
+		v1.SetInvoiceStatuses{}, permissionSetStatus, true)
 	c.addPostRoute(v1.RouteReviewInvoices, c.HandleReviewInvoices,
-		v1.ReviewInvoices{}, permissionAdmin, true)
+		v1.ReviewInvoices{}, permissionViewInvoices, true)
 	c.addPostRoute(v1.RoutePayInvoices, c.HandlePayInvoices,
-		v1.PayInvoices{}, permissionAdmin, true)
+		v1.PayInvoices{}, permissionPay, true)
+	c.addGetRoute(v1.RouteMissingInvoices, c.HandleMissingInvoices,
+		v1.MissingInvoices{}, permissionViewInvoices, true)
+	c.addGetRoute(v1.RouteSpendingReport, c.HandleSpendingReport,
+		v1.SpendingReport{}, permissionViewInvoices, true)
+	c.addGetRoute(v1.RouteSearchInvoices, c.HandleSearchInvoices,
+		v1.SearchInvoices{}, permissionLogin, true)
 	c.addPostRoute(v1.RoutePayInvoice, c.HandlePayInvoice,
-		v1.PayInvoice{}, permissionAdmin, true)
+		v1.PayInvoice{}, permissionPay, true)
 	c.addPostRoute(v1.RouteUpdateInvoicePayment, c.HandleUpdateInvoicePayment,
-		v1.UpdateInvoicePayment{}, permissionAdmin, true)
+		v1.UpdateInvoicePayment{}, permissionPay, true)
 	c.addGetRoute(v1.RouteUsers, c.HandleUsers, v1.Users{},
-		permissionAdmin, false)
+		permissionViewUsers, false)
+	c.addGetRoute(v1.RouteTeamInvoices, c.HandleTeamInvoices,
+		v1.TeamInvoices{}, permissionLeadReview, true)
+	c.addPostRoute(v1.RouteLeadReviewInvoice, c.HandleLeadReviewInvoice,
+		v1.LeadReviewInvoice{}, permissionLeadReview, true)
 	c.addGetRoute(v1.RouteRate, c.HandleRate, v1.Rate{},
-		permissionAdmin, false)
+		permissionViewInvoices, false)
+	c.addGetRoute(v1.RouteProposalReport, c.HandleProposalReport,
+		v1.ProposalReport{}, permissionViewInvoices, true)
+
+	// Routes that require being logged in as an admin.
+	c.addPostRoute(v1.RouteSetProposalBudget, c.HandleSetProposalBudget,
+		v1.SetProposalBudget{}, permissionAdmin, false)
 }
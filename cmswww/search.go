@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+// invoiceSearchResult is an invoice which matches the search criteria,
+// along with the value it's sorted by.
+type invoiceSearchResult struct {
+	invoice database.Invoice
+	sortKey int64
+}
+
+// encodeSearchCursor returns the cursor which points after the search
+// result with the given sort key and token.
+func encodeSearchCursor(sortKey int64, token string) string {
+	cursor := fmt.Sprintf("%v,%v", sortKey, token)
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// decodeSearchCursor returns the sort key and token that the cursor points
+// after.
+func decodeSearchCursor(cursor string) (int64, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", err
+	}
+
+	parts := strings.SplitN(string(b), ",", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", fmt.Errorf("malformed cursor")
+	}
+	sortKey, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return sortKey, parts[1], nil
+}
+
+// invoiceSearchLess returns whether the search result a sorts before b in
+// the given sort order. Ties are broken by token so that the order is
+// stable between pages.
+func invoiceSearchLess(sortOrder v1.InvoiceSortT, aKey int64, aToken string, bKey int64, bToken string) bool {
+	if aKey != bKey {
+		switch sortOrder {
+		case v1.InvoiceSortOldest, v1.InvoiceSortLowestCost:
+			return aKey < bKey
+		default:
+			return aKey > bKey
+		}
+	}
+	return aToken < bToken
+}
+
+// validateSearchPeriod returns an error if the month and year of one end of
+// the invoice period range are invalid. Both are optional, but the month
+// requires the year.
+func validateSearchPeriod(month, year uint16) error {
+	if month > 12 || (month != 0 && year == 0) {
+		return v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid invoice period"},
+		}
+	}
+	return nil
+}
+
+// matchesInvoiceReview returns whether the line items and the total cost of
+// an invoice match the search criteria.
+func matchesInvoiceReview(si *v1.SearchInvoices, invoiceReview *v1.InvoiceReview) bool {
+	if invoiceReview.TotalCostUSD < si.MinTotalCost {
+		return false
+	}
+	if si.MaxTotalCost != 0 && invoiceReview.TotalCostUSD > si.MaxTotalCost {
+		return false
+	}
+
+	if si.Proposal == "" && si.Type == "" && si.Text == "" {
+		return true
+	}
+
+	// A single line item has to match all of the line item criteria.
+	text := strings.ToLower(si.Text)
+	for _, lineItem := range invoiceReview.LineItems {
+		if si.Proposal != "" && lineItem.Proposal != si.Proposal {
+			continue
+		}
+		if si.Type != "" && !strings.EqualFold(lineItem.Type, si.Type) {
+			continue
+		}
+		if text != "" &&
+			!strings.Contains(strings.ToLower(lineItem.Description), text) {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+// pageSearchResults sorts the search results and returns the page of up to
+// limit results which follow the cursor, along with the cursor of the next
+// page if there are more results.
+func pageSearchResults(results []invoiceSearchResult, sortOrder v1.InvoiceSortT, limit int, hasCursor bool, cursorKey int64, cursorToken string) ([]invoiceSearchResult, string) {
+	sort.Slice(results, func(i, j int) bool {
+		return invoiceSearchLess(sortOrder, results[i].sortKey,
+			results[i].invoice.Token, results[j].sortKey,
+			results[j].invoice.Token)
+	})
+
+	// Skip the results up to and including the one the cursor points
+	// after.
+	start := 0
+	if hasCursor {
+		start = sort.Search(len(results), func(i int) bool {
+			return invoiceSearchLess(sortOrder, cursorKey, cursorToken,
+				results[i].sortKey, results[i].invoice.Token)
+		})
+	}
+
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+
+	var next string
+	if end < len(results) {
+		last := &results[end-1]
+		next = encodeSearchCursor(last.sortKey, last.invoice.Token)
+	}
+	return results[start:end], next
+}
+
+// searchRequiresReview returns whether the search has criteria which can
+// only be checked against the line items of the invoices, which aren't
+// stored in the database.
+func searchRequiresReview(si *v1.SearchInvoices) bool {
+	return si.Type != "" || si.Text != "" || si.MinTotalCost != 0 ||
+		si.MaxTotalCost != 0
+}
+
+// searchInvoicesBySubmission returns a page of the invoices which match the
+// search criteria, sorted by submission time. The invoices are read from the
+// database in batches, starting after the cursor, until the page is full.
+func (c *cmswww) searchInvoicesBySubmission(si *v1.SearchInvoices, ir database.InvoicesRequest, limit int) (*v1.SearchInvoicesReply, error) {
+	requiresReview := searchRequiresReview(si)
+
+	// One more invoice than the limit is looked for to tell whether there
+	// is a next page.
+	ir.Newest = si.Sort == v1.InvoiceSortNewest
+	ir.Limit = limit + 1
+	page := make([]database.Invoice, 0, ir.Limit)
+	var numMatches int
+	for len(page) < ir.Limit {
+		invoices, n, err := c.db.GetInvoices(ir)
+		if err != nil {
+			return nil, err
+		}
+		numMatches = n
+
+		for _, invoice := range invoices {
+			if requiresReview {
+				err := c.fetchInvoiceFileIfNecessary(&invoice)
+				if err != nil {
+					return nil, err
+				}
+				invoiceReview, err := c.createInvoiceReview(&invoice)
+				if err != nil {
+					return nil, err
+				}
+				if !matchesInvoiceReview(si, invoiceReview) {
+					continue
+				}
+			}
+
+			page = append(page, invoice)
+			if len(page) == ir.Limit {
+				break
+			}
+		}
+
+		if len(invoices) < ir.Limit {
+			break
+		}
+		last := invoices[len(invoices)-1]
+		ir.AfterTime = last.Timestamp
+		ir.AfterToken = last.Token
+	}
+
+	// The number of matches is only known when all of the criteria are
+	// checked by the database.
+	var sir v1.SearchInvoicesReply
+	if !requiresReview {
+		sir.TotalMatches = uint64(numMatches)
+	}
+	if len(page) > limit {
+		page = page[:limit]
+		last := page[limit-1]
+		sir.NextCursor = encodeSearchCursor(last.Timestamp, last.Token)
+	}
+	sir.Invoices = convertDatabaseInvoicesToInvoices(page)
+
+	return &sir, nil
+}
+
+// searchInvoicesByCost returns a page of the invoices which match the search
+// criteria, sorted by total cost. The total cost isn't stored in the
+// database, so every invoice which matches the other criteria is read and
+// sorted.
+func (c *cmswww) searchInvoicesByCost(si *v1.SearchInvoices, ir database.InvoicesRequest, limit int, hasCursor bool, cursorKey int64, cursorToken string) (*v1.SearchInvoicesReply, error) {
+	ir.Page = -1
+	invoices, _, err := c.db.GetInvoices(ir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]invoiceSearchResult, 0)
+	for _, invoice := range invoices {
+		err := c.fetchInvoiceFileIfNecessary(&invoice)
+		if err != nil {
+			return nil, err
+		}
+
+		invoiceReview, err := c.createInvoiceReview(&invoice)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matchesInvoiceReview(si, invoiceReview) {
+			continue
+		}
+
+		results = append(results, invoiceSearchResult{
+			invoice: invoice,
+			sortKey: int64(invoiceReview.TotalCostUSD),
+		})
+	}
+
+	page, next := pageSearchResults(results, si.Sort, limit, hasCursor,
+		cursorKey, cursorToken)
+
+	sir := v1.SearchInvoicesReply{
+		TotalMatches: uint64(len(results)),
+		NextCursor:   next,
+	}
+	invoices = make([]database.Invoice, 0, len(page))
+	for _, result := range page {
+		invoices = append(invoices, result.invoice)
+	}
+	sir.Invoices = convertDatabaseInvoicesToInvoices(invoices)
+
+	return &sir, nil
+}
+
+// HandleSearchInvoices returns a page of the invoices which match the
+// search criteria.
+func (c *cmswww) HandleSearchInvoices(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	si := req.(*v1.SearchInvoices)
+
+	if _, ok := v1.InvoiceSort[si.Sort]; !ok {
+		return nil, v1.UserError{
+			ErrorCode:    v1.ErrorStatusInvalidInput,
+			ErrorContext: []string{"invalid sort order"},
+		}
+	}
+	if err := validateSearchPeriod(si.FromMonth, si.FromYear); err != nil {
+		return nil, err
+	}
+	if err := validateSearchPeriod(si.ToMonth, si.ToYear); err != nil {
+		return nil, err
+	}
+
+	statusMap := make(map[v1.InvoiceStatusT]bool)
+	for _, status := range si.Statuses {
+		if _, ok := v1.InvoiceStatus[status]; !ok ||
+			status == v1.InvoiceStatusInvalid {
+			return nil, v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{"invalid invoice status"},
+			}
+		}
+		statusMap[status] = true
+	}
+
+	limit := si.Limit
+	if limit == 0 || limit > v1.ListPageSize {
+		limit = v1.ListPageSize
+	}
+
+	var (
+		hasCursor   bool
+		cursorKey   int64
+		cursorToken string
+	)
+	if si.Cursor != "" {
+		var err error
+		cursorKey, cursorToken, err = decodeSearchCursor(si.Cursor)
+		if err != nil {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusInvalidSearchCursor,
+			}
+		}
+		hasCursor = true
+	}
+
+	// Users who can't view all invoices can only search their own.
+	userID := si.UserID
+	if !hasPermission(user, permissionViewInvoices) {
+		ownID := strconv.FormatUint(user.ID, 10)
+		if userID != "" && userID != ownID {
+			return &v1.SearchInvoicesReply{
+				Invoices: make([]v1.InvoiceRecord, 0),
+			}, nil
+		}
+		userID = ownID
+	}
+
+	ir := database.InvoicesRequest{
+		UserID:        userID,
+		StatusMap:     statusMap,
+		FromMonth:     si.FromMonth,
+		FromYear:      si.FromYear,
+		ToMonth:       si.ToMonth,
+		ToYear:        si.ToYear,
+		SubmittedFrom: si.SubmittedFrom,
+		SubmittedTo:   si.SubmittedTo,
+		Proposal:      si.Proposal,
+	}
+
+	if si.Sort == v1.InvoiceSortHighestCost ||
+		si.Sort == v1.InvoiceSortLowestCost {
+		return c.searchInvoicesByCost(si, ir, int(limit), hasCursor,
+			cursorKey, cursorToken)
+	}
+
+	if hasCursor {
+		ir.AfterTime = cursorKey
+		ir.AfterToken = cursorToken
+	}
+	return c.searchInvoicesBySubmission(si, ir, int(limit))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+func TestSearchCursor(t *testing.T) {
+	for _, test := range []struct {
+		sortKey int64
+		token   string
+	}{
+		{0, "a"},
+		{1546300800, "0a1b2c"},
+		{-5, "token,with,commas"},
+	} {
+		sortKey, token, err := decodeSearchCursor(
+			encodeSearchCursor(test.sortKey, test.token))
+		if err != nil {
+			t.Errorf("%v,%v: %v", test.sortKey, test.token, err)
+			continue
+		}
+		if sortKey != test.sortKey || token != test.token {
+			t.Errorf("got %v,%v, want %v,%v", sortKey, token,
+				test.sortKey, test.token)
+		}
+	}
+
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for _, cursor := range []string{
+		"not base64!",
+		encode("12345"),
+		encode("12345,"),
+		encode(",token"),
+		encode("twelve,token"),
+		encode("1.5,token"),
+		encode("99999999999999999999,token"),
+	} {
+		_, _, err := decodeSearchCursor(cursor)
+		if err == nil {
+			t.Errorf("cursor %q: expected an error", cursor)
+		}
+	}
+}
+
+func TestInvoiceSearchLess(t *testing.T) {
+	tests := []struct {
+		sortOrder v1.InvoiceSortT
+		aKey      int64
+		aToken    string
+		bKey      int64
+		bToken    string
+		less      bool
+	}{
+		{v1.InvoiceSortNewest, 2, "a", 1, "b", true},
+		{v1.InvoiceSortNewest, 1, "a", 2, "b", false},
+		{v1.InvoiceSortOldest, 1, "b", 2, "a", true},
+		{v1.InvoiceSortHighestCost, 2, "b", 1, "a", true},
+		{v1.InvoiceSortLowestCost, 1, "b", 2, "a", true},
+
+		// Ties are broken by token in every sort order.
+		{v1.InvoiceSortNewest, 1, "a", 1, "b", true},
+		{v1.InvoiceSortOldest, 1, "a", 1, "b", true},
+		{v1.InvoiceSortHighestCost, 1, "b", 1, "a", false},
+		{v1.InvoiceSortLowestCost, 1, "a", 1, "a", false},
+	}
+
+	for _, test := range tests {
+		less := invoiceSearchLess(test.sortOrder, test.aKey, test.aToken,
+			test.bKey, test.bToken)
+		if less != test.less {
+			t.Errorf("%v: %v,%v < %v,%v: got %v, want %v",
+				v1.InvoiceSort[test.sortOrder], test.aKey, test.aToken,
+				test.bKey, test.bToken, less, test.less)
+		}
+	}
+}
+
+func TestPageSearchResults(t *testing.T) {
+	// Many of the results share a sort key, so pages must be split between
+	// results with the same key.
+	keys := []int64{5, 5, 5, 3, 3, 8, 8, 1, 5, 3, 8, 0}
+	newResults := func(r *rand.Rand) []invoiceSearchResult {
+		results := make([]invoiceSearchResult, 0, len(keys))
+		for i, key := range keys {
+			results = append(results, invoiceSearchResult{
+				invoice: database.Invoice{
+					Token: fmt.Sprintf("token%02d", i),
+				},
+				sortKey: key,
+			})
+		}
+		r.Shuffle(len(results), func(i, j int) {
+			results[i], results[j] = results[j], results[i]
+		})
+		return results
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for sortOrder := range v1.InvoiceSort {
+		// The expected order of all of the results.
+		all, _ := pageSearchResults(newResults(r), sortOrder, len(keys),
+			false, 0, "")
+		for i := 1; i < len(all); i++ {
+			if !invoiceSearchLess(sortOrder, all[i-1].sortKey,
+				all[i-1].invoice.Token, all[i].sortKey,
+				all[i].invoice.Token) {
+				t.Fatalf("%v: results out of order at %v",
+					v1.InvoiceSort[sortOrder], i)
+			}
+		}
+
+		for limit := 1; limit <= len(keys)+1; limit++ {
+			var (
+				hasCursor   bool
+				cursorKey   int64
+				cursorToken string
+				paged       []invoiceSearchResult
+			)
+			for pages := 0; ; pages++ {
+				if pages > len(keys) {
+					t.Fatalf("%v, limit %v: too many pages",
+						v1.InvoiceSort[sortOrder], limit)
+				}
+
+				// Each page is searched again from scratch, in a
+				// different order, like a new request would be.
+				page, next := pageSearchResults(newResults(r), sortOrder,
+					limit, hasCursor, cursorKey, cursorToken)
+				if len(page) > limit {
+					t.Fatalf("%v, limit %v: got %v results",
+						v1.InvoiceSort[sortOrder], limit, len(page))
+				}
+				paged = append(paged, page...)
+				if next == "" {
+					break
+				}
+
+				var err error
+				cursorKey, cursorToken, err = decodeSearchCursor(next)
+				if err != nil {
+					t.Fatal(err)
+				}
+				hasCursor = true
+			}
+
+			// The pages must neither overlap nor skip results.
+			if len(paged) != len(all) {
+				t.Errorf("%v, limit %v: got %v results, want %v",
+					v1.InvoiceSort[sortOrder], limit, len(paged), len(all))
+				continue
+			}
+			for i := range all {
+				if paged[i].invoice.Token != all[i].invoice.Token {
+					t.Errorf("%v, limit %v: result %v is %v, want %v",
+						v1.InvoiceSort[sortOrder], limit, i,
+						paged[i].invoice.Token, all[i].invoice.Token)
+					break
+				}
+			}
+		}
+	}
+
+	// A cursor past the last result returns an empty last page.
+	page, next := pageSearchResults(newResults(r), v1.InvoiceSortLowestCost,
+		5, true, 100, "")
+	if len(page) != 0 || next != "" {
+		t.Errorf("got %v results and cursor %q past the end", len(page),
+			next)
+	}
+}
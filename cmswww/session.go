@@ -1,10 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
@@ -38,6 +38,11 @@ func (c *cmswww) getSession(r *http.Request) (*sessions.Session, error) {
 // GetSessionEmail returns the email address of the currently logged in user
 // from the session store.
 func (c *cmswww) GetSessionEmail(r *http.Request) (string, error) {
+	// Requests authenticated with an API token don't use the session.
+	if user, ok := r.Context().Value(apiTokenUserKey).(*database.User); ok {
+		return user.Email, nil
+	}
+
 	session, err := c.getSession(r)
 	if err != nil {
 		return "", err
@@ -117,12 +122,10 @@ func (c *cmswww) SetupSessions() error {
 		}
 		log.Infof("Cookie key generated: %v", cookieKey)
 	}
-	sessionsDir := filepath.Join(c.cfg.DataDir, "sessions")
-	err = os.MkdirAll(sessionsDir, 0700)
-	if err != nil {
-		return err
-	}
-	c.store = sessions.NewFilesystemStore(sessionsDir, cookieKey)
+
+	// Sessions are stored in the database so that they can be shared by
+	// multiple cmswww instances, as long as they use the same cookie key.
+	c.store = newSessionStore(c.db, cookieKey)
 	c.store.Options = &sessions.Options{
 		Path:     "/",
 		MaxAge:   86400, // One day
@@ -147,18 +150,62 @@ func (c *cmswww) ReplaceSessionIfInvalid(w http.ResponseWriter, r *http.Request)
 	return false, nil
 }
 
-// isAdmin returns true if the current session has admin privileges.
-func (c *cmswww) isAdmin(r *http.Request) (bool, error) {
-	user, err := c.GetSessionUser(r)
+// revokeUserSessions deletes all of the user's sessions, except the session
+// of the given request, if one is provided.
+func (c *cmswww) revokeUserSessions(user *database.User, r *http.Request) error {
+	var exceptIDs []uint64
+	if r != nil {
+		dbSession, err := c.getCurrentSession(r)
+		if err != nil {
+			return err
+		}
+		if dbSession != nil {
+			exceptIDs = append(exceptIDs, dbSession.ID)
+		}
+	}
+
+	return c.db.DeleteSessionsByUser(user.ID, exceptIDs...)
+}
+
+// getCurrentSession returns the database session of the request, or nil if
+// the request has no session.
+func (c *cmswww) getCurrentSession(r *http.Request) (*database.Session, error) {
+	session, err := c.getSession(r)
+	if err != nil {
+		return nil, err
+	}
+	if session.ID == "" {
+		return nil, nil
+	}
+
+	dbSession, err := c.db.GetSessionByKey(session.ID)
+	if err != nil {
+		if err == database.ErrSessionNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return dbSession, nil
+}
+
+// recordFailedLoginAttempt increments the user's failed login attempts and
+// notifies the user once further login attempts start being delayed.
+func (c *cmswww) recordFailedLoginAttempt(user *database.User) error {
+	user.FailedLoginAttempts = user.FailedLoginAttempts + 1
+	user.LastFailedLogin = time.Now().Unix()
+	err := c.db.UpdateUser(user)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	if user == nil {
-		return false, nil
+	if c.cfg.LoginDelayAfter != 0 &&
+		user.FailedLoginAttempts == c.cfg.LoginDelayAfter {
+		// This is conditional on the email server being setup.
+		return c.emailFailedLoginAttempts(user.Email)
 	}
 
-	return user.Admin, nil
+	return nil
 }
 
 func (c *cmswww) login(l *v1.Login) loginReplyWithError {
@@ -189,43 +236,54 @@ func (c *cmswww) login(l *v1.Login) loginReplyWithError {
 		}
 	}
 
-	// Check the user's password.
-	err = bcrypt.CompareHashAndPassword(user.HashedPassword,
-		[]byte(l.Password))
-	if err != nil {
-		if !IsUserLocked(user.FailedLoginAttempts) {
-			user.FailedLoginAttempts = user.FailedLoginAttempts + 1
-			err := c.db.UpdateUser(user)
-			if err != nil {
-				return loginReplyWithError{
-					reply: nil,
-					err:   err,
-				}
-			}
+	// Check if login attempts are being delayed due to previous failed
+	// attempts. Attempts made during the delay are not checked, so they
+	// don't count as failed attempts.
+	wait := c.loginDelayRemaining(user, time.Now())
+	if wait > 0 {
+		return loginReplyWithError{
+			reply: nil,
+			err:   newTooManyRequestsError(wait),
+		}
+	}
 
-			// Check if the user is locked again so we can send an email.
-			if IsUserLocked(user.FailedLoginAttempts) {
-				// This is conditional on the email server being setup.
-				err := c.emailUserLocked(user.Email)
-				if err != nil {
-					return loginReplyWithError{
-						reply: nil,
-						err:   err,
-					}
-				}
+	// Check the user's credentials, which are either a login challenge
+	// signed with the user's active identity, or the password.
+	var errorCode v1.ErrorStatusT
+	if l.Signature != "" {
+		errorCode, err = c.checkLoginChallenge(user, l)
+		if err != nil {
+			return loginReplyWithError{
+				reply: nil,
+				err:   err,
+			}
+		}
+	} else {
+		err = bcrypt.CompareHashAndPassword(user.HashedPassword,
+			[]byte(l.Password))
+		if err != nil {
+			errorCode = v1.ErrorStatusInvalidEmailOrPassword
+		}
+	}
+	if errorCode != v1.ErrorStatusInvalid {
+		err := c.recordFailedLoginAttempt(user)
+		if err != nil {
+			return loginReplyWithError{
+				reply: nil,
+				err:   err,
 			}
 		}
 
 		return loginReplyWithError{
 			reply: nil,
 			err: v1.UserError{
-				ErrorCode: v1.ErrorStatusInvalidEmailOrPassword,
+				ErrorCode: errorCode,
 			},
 		}
 	}
 
-	// Check if user is locked due to too many login attempts
-	if IsUserLocked(user.FailedLoginAttempts) {
+	// Check if the user has been locked by an admin.
+	if IsUserLocked(user) {
 		return loginReplyWithError{
 			reply: nil,
 			err: v1.UserError{
@@ -234,6 +292,35 @@ func (c *cmswww) login(l *v1.Login) loginReplyWithError {
 		}
 	}
 
+	// Check the two-factor authentication code, if enabled.
+	if user.TOTPEnabled() {
+		if l.TOTPCode == "" {
+			return loginReplyWithError{
+				reply: nil,
+				err: v1.UserError{
+					ErrorCode: v1.ErrorStatusTOTPCodeRequired,
+				},
+			}
+		}
+
+		if !checkTOTPOrRecoveryCode(user, l.TOTPCode, time.Now()) {
+			err := c.recordFailedLoginAttempt(user)
+			if err != nil {
+				return loginReplyWithError{
+					reply: nil,
+					err:   err,
+				}
+			}
+
+			return loginReplyWithError{
+				reply: nil,
+				err: v1.UserError{
+					ErrorCode: v1.ErrorStatusTOTPCodeInvalid,
+				},
+			}
+		}
+	}
+
 	lastLogin := user.LastLogin
 	user.FailedLoginAttempts = 0
 	user.LastLogin = time.Now().Unix()
@@ -252,6 +339,90 @@ func (c *cmswww) login(l *v1.Login) loginReplyWithError {
 	}
 }
 
+// checkLoginChallenge verifies the login challenge and its signature made
+// with the user's active identity. The challenge is cleared so that it can
+// only be used once. A non-zero error code is returned if the challenge or
+// signature is invalid.
+func (c *cmswww) checkLoginChallenge(user *database.User, l *v1.Login) (v1.ErrorStatusT, error) {
+	challenge, err := hex.DecodeString(l.Challenge)
+	valid := err == nil && len(user.LoginChallenge) != 0 &&
+		bytes.Equal(challenge, user.LoginChallenge) &&
+		time.Now().Unix() < user.LoginChallengeExpiry
+
+	if len(user.LoginChallenge) != 0 {
+		user.LoginChallenge = []byte{}
+		err = c.db.UpdateUser(user)
+		if err != nil {
+			return v1.ErrorStatusInvalid, err
+		}
+	}
+
+	if !valid {
+		return v1.ErrorStatusInvalidLoginChallenge, nil
+	}
+
+	activeIdentity, ok := database.ActiveIdentityString(user.Identities)
+	if !ok {
+		return v1.ErrorStatusNoPublicKey, nil
+	}
+
+	err = checkPublicKeyAndSignature(user, activeIdentity, l.Signature,
+		v1.LoginChallengePrefix, l.Challenge)
+	if err != nil {
+		if userErr, ok := err.(v1.UserError); ok {
+			return userErr.ErrorCode, nil
+		}
+		return v1.ErrorStatusInvalid, err
+	}
+
+	return v1.ErrorStatusInvalid, nil
+}
+
+// HandleLoginChallenge issues a random challenge which the user can sign with
+// their active identity to log in without a password.
+func (c *cmswww) HandleLoginChallenge(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	lc := req.(*v1.LoginChallenge)
+
+	err := c.checkRateLimits(r, lc.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := util.Random(v1.LoginChallengeSize)
+	if err != nil {
+		return nil, err
+	}
+	expiry := time.Now().Add(v1.LoginChallengeExpiryTime).Unix()
+
+	// Only store the challenge for users who can use it, but always reply
+	// the same way so that the route can't be used to tell which emails
+	// belong to users.
+	dbUser, err := c.db.GetUserByEmail(lc.Email)
+	if err != nil && err != database.ErrUserNotFound {
+		return nil, err
+	}
+	if err == nil {
+		if _, ok := database.ActiveIdentity(dbUser.Identities); ok {
+			dbUser.LoginChallenge = challenge
+			dbUser.LoginChallengeExpiry = expiry
+			err = c.db.UpdateUser(dbUser)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &v1.LoginChallengeReply{
+		Challenge: hex.EncodeToString(challenge),
+		Expiry:    expiry,
+	}, nil
+}
+
 // ProcessLogin checks that a user exists, is verified, and has
 // the correct password.
 func (c *cmswww) HandleLogin(
@@ -261,6 +432,12 @@ func (c *cmswww) HandleLogin(
 	r *http.Request,
 ) (interface{}, error) {
 	l := req.(*v1.Login)
+
+	err := c.checkRateLimits(r, l.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		loginReply loginReplyWithError
 		login      = make(chan loginReplyWithError)
@@ -311,6 +488,12 @@ func (c *cmswww) CreateLoginReply(user *database.User, lastLogin int64) (*v1.Log
 		Username:  user.Username,
 		PublicKey: activeIdentity,
 		LastLogin: lastLogin,
+
+		Roles: user.Roles,
+
+		TOTPEnabled: user.TOTPEnabled(),
+		TOTPRequired: (user.Admin || user.Roles != 0) && c.cfg.RequireAdminTOTP &&
+			!user.TOTPEnabled(),
 	}
 
 	return &reply, nil
@@ -331,3 +514,102 @@ func (c *cmswww) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	var reply v1.LogoutReply
 	util.RespondWithJSON(w, http.StatusOK, reply)
 }
+
+// HandleUserSessions returns the user's active login sessions.
+func (c *cmswww) HandleUserSessions(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	dbSessions, err := c.db.GetSessionsByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentSession, err := c.getCurrentSession(r)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	usr := v1.UserSessionsReply{
+		Sessions: make([]v1.UserSession, 0, len(dbSessions)),
+	}
+	for _, dbSession := range dbSessions {
+		if now >= dbSession.Expiry {
+			continue
+		}
+
+		usr.Sessions = append(usr.Sessions, v1.UserSession{
+			ID:        strconv.FormatUint(dbSession.ID, 10),
+			IPAddress: dbSession.IPAddress,
+			UserAgent: dbSession.UserAgent,
+			Timestamp: dbSession.Timestamp,
+			LastSeen:  dbSession.LastSeen,
+			Expiry:    dbSession.Expiry,
+			Current: currentSession != nil &&
+				currentSession.ID == dbSession.ID,
+		})
+	}
+
+	return &usr, nil
+}
+
+// HandleRevokeSessions revokes one of the user's login sessions, or all of
+// them except the current one.
+func (c *cmswww) HandleRevokeSessions(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	rs := req.(*v1.RevokeSessions)
+
+	if rs.All {
+		err := c.revokeUserSessions(user, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return &v1.RevokeSessionsReply{}, nil
+	}
+
+	id, err := strconv.ParseUint(rs.ID, 10, 64)
+	if err != nil {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusSessionNotFound,
+		}
+	}
+
+	// Ensure the session belongs to the user.
+	dbSessions, err := c.db.GetSessionsByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, dbSession := range dbSessions {
+		if dbSession.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusSessionNotFound,
+		}
+	}
+
+	err = c.db.DeleteSession(id)
+	if err != nil {
+		if err == database.ErrSessionNotFound {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusSessionNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	return &v1.RevokeSessionsReply{}, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+const (
+	// sessionLastSeenInterval is how often the last seen time of a session
+	// is updated in the database while it's being used.
+	sessionLastSeenInterval = time.Minute
+)
+
+var (
+	_ sessions.Store = (*sessionStore)(nil)
+)
+
+// sessionStore implements the gorilla sessions.Store interface by keeping
+// the session values in the database, so that sessions can be shared
+// between multiple cmswww instances and can be listed and revoked by users.
+//
+// Like the gorilla FilesystemStore, only the session key is stored in the
+// cookie.
+type sessionStore struct {
+	db      database.Database
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+}
+
+// newSessionStore returns a new database backed session store. The key
+// pairs are used to authenticate and optionally encrypt the session cookie
+// and values, as described for sessions.NewFilesystemStore.
+func newSessionStore(db database.Database, keyPairs ...[]byte) *sessionStore {
+	return &sessionStore{
+		db:     db,
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+	}
+}
+
+// Get returns a cached session for the request, or creates a new one.
+func (s *sessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session identified by the request's cookie. A new session
+// is returned if there is no cookie, or if the session has expired or was
+// revoked.
+func (s *sessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	err = securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...)
+	if err != nil {
+		return session, err
+	}
+
+	err = s.load(r, session)
+	if err != nil {
+		session.ID = ""
+		if err == database.ErrSessionNotFound {
+			return session, nil
+		}
+		return session, err
+	}
+
+	session.IsNew = false
+	return session, nil
+}
+
+// Save stores the session in the database and sets its cookie. If the
+// session's MaxAge is <= 0, the session is deleted instead.
+func (s *sessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		err := s.erase(session)
+		if err != nil {
+			return err
+		}
+
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "",
+			session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(
+			securecookie.GenerateRandomKey(32)), "=")
+	}
+
+	err := s.save(r, session)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID,
+		s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded,
+		session.Options))
+	return nil
+}
+
+// load reads the session from the database and decodes its values.
+func (s *sessionStore) load(r *http.Request, session *sessions.Session) error {
+	dbSession, err := s.db.GetSessionByKey(session.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.Unix() >= dbSession.Expiry {
+		err := s.db.DeleteSession(dbSession.ID)
+		if err != nil && err != database.ErrSessionNotFound {
+			return err
+		}
+		return database.ErrSessionNotFound
+	}
+
+	err = securecookie.DecodeMulti(session.Name(), dbSession.Values,
+		&session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	// Keep track of when and where the session was last used, without
+	// writing to the database on every request.
+	if now.Sub(time.Unix(dbSession.LastSeen, 0)) >= sessionLastSeenInterval {
+		dbSession.LastSeen = now.Unix()
+		dbSession.IPAddress = remoteAddr(r)
+		dbSession.UserAgent = r.UserAgent()
+		err = s.db.SaveSession(dbSession)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save encodes the session values and writes the session to the database.
+func (s *sessionStore) save(r *http.Request, session *sessions.Session) error {
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.Values,
+		s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	dbSession, err := s.db.GetSessionByKey(session.ID)
+	if err == database.ErrSessionNotFound {
+		dbSession = &database.Session{
+			Key: session.ID,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// Link the session to the logged in user so that it can be listed and
+	// revoked.
+	dbSession.UserID = 0
+	if email, ok := session.Values["email"].(string); ok && email != "" {
+		user, err := s.db.GetUserByEmail(email)
+		if err != nil {
+			return err
+		}
+		dbSession.UserID = user.ID
+	}
+
+	now := time.Now()
+	dbSession.Values = encoded
+	dbSession.IPAddress = remoteAddr(r)
+	dbSession.UserAgent = r.UserAgent()
+	dbSession.LastSeen = now.Unix()
+	dbSession.Expiry = now.Add(time.Duration(session.Options.MaxAge) *
+		time.Second).Unix()
+
+	return s.db.SaveSession(dbSession)
+}
+
+// erase deletes the session from the database.
+func (s *sessionStore) erase(session *sessions.Session) error {
+	if session.ID == "" {
+		return nil
+	}
+
+	dbSession, err := s.db.GetSessionByKey(session.ID)
+	if err != nil {
+		if err == database.ErrSessionNotFound {
+			return nil
+		}
+		return err
+	}
+
+	err = s.db.DeleteSession(dbSession.ID)
+	if err != nil && err != database.ErrSessionNotFound {
+		return err
+	}
+
+	return nil
+}
@@ -31,8 +31,8 @@ $ cmswwwcli verifyidentity {{.Token}}
 You are receiving this email because a new identity (public key: {{.PublicKey}}) was generated for {{.Email}} on Decred Contractor Management. If you did not perform this action, please notify the administrators.
 `
 
-const templateUserLockedResetPasswordRaw = `
-Your account was locked due to too many login attempts. You need to reset your password in order to unlock your account by executing the following:
+const templateFailedLoginAttemptsRaw = `
+There have been too many failed login attempts for your account, so further login attempts will be delayed. If you have forgotten your password, you can reset it by executing the following:
 
 $ cmswwwcli resetpassword {{.Email}}
 
@@ -55,6 +55,22 @@ $ cmswwwcli updatexpublickey --token={{.Token}} --xpublickey=<your extended publ
 You are receiving this email because it has been requested to update the extended public key for {{.Email}} on Decred Contractor Management. If you did not perform this action, please notify the administrators.
 `
 
+const templateChangeEmailOldAddressEmailRaw = `
+It has been requested to change the email address of your account to {{.NewEmail}}. A second verification token has been sent to that address. To complete the change, you will need to execute the following:
+
+$ cmswwwcli changeemail {{.NewEmail}} --oldtoken={{.Token}} --newtoken=<token sent to {{.NewEmail}}>
+
+You are receiving this email because it has been requested to change the email address for {{.Email}} on Decred Contractor Management. If you did not perform this action, please notify the administrators.
+`
+
+const templateChangeEmailNewAddressEmailRaw = `
+It has been requested to change the email address of your account from {{.Email}} to this address. A second verification token has been sent to {{.Email}}. To complete the change, you will need to execute the following:
+
+$ cmswwwcli changeemail {{.NewEmail}} --oldtoken=<token sent to {{.Email}}> --newtoken={{.Token}}
+
+You are receiving this email because it has been requested to change the email address for {{.Email}} to {{.NewEmail}} on Decred Contractor Management. If you did not perform this action, please ignore this email.
+`
+
 const templateInvoiceApprovedEmailRaw = `
 Your {{.Date}} invoice has just been approved! You will soon receive payment in DCR for the billed amount at the DCR/USD rate for {{.Date}}.
 
@@ -68,9 +84,42 @@ Invoice token: {{.Token}}
 Reason for rejection: {{.Reason}}
 `
 
+const templateInvoiceOnHoldEmailRaw = `
+Your {{.Date}} invoice has been put on hold, and won't be paid until the hold is released.
+
+Invoice token: {{.Token}}
+Reason for hold: {{.Reason}}
+`
+
+const templateInvoiceDisputedEmailRaw = `
+The payment of your {{.Date}} invoice has been disputed.
+
+Invoice token: {{.Token}}
+Reason for dispute: {{.Reason}}
+`
+
 const templateInvoicePaidEmailRaw = `
 You have received payment for your {{.Date}} invoice!
 
 Invoice token: {{.Token}}
 Transaction: {{.TxID}}
 `
+
+const templateInvoiceCommentEmailRaw = `
+{{if .Reply}}{{.Username}} has replied to your comment on a {{.Date}} invoice.{{else}}{{.Username}} has commented on your {{.Date}} invoice.{{end}}
+
+Invoice token: {{.Token}}
+Comment: {{.Comment}}
+
+To view all comments on the invoice, execute the following:
+
+$ cmswwwcli invoicecomments {{.Token}}
+`
+
+const templateInvoiceReminderEmailRaw = `
+You have not yet submitted your invoice for {{.Date}}. Invoices submitted after {{.Deadline}} will be flagged as late.
+
+To submit your invoice, execute the following:
+
+$ cmswwwcli submitinvoice {{.Month}} {{.Year}}
+`
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/decred/politeia/util"
+
+	"github.com/decred/contractor-mgmt/cmswww/api/v1"
+	"github.com/decred/contractor-mgmt/cmswww/database"
+)
+
+const (
+	// totpIssuer is the issuer displayed by authenticator apps.
+	totpIssuer = "Decred CMS"
+
+	// totpSecretSize is the size in bytes of a TOTP secret, as recommended
+	// by RFC 4226.
+	totpSecretSize = 20
+
+	// totpPeriod is the number of seconds each code is valid for.
+	totpPeriod = 30
+
+	// totpDigits is the number of digits in each code.
+	totpDigits = 6
+
+	// totpSkew is the number of time steps before and after the current
+	// one for which codes are still accepted, to allow for clock drift.
+	totpSkew = 1
+
+	// totpRecoveryCodeCount is the number of recovery codes generated when
+	// two-factor authentication is enabled.
+	totpRecoveryCodeCount = 10
+
+	// totpRecoveryCodeSize is the size in bytes of each recovery code.
+	totpRecoveryCodeSize = 5
+)
+
+var (
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// generateTOTPSecret returns a new random base32 encoded TOTP secret.
+func generateTOTPSecret() (string, error) {
+	secret, err := util.Random(totpSecretSize)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// totpURI returns the otpauth:// URI for the given secret, which
+// authenticator apps can import directly or from a QR code.
+func totpURI(secret, account string) string {
+	label := url.PathEscape(totpIssuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%v", totpDigits))
+	params.Set("period", fmt.Sprintf("%v", totpPeriod))
+
+	return fmt.Sprintf("otpauth://totp/%v?%v", label,
+		strings.Replace(params.Encode(), "+", "%20", -1))
+}
+
+// totpCode returns the code for the given secret and time step, as
+// specified by RFC 6238.
+func totpCode(secret []byte, step uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	var modulo uint32 = 1
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
+
+// validateTOTPCode checks the code against the given secret for the time
+// steps around the given time, and returns the matching step. Steps at or
+// before lastUsedStep are rejected so that a code cannot be reused.
+func validateTOTPCode(secret, code string, lastUsedStep uint64, now time.Time) (uint64, bool) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := uint64(now.Unix()) / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		step := uint64(int64(current) + int64(i))
+		if step <= lastUsedStep {
+			continue
+		}
+
+		expected := totpCode(key, step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// normalizeRecoveryCode strips the formatting from a recovery code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.Replace(code, "-", "", -1)
+}
+
+// hashRecoveryCode returns the hash of the recovery code that is stored
+// in the database.
+func hashRecoveryCode(code string) string {
+	digest := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(digest[:])
+}
+
+// generateRecoveryCodes returns a new set of recovery codes along with
+// their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, totpRecoveryCodeCount)
+	hashes := make([]string, 0, totpRecoveryCodeCount)
+	for i := 0; i < totpRecoveryCodeCount; i++ {
+		b, err := util.Random(totpRecoveryCodeSize)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encoded := hex.EncodeToString(b)
+		code := encoded[:len(encoded)/2] + "-" + encoded[len(encoded)/2:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// checkTOTPOrRecoveryCode returns whether the code is either a valid TOTP
+// code or an unused recovery code for the user. The user is updated to
+// prevent the code from being used again; it's up to the caller to save
+// the user to the database.
+func checkTOTPOrRecoveryCode(user *database.User, code string, now time.Time) bool {
+	step, ok := validateTOTPCode(user.TOTPSecret, code, user.TOTPLastUsedStep,
+		now)
+	if ok {
+		user.TOTPLastUsedStep = step
+		return true
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, recoveryCode := range user.TOTPRecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(recoveryCode)) == 1 {
+			user.TOTPRecoveryCodes = append(user.TOTPRecoveryCodes[:i],
+				user.TOTPRecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleSetTOTP generates a new TOTP secret for the user. Two-factor
+// authentication isn't enabled until the secret is verified.
+func (c *cmswww) HandleSetTOTP(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	st := req.(*v1.SetTOTP)
+
+	// Replacing an existing secret requires a valid code.
+	if user.TOTPEnabled() {
+		if st.Code == "" {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusTOTPCodeRequired,
+			}
+		}
+
+		if !checkTOTPOrRecoveryCode(user, st.Code, time.Now()) {
+			return nil, v1.UserError{
+				ErrorCode: v1.ErrorStatusTOTPCodeInvalid,
+			}
+		}
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPVerified = false
+	user.TOTPRecoveryCodes = nil
+	err = c.db.UpdateUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.SetTOTPReply{
+		Secret: secret,
+		URI:    totpURI(secret, user.Email),
+	}, nil
+}
+
+// HandleVerifyTOTP verifies a code for the user's new TOTP secret, enables
+// two-factor authentication and returns a new set of recovery codes.
+func (c *cmswww) HandleVerifyTOTP(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	vt := req.(*v1.VerifyTOTP)
+
+	if user.TOTPSecret == "" {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusTOTPNotEnabled,
+		}
+	}
+
+	if user.TOTPVerified {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusTOTPAlreadyEnabled,
+		}
+	}
+
+	step, ok := validateTOTPCode(user.TOTPSecret, vt.Code,
+		user.TOTPLastUsedStep, time.Now())
+	if !ok {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusTOTPCodeInvalid,
+		}
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPVerified = true
+	user.TOTPLastUsedStep = step
+	user.TOTPRecoveryCodes = hashes
+	err = c.db.UpdateUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.VerifyTOTPReply{
+		RecoveryCodes: codes,
+	}, nil
+}
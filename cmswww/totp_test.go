@@ -0,0 +1,141 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key used by the test vectors in appendix B of
+// RFC 6238.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	// The vectors in RFC 6238 are 8 digits long; the 6 digit codes are
+	// their last 6 digits.
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code := totpCode(rfc6238Secret, uint64(test.time)/totpPeriod)
+		if code != test.code {
+			t.Errorf("time %v: got code %v, want %v", test.time, code,
+				test.code)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfc6238Secret)
+	now := time.Unix(1111111111, 0)
+	current := uint64(now.Unix()) / totpPeriod
+
+	tests := []struct {
+		name         string
+		secret       string
+		code         string
+		lastUsedStep uint64
+		step         uint64
+		ok           bool
+	}{
+		{
+			name:   "current step",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current),
+			step:   current,
+			ok:     true,
+		},
+		{
+			name:   "previous step",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current-1),
+			step:   current - 1,
+			ok:     true,
+		},
+		{
+			name:   "next step",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current+1),
+			step:   current + 1,
+			ok:     true,
+		},
+		{
+			name:   "two steps behind",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current-2),
+		},
+		{
+			name:   "two steps ahead",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current+2),
+		},
+		{
+			name:   "surrounding whitespace",
+			secret: secret,
+			code:   " " + totpCode(rfc6238Secret, current) + "\n",
+			step:   current,
+			ok:     true,
+		},
+		{
+			name:         "replayed code",
+			secret:       secret,
+			code:         totpCode(rfc6238Secret, current),
+			lastUsedStep: current,
+		},
+		{
+			name:         "code older than the last used one",
+			secret:       secret,
+			code:         totpCode(rfc6238Secret, current-1),
+			lastUsedStep: current,
+		},
+		{
+			name:         "code newer than the last used one",
+			secret:       secret,
+			code:         totpCode(rfc6238Secret, current+1),
+			lastUsedStep: current,
+			step:         current + 1,
+			ok:           true,
+		},
+		{
+			name:   "wrong code",
+			secret: secret,
+			code:   "000000",
+		},
+		{
+			name:   "too short",
+			secret: secret,
+			code:   totpCode(rfc6238Secret, current)[1:],
+		},
+		{
+			name:   "eight digit code",
+			secret: secret,
+			code:   "14050471",
+		},
+		{
+			name:   "invalid secret",
+			secret: "not base32!",
+			code:   totpCode(rfc6238Secret, current),
+		},
+	}
+
+	for _, test := range tests {
+		step, ok := validateTOTPCode(test.secret, test.code,
+			test.lastUsedStep, now)
+		if ok != test.ok {
+			t.Errorf("%v: got ok %v, want %v", test.name, ok, test.ok)
+			continue
+		}
+		if step != test.step {
+			t.Errorf("%v: got step %v, want %v", test.name, step,
+				test.step)
+		}
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/badoux/checkmail"
 	"github.com/decred/politeia/politeiad/api/v1/identity"
 	"github.com/decred/politeia/util"
 	"golang.org/x/crypto/bcrypt"
@@ -15,10 +17,9 @@ import (
 	"github.com/decred/contractor-mgmt/cmswww/database"
 )
 
-// IsUserLocked returns true if the number of failed login attempts exceeds
-// the threshold for locking a user account.
-func IsUserLocked(failedLoginAttempts uint64) bool {
-	return failedLoginAttempts >= v1.LoginAttemptsToLockUser
+// IsUserLocked returns true if the user account has been locked by an admin.
+func IsUserLocked(user *database.User) bool {
+	return user.Locked
 }
 
 // hashPassword hashes the given password string with the default bcrypt cost
@@ -107,8 +108,13 @@ func (c *cmswww) HandleRegister(
 ) (interface{}, error) {
 	nu := req.(*v1.Register)
 
+	err := c.checkRateLimits(r, nu.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check that the user already exists.
-	user, err := c.db.GetUserByEmail(nu.Email)
+	user, err = c.db.GetUserByEmail(nu.Email)
 	if err != nil {
 		if err == database.ErrUserNotFound {
 			return nil, v1.UserError{
@@ -312,6 +318,176 @@ func (c *cmswww) emailUpdateExtendedPublicKey(
 	return nil
 }
 
+// HandleChangeEmail changes a user's email address. The first call, with only
+// the new email address, sends a verification token to both the current and
+// the new email addresses; the second call with both tokens changes the email
+// address.
+func (c *cmswww) HandleChangeEmail(
+	req interface{},
+	user *database.User,
+	w http.ResponseWriter,
+	r *http.Request,
+) (interface{}, error) {
+	ce := req.(*v1.ChangeEmail)
+	var cer v1.ChangeEmailReply
+
+	newEmail := strings.TrimSpace(ce.NewEmail)
+	if newEmail == user.Email {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusDuplicateEmail,
+		}
+	}
+
+	if ce.OldVerificationToken == "" && ce.NewVerificationToken == "" {
+		err := c.emailChangeEmail(user, newEmail, &cer)
+		if err != nil {
+			return nil, err
+		}
+		return &cer, nil
+	}
+
+	err := c.verifyChangeEmail(user, newEmail, ce)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session is tied to the email address, so update the current
+	// session and log the user out everywhere else.
+	err = c.setSessionUser(w, r, newEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.revokeUserSessions(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cer, nil
+}
+
+// emailChangeEmail generates the verification tokens for an email address
+// change and sends them to the current and the new email addresses. Any
+// pending email address change is replaced.
+func (c *cmswww) emailChangeEmail(
+	user *database.User,
+	newEmail string,
+	cer *v1.ChangeEmailReply,
+) error {
+	if err := checkmail.ValidateFormat(newEmail); err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusMalformedEmail,
+		}
+	}
+
+	_, err := c.db.GetUserByEmail(newEmail)
+	if err == nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusDuplicateEmail,
+		}
+	} else if err != database.ErrUserNotFound {
+		return err
+	}
+
+	// Generate a verification token for each email address.
+	oldToken, expiry, err := c.generateVerificationTokenAndExpiry()
+	if err != nil {
+		return err
+	}
+	newToken, _, err := c.generateVerificationTokenAndExpiry()
+	if err != nil {
+		return err
+	}
+
+	// Add the pending email address change to the db.
+	user.ChangeEmailAddress = newEmail
+	user.ChangeEmailOldVerificationToken = oldToken
+	user.ChangeEmailNewVerificationToken = newToken
+	user.ChangeEmailVerificationExpiry = expiry
+	err = c.db.UpdateUser(user)
+	if err != nil {
+		return err
+	}
+
+	// This is conditional on the email server being setup.
+	err = c.emailChangeEmailVerificationLinks(user.Email, newEmail,
+		hex.EncodeToString(oldToken), hex.EncodeToString(newToken))
+	if err != nil {
+		return err
+	}
+
+	// Only set the tokens if email verification is disabled.
+	if c.cfg.SMTP == nil {
+		cer.OldVerificationToken = hex.EncodeToString(oldToken)
+		cer.NewVerificationToken = hex.EncodeToString(newToken)
+	}
+
+	return nil
+}
+
+// verifyChangeEmail checks the verification tokens of a pending email address
+// change and changes the user's email address.
+func (c *cmswww) verifyChangeEmail(
+	user *database.User,
+	newEmail string,
+	ce *v1.ChangeEmail,
+) error {
+	// Decode the verification tokens.
+	oldToken, err := hex.DecodeString(ce.OldVerificationToken)
+	if err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusVerificationTokenInvalid,
+		}
+	}
+	newToken, err := hex.DecodeString(ce.NewVerificationToken)
+	if err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusVerificationTokenInvalid,
+		}
+	}
+
+	// Check that the change is pending and that both verification tokens
+	// match.
+	if user.ChangeEmailAddress == "" || user.ChangeEmailAddress != newEmail ||
+		!bytes.Equal(oldToken, user.ChangeEmailOldVerificationToken) ||
+		!bytes.Equal(newToken, user.ChangeEmailNewVerificationToken) {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusVerificationTokenInvalid,
+		}
+	}
+
+	// Check that the tokens haven't expired.
+	if user.ChangeEmailVerificationExpiry < time.Now().Unix() {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusVerificationTokenExpired,
+		}
+	}
+
+	// Change the email address, which fails if another user has started
+	// using the new email address in the meantime.
+	err = c.db.ChangeUserEmail(user.ID, user.Email, newEmail)
+	if err != nil {
+		switch err {
+		case database.ErrUserExists:
+			return v1.UserError{
+				ErrorCode: v1.ErrorStatusDuplicateEmail,
+			}
+		case database.ErrInvalidEmail:
+			return v1.UserError{
+				ErrorCode: v1.ErrorStatusMalformedEmail,
+			}
+		}
+		return err
+	}
+
+	user.Email = newEmail
+	user.ChangeEmailAddress = ""
+	user.ChangeEmailOldVerificationToken = nil
+	user.ChangeEmailNewVerificationToken = nil
+	user.ChangeEmailVerificationExpiry = 0
+	return nil
+}
+
 func (c *cmswww) verifyUpdateExtendedPublicKey(
 	user *database.User,
 	rp *v1.ResetPassword,
@@ -352,7 +528,7 @@ func (c *cmswww) verifyUpdateExtendedPublicKey(
 	}
 
 	// Clear out the verification token fields, set the new password in the db,
-	// and unlock account
+	// and reset the failed login attempts
 	user.ResetPasswordVerificationToken = []byte{}
 	user.ResetPasswordVerificationExpiry = 0
 	user.HashedPassword = hashedPassword
@@ -373,6 +549,13 @@ func (c *cmswww) HandleNewIdentity(
 	var token []byte
 	var expiry int64
 
+	// Deactivated contractors can't get new identities once their
+	// identities have been deactivated.
+	err := c.checkUserNotDeactivated(user)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure we got a proper pubkey.
 	pk, err := validatePubkey(ni.PublicKey)
 	if err != nil {
@@ -442,6 +625,11 @@ func (c *cmswww) HandleVerifyNewIdentity(
 ) (interface{}, error) {
 	vni := req.(*v1.VerifyNewIdentity)
 
+	err := c.checkUserNotDeactivated(user)
+	if err != nil {
+		return nil, err
+	}
+
 	// Decode the verification token.
 	token, err := hex.DecodeString(vni.VerificationToken)
 	if err != nil {
@@ -542,6 +730,12 @@ func (c *cmswww) HandleChangePassword(
 		return nil, err
 	}
 
+	// Log the user out of their other sessions.
+	err = c.revokeUserSessions(user, r)
+	if err != nil {
+		return nil, err
+	}
+
 	return &v1.ChangePasswordReply{}, nil
 }
 
@@ -559,8 +753,12 @@ func (c *cmswww) HandleResetPassword(
 	rp := req.(*v1.ResetPassword)
 	rpr := &v1.ResetPasswordReply{}
 
+	err := c.checkRateLimits(r, rp.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get user from db.
-	var err error
 	user, err = c.db.GetUserByEmail(rp.Email)
 	if err != nil {
 		if err == database.ErrInvalidEmail {
@@ -669,11 +867,17 @@ func (c *cmswww) verifyResetPassword(
 	}
 
 	// Clear out the verification token fields, set the new password in the db,
-	// and unlock account
+	// and reset the failed login attempts
 	user.ResetPasswordVerificationToken = []byte{}
 	user.ResetPasswordVerificationExpiry = 0
 	user.HashedPassword = hashedPassword
 	user.FailedLoginAttempts = 0
 
-	return c.db.UpdateUser(user)
+	err = c.db.UpdateUser(user)
+	if err != nil {
+		return err
+	}
+
+	// Log the user out everywhere, in case the password was compromised.
+	return c.revokeUserSessions(user, nil)
 }
@@ -2,16 +2,20 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/hex"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/decred/dcrtime/merkle"
 	"github.com/decred/politeia/politeiad/api/v1/identity"
+	"github.com/decred/politeia/politeiad/api/v1/mime"
 	"github.com/decred/politeia/util"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
@@ -93,8 +97,11 @@ func checkSignature(id []byte, signature string, elements ...string) error {
 	return nil
 }
 
+// validateInvoice validates the invoice file and its attachments, and the
+// signature of the merkle root of their digests.
 func validateInvoice(
 	signature, publicKey, payload string,
+	attachments []v1.File,
 	month, year int,
 	user *database.User,
 ) error {
@@ -130,10 +137,24 @@ func validateInvoice(
 	if err != nil {
 		return err
 	}
-	digest := util.Digest(data)
+	err = validateInvoiceAttachments(attachments)
+	if err != nil {
+		return err
+	}
 
-	// Validate the string representation of the digest against the signature.
-	if !pk.VerifyMessage([]byte(hex.EncodeToString(digest)), sig) {
+	var digest [sha256.Size]byte
+	copy(digest[:], util.Digest(data))
+	hashes := []*[sha256.Size]byte{&digest}
+	for _, attachment := range attachments {
+		d, _ := util.ConvertDigest(attachment.Digest)
+		hashes = append(hashes, &d)
+	}
+
+	// Validate the string representation of the merkle root against the
+	// signature. Without attachments, the merkle root is the digest of the
+	// invoice file.
+	mr := merkle.Root(hashes)
+	if !pk.VerifyMessage([]byte(hex.EncodeToString(mr[:])), sig) {
 		return v1.UserError{
 			ErrorCode: v1.ErrorStatusInvalidSignature,
 		}
@@ -166,13 +187,188 @@ func validateInvoice(
 	return nil
 }
 
-// Invoices should only be viewable by admins and the users who submit them.
+// validateInvoiceAttachments validates the names, sizes, MIME types and
+// digests of the attachments of an invoice.
+func validateInvoiceAttachments(attachments []v1.File) error {
+	if len(attachments) > v1.PolicyMaxAttachments {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusMaxAttachmentsExceededPolicy,
+		}
+	}
+
+	names := make(map[string]bool, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.Name == "" || attachment.Name == invoiceFilename ||
+			filepath.Base(attachment.Name) != attachment.Name ||
+			strings.HasPrefix(attachment.Name, ".") ||
+			names[attachment.Name] {
+			return v1.UserError{
+				ErrorCode: v1.ErrorStatusInvalidInput,
+				ErrorContext: []string{fmt.Sprintf("invalid attachment "+
+					"name: %v", attachment.Name)},
+			}
+		}
+		names[attachment.Name] = true
+
+		data, err := base64.StdEncoding.DecodeString(attachment.Payload)
+		if err != nil {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidBase64,
+				ErrorContext: []string{attachment.Name},
+			}
+		}
+
+		if len(data) > v1.PolicyMaxAttachmentSize {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusMaxAttachmentSizeExceededPolicy,
+				ErrorContext: []string{attachment.Name},
+			}
+		}
+
+		mimeType := mime.DetectMimeType(data)
+		if !isValidAttachmentMIMEType(mimeType) {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusUnsupportedMIMEType,
+				ErrorContext: []string{attachment.Name, mimeType},
+			}
+		}
+		if attachment.MIME != mimeType {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidMIMEType,
+				ErrorContext: []string{attachment.Name, mimeType},
+			}
+		}
+
+		if attachment.Digest != hex.EncodeToString(util.Digest(data)) {
+			return v1.UserError{
+				ErrorCode:    v1.ErrorStatusInvalidFileDigest,
+				ErrorContext: []string{attachment.Name},
+			}
+		}
+	}
+
+	return nil
+}
+
+func isValidAttachmentMIMEType(mimeType string) bool {
+	for _, validMIMEType := range v1.PolicyValidMIMETypes {
+		if mimeType == validMIMEType {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpenseLineItem returns whether the line item, given as the values of
+// the invoice fields, is an expense rather than labor.
+func isExpenseLineItem(record []string) bool {
+	return strings.EqualFold(strings.TrimSpace(record[0]),
+		v1.PolicyInvoiceExpenseType)
+}
+
+// validateInvoiceLineItems validates every line item of the invoice file.
+func (c *cmswww) validateInvoiceLineItems(user *database.User, payload string) error {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidBase64,
+		}
+	}
+
+	csvReader := csv.NewReader(strings.NewReader(string(data)))
+	csvReader.Comma = v1.PolicyInvoiceFieldDelimiterChar
+	csvReader.Comment = v1.PolicyInvoiceCommentChar
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusMalformedInvoiceFile,
+		}
+	}
+
+	for i, record := range records {
+		err = validateLineItem(user, i+1, record)
+		if err != nil {
+			return err
+		}
+
+		err = c.validateLineItemProposal(i+1, record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateLineItem validates a line item, given as the values of the invoice
+// fields. Labor line items must have hours and must not exceed the user's
+// hourly rate cap; expense line items have no hours and a valid expense
+// category as their subtype. Line items are numbered from 1.
+func validateLineItem(user *database.User, lineItem int, record []string) error {
+	malformed := func(reason string) error {
+		return v1.UserError{
+			ErrorCode: v1.ErrorStatusMalformedInvoiceFile,
+			ErrorContext: []string{fmt.Sprintf("line item %v: %v",
+				lineItem, reason)},
+		}
+	}
+
+	if len(record) != len(v1.InvoiceFields) {
+		return malformed(fmt.Sprintf("expected %v fields",
+			len(v1.InvoiceFields)))
+	}
+
+	// The hours and total cost are the 5th and 6th fields.
+	hours, err := strconv.ParseUint(strings.TrimSpace(record[4]), 10, 64)
+	if err != nil {
+		return malformed("invalid hours")
+	}
+	totalCost, err := strconv.ParseUint(strings.TrimSpace(record[5]), 10, 64)
+	if err != nil || totalCost == 0 {
+		return malformed("invalid total cost")
+	}
+
+	if !isExpenseLineItem(record) {
+		if hours == 0 {
+			return malformed("labor must have hours")
+		}
+		if user.ContractHourlyRateCap != 0 &&
+			totalCost > hours*user.ContractHourlyRateCap {
+			return v1.UserError{
+				ErrorCode: v1.ErrorStatusHourlyRateCapExceeded,
+				ErrorContext: []string{
+					fmt.Sprintf("line item %v", lineItem),
+					fmt.Sprintf("cap %v USD/hour",
+						user.ContractHourlyRateCap),
+				},
+			}
+		}
+		return nil
+	}
+
+	if hours != 0 {
+		return malformed("expenses cannot have hours")
+	}
+	category := strings.TrimSpace(record[1])
+	for _, validCategory := range v1.PolicyExpenseCategories {
+		if strings.EqualFold(category, validCategory) {
+			return nil
+		}
+	}
+	return malformed(fmt.Sprintf("invalid expense category: %v", category))
+}
+
+// Invoices should only be viewable by admins, users with a role that grants
+// access to all invoices, and the users who submit them.
 func validateUserCanSeeInvoice(invoice *v1.InvoiceRecord, user *database.User) error {
 	authorID, err := strconv.ParseUint(invoice.UserID, 10, 64)
 	if err != nil {
 		return err
 	}
-	if user == nil || (!user.Admin && user.ID != authorID) {
+	if user == nil || (!hasPermission(user, permissionViewInvoices) &&
+		user.ID != authorID) {
 		return v1.UserError{
 			ErrorCode: v1.ErrorStatusInvoiceNotFound,
 		}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+)
+
+// roleContractor is the name used in workflow transitions for changes made
+// by the contractor who submitted the invoice.
+const roleContractor = "contractor"
+
+// defaultInvoiceTransitions is the invoice workflow used when none is
+// configured, in the format of the invoicetransition option.
+var defaultInvoiceTransitions = []string{
+	"unreviewed:approved:reviewer",
+	"unreviewed:rejected:reviewer:reason",
+	"unreviewed:withdrawn:contractor",
+	"rejected:approved:reviewer",
+	"rejected:unreviewedchanges:reviewer",
+	"rejected:withdrawn:contractor",
+	"unreviewedchanges:approved:reviewer",
+	"unreviewedchanges:rejected:reviewer:reason",
+	"unreviewedchanges:withdrawn:contractor",
+	"approved:paid:treasurer",
+	"approved:onhold:treasurer:reason",
+	"onhold:approved:treasurer",
+	"onhold:rejected:reviewer:reason",
+	"paid:disputed:treasurer:reason",
+	"disputed:paid:treasurer",
+}
+
+// editableInvoiceStatuses are the statuses of invoices which contractors can
+// edit. Invoices which have been approved, or which are on hold or disputed,
+// can't be edited, since editing returns them to review.
+var editableInvoiceStatuses = []v1.InvoiceStatusT{
+	v1.InvoiceStatusNotReviewed,
+	v1.InvoiceStatusUnreviewedChanges,
+	v1.InvoiceStatusRejected,
+}
+
+// invoiceWorkflow is the set of invoice statuses and the status changes
+// which can be made between them.
+type invoiceWorkflow struct {
+	v1.InvoiceWorkflow
+}
+
+// workflowStatusName returns the name of an invoice status as it's used in
+// the invoicetransition option.
+func workflowStatusName(status v1.InvoiceStatusT) string {
+	return strings.Replace(v1.InvoiceStatus[status], " ", "", -1)
+}
+
+// parseWorkflowStatus returns the invoice status with the given name. The
+// statuses which are only set by the server can't be used.
+func parseWorkflowStatus(name string) (v1.InvoiceStatusT, error) {
+	for status := range v1.InvoiceStatus {
+		switch status {
+		case v1.InvoiceStatusInvalid, v1.InvoiceStatusNotFound,
+			v1.InvoiceStatusAwaitingApproval:
+			continue
+		}
+		if workflowStatusName(status) == strings.ToLower(name) {
+			return status, nil
+		}
+	}
+
+	return v1.InvoiceStatusInvalid, fmt.Errorf("invalid invoice status %q",
+		name)
+}
+
+// parseWorkflowRole returns the user role with the given name, or 0 for
+// the contractor who submitted the invoice.
+func parseWorkflowRole(name string) (v1.UserRoleT, error) {
+	switch strings.ToLower(name) {
+	case roleContractor:
+		return 0, nil
+	case v1.UserRole[v1.UserRoleReviewer]:
+		return v1.UserRoleReviewer, nil
+	case v1.UserRole[v1.UserRoleTreasurer]:
+		return v1.UserRoleTreasurer, nil
+	}
+
+	return 0, fmt.Errorf("invalid role %q, must be one of %v, %v or %v",
+		name, v1.UserRole[v1.UserRoleReviewer],
+		v1.UserRole[v1.UserRoleTreasurer], roleContractor)
+}
+
+// parseInvoiceWorkflow parses invoice status transitions of the form
+// <from status>:<to status>:<role>[:reason] and checks that they form a
+// workflow the server can run. The default workflow is used if there are
+// no transitions.
+func parseInvoiceWorkflow(transitions []string) (*invoiceWorkflow, error) {
+	if len(transitions) == 0 {
+		transitions = defaultInvoiceTransitions
+	}
+
+	var w invoiceWorkflow
+	for _, transition := range transitions {
+		parts := strings.Split(transition, ":")
+		if len(parts) != 3 && len(parts) != 4 {
+			return nil, fmt.Errorf("invalid invoice transition %q, format "+
+				"must be <from status>:<to status>:<role>[:reason]",
+				transition)
+		}
+
+		var (
+			t   v1.InvoiceStatusTransition
+			err error
+		)
+		t.From, err = parseWorkflowStatus(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invoice transition %q: %v",
+				transition, err)
+		}
+		t.To, err = parseWorkflowStatus(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invoice transition %q: %v",
+				transition, err)
+		}
+		t.Role, err = parseWorkflowRole(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invoice transition %q: %v",
+				transition, err)
+		}
+		if len(parts) == 4 {
+			if parts[3] != "reason" {
+				return nil, fmt.Errorf("invoice transition %q: the "+
+					"last field must be reason", transition)
+			}
+			t.ReasonRequired = true
+		}
+
+		if t.From == t.To {
+			return nil, fmt.Errorf("invoice transition %q: the statuses "+
+				"must be different", transition)
+		}
+		if _, ok := w.transition(t.From, t.To); ok {
+			return nil, fmt.Errorf("duplicate invoice transition %q",
+				transition)
+		}
+
+		// Withdrawing an invoice is the only change contractors make
+		// through the workflow, and only they can make it.
+		if (t.Role == 0) != (t.To == v1.InvoiceStatusWithdrawn) {
+			return nil, fmt.Errorf("invoice transition %q: invoices are "+
+				"withdrawn by the %v, who can't make other status changes",
+				transition, roleContractor)
+		}
+
+		w.Transitions = append(w.Transitions, t)
+	}
+
+	// New and edited invoices are set to these statuses, and invoices are
+	// marked as paid once their payment is received, so they are always
+	// part of the workflow.
+	w.Statuses = []v1.InvoiceStatusT{
+		v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusUnreviewedChanges,
+		v1.InvoiceStatusPaid,
+	}
+	for _, t := range w.Transitions {
+		for _, status := range []v1.InvoiceStatusT{t.From, t.To} {
+			if !statusInSlice(w.Statuses, status) {
+				w.Statuses = append(w.Statuses, status)
+			}
+		}
+	}
+
+	// Invoices are paid once they're ready for payment, if the workflow
+	// has that step, and otherwise once they're approved.
+	w.PayableStatus = v1.InvoiceStatusApproved
+	if w.hasStatus(v1.InvoiceStatusReadyForPayment) {
+		w.PayableStatus = v1.InvoiceStatusReadyForPayment
+	}
+	if _, ok := w.transition(w.PayableStatus, v1.InvoiceStatusPaid); !ok {
+		return nil, fmt.Errorf("invoice workflow must have a transition "+
+			"from %v to %v", workflowStatusName(w.PayableStatus),
+			workflowStatusName(v1.InvoiceStatusPaid))
+	}
+
+	// Every status must be reachable by reviewing new or edited invoices.
+	reached := []v1.InvoiceStatusT{
+		v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusUnreviewedChanges,
+	}
+	for i := 0; i < len(reached); i++ {
+		for _, t := range w.Transitions {
+			if t.From == reached[i] && !statusInSlice(reached, t.To) {
+				reached = append(reached, t.To)
+			}
+		}
+	}
+	for _, status := range w.Statuses {
+		if !statusInSlice(reached, status) {
+			return nil, fmt.Errorf("invoice status %v can't be reached "+
+				"from %v or %v", workflowStatusName(status),
+				workflowStatusName(v1.InvoiceStatusNotReviewed),
+				workflowStatusName(v1.InvoiceStatusUnreviewedChanges))
+		}
+	}
+
+	// Invoices which are released from hold or whose dispute is resolved
+	// aren't subject to the approval rules again, so those statuses must
+	// only be reachable once the invoice has been approved.
+	approved := []v1.InvoiceStatusT{
+		v1.InvoiceStatusApproved,
+		v1.InvoiceStatusPaid,
+		w.PayableStatus,
+	}
+	unapproved := []v1.InvoiceStatusT{
+		v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusUnreviewedChanges,
+	}
+	for i := 0; i < len(unapproved); i++ {
+		for _, t := range w.Transitions {
+			if t.From != unapproved[i] || requiresApproval(t.From, t.To) {
+				continue
+			}
+			if statusInSlice(approved, t.To) {
+				return nil, fmt.Errorf("invoice transition %v:%v lets "+
+					"invoices become %v without being approved",
+					workflowStatusName(t.From), workflowStatusName(t.To),
+					workflowStatusName(t.To))
+			}
+			if !statusInSlice(unapproved, t.To) {
+				unapproved = append(unapproved, t.To)
+			}
+		}
+	}
+
+	return &w, nil
+}
+
+// hasStatus returns whether the status is part of the workflow.
+func (w *invoiceWorkflow) hasStatus(status v1.InvoiceStatusT) bool {
+	return statusInSlice(w.Statuses, status)
+}
+
+// isEditable returns whether invoices with the status can be edited.
+func (w *invoiceWorkflow) isEditable(status v1.InvoiceStatusT) bool {
+	return w.hasStatus(status) && statusInSlice(editableInvoiceStatuses, status)
+}
+
+// transition returns the transition between the given statuses, if the
+// workflow has one.
+func (w *invoiceWorkflow) transition(from, to v1.InvoiceStatusT) (*v1.InvoiceStatusTransition, bool) {
+	for i, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return &w.Transitions[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// validateStatusTransition returns the transition an admin makes by moving
+// an invoice from the old status to the new one, and returns an error if the
+// workflow doesn't allow the change or the reason is missing.
+func (w *invoiceWorkflow) validateStatusTransition(
+	oldStatus v1.InvoiceStatusT,
+	newStatus v1.InvoiceStatusT,
+	reason *string,
+) (*v1.InvoiceStatusTransition, error) {
+	t, ok := w.transition(oldStatus, newStatus)
+	if !ok || t.Role == 0 {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusInvalidInvoiceStatusTransition,
+		}
+	}
+
+	if t.ReasonRequired && reason == nil {
+		return nil, v1.UserError{
+			ErrorCode: v1.ErrorStatusReasonNotProvided,
+		}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/decred/contractor-mgmt/cmswww/api/v1"
+)
+
+// editTransitions returns the default invoice transitions without the
+// removed ones and with the added ones.
+func editTransitions(removed []string, added ...string) []string {
+	transitions := make([]string, 0, len(defaultInvoiceTransitions))
+	for _, transition := range defaultInvoiceTransitions {
+		keep := true
+		for _, r := range removed {
+			if transition == r {
+				keep = false
+			}
+		}
+		if keep {
+			transitions = append(transitions, transition)
+		}
+	}
+	return append(transitions, added...)
+}
+
+func TestParseInvoiceWorkflow(t *testing.T) {
+	readyForPayment := editTransitions([]string{"approved:paid:treasurer"},
+		"approved:readyforpayment:treasurer",
+		"readyforpayment:paid:treasurer")
+
+	tests := []struct {
+		name          string
+		transitions   []string
+		payableStatus v1.InvoiceStatusT
+		err           string // Substring of the error, if any
+	}{
+		{
+			name:          "default workflow",
+			payableStatus: v1.InvoiceStatusApproved,
+		},
+		{
+			name:          "ready for payment",
+			transitions:   readyForPayment,
+			payableStatus: v1.InvoiceStatusReadyForPayment,
+		},
+		{
+			name:        "too few fields",
+			transitions: editTransitions(nil, "unreviewed:approved"),
+			err:         "format must be",
+		},
+		{
+			name:        "too many fields",
+			transitions: editTransitions(nil, "unreviewed:approved:reviewer:reason:x"),
+			err:         "format must be",
+		},
+		{
+			name:        "invalid from status",
+			transitions: editTransitions(nil, "new:approved:reviewer"),
+			err:         `invalid invoice status "new"`,
+		},
+		{
+			name:        "invalid to status",
+			transitions: editTransitions(nil, "unreviewed:done:reviewer"),
+			err:         `invalid invoice status "done"`,
+		},
+		{
+			name:        "server status",
+			transitions: editTransitions(nil, "unreviewed:awaitingadditionalapproval:reviewer"),
+			err:         "invalid invoice status",
+		},
+		{
+			name:        "invalid role",
+			transitions: editTransitions(nil, "unreviewed:approved:admin"),
+			err:         `invalid role "admin"`,
+		},
+		{
+			name:        "invalid last field",
+			transitions: editTransitions(nil, "unreviewed:rejected:reviewer:why"),
+			err:         "the last field must be reason",
+		},
+		{
+			name:        "same statuses",
+			transitions: editTransitions(nil, "approved:approved:reviewer"),
+			err:         "the statuses must be different",
+		},
+		{
+			name:        "duplicate transition",
+			transitions: editTransitions(nil, "unreviewed:approved:treasurer"),
+			err:         "duplicate invoice transition",
+		},
+		{
+			name:        "contractor changes status",
+			transitions: editTransitions(nil, "unreviewedchanges:unreviewed:contractor"),
+			err:         "invoices are withdrawn by the contractor",
+		},
+		{
+			name:        "reviewer withdraws",
+			transitions: editTransitions(nil, "approved:withdrawn:reviewer"),
+			err:         "invoices are withdrawn by the contractor",
+		},
+		{
+			name: "no transition to paid",
+			transitions: editTransitions([]string{
+				"approved:paid:treasurer",
+				"paid:disputed:treasurer:reason",
+				"disputed:paid:treasurer",
+			}),
+			err: "must have a transition from approved to paid",
+		},
+		{
+			name: "no transition from ready for payment to paid",
+			transitions: editTransitions(nil,
+				"approved:readyforpayment:treasurer"),
+			err: "must have a transition from readyforpayment to paid",
+		},
+		{
+			name: "unreachable status",
+			transitions: editTransitions([]string{
+				"unreviewed:withdrawn:contractor",
+				"rejected:withdrawn:contractor",
+				"unreviewedchanges:withdrawn:contractor",
+			}, "withdrawn:rejected:reviewer"),
+			err: "invoice status withdrawn can't be reached",
+		},
+		{
+			name:        "hold skips approval",
+			transitions: editTransitions(nil, "unreviewed:onhold:treasurer:reason"),
+			err:         "onhold:approved lets invoices become approved without being approved",
+		},
+		{
+			name:        "dispute skips approval",
+			transitions: editTransitions(nil, "rejected:disputed:treasurer:reason"),
+			err:         "disputed:paid lets invoices become paid without being approved",
+		},
+		{
+			name:        "ready for payment skips approval",
+			transitions: append(readyForPayment, "unreviewed:readyforpayment:reviewer"),
+			err:         "unreviewed:readyforpayment lets invoices become readyforpayment",
+		},
+		{
+			name:        "hold after rejection",
+			transitions: editTransitions(nil, "rejected:onhold:treasurer:reason"),
+			err:         "onhold:approved lets invoices become approved",
+		},
+	}
+
+	for _, test := range tests {
+		w, err := parseInvoiceWorkflow(test.transitions)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%v: got error %v, want %q", test.name, err,
+					test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if w.PayableStatus != test.payableStatus {
+			t.Errorf("%v: got payable status %v, want %v", test.name,
+				w.PayableStatus, test.payableStatus)
+		}
+	}
+}
+
+func TestParseInvoiceWorkflowDefault(t *testing.T) {
+	w, err := parseInvoiceWorkflow(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.Transitions) != len(defaultInvoiceTransitions) {
+		t.Errorf("got %v transitions, want %v", len(w.Transitions),
+			len(defaultInvoiceTransitions))
+	}
+	for _, status := range []v1.InvoiceStatusT{
+		v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusUnreviewedChanges,
+		v1.InvoiceStatusRejected,
+		v1.InvoiceStatusApproved,
+		v1.InvoiceStatusPaid,
+		v1.InvoiceStatusWithdrawn,
+		v1.InvoiceStatusOnHold,
+		v1.InvoiceStatusDisputed,
+	} {
+		if !w.hasStatus(status) {
+			t.Errorf("missing status %v", v1.InvoiceStatus[status])
+		}
+	}
+	if w.hasStatus(v1.InvoiceStatusReadyForPayment) {
+		t.Errorf("unexpected status %v",
+			v1.InvoiceStatus[v1.InvoiceStatusReadyForPayment])
+	}
+
+	tr, ok := w.transition(v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusRejected)
+	if !ok || tr.Role != v1.UserRoleReviewer || !tr.ReasonRequired {
+		t.Errorf("unexpected unreviewed to rejected transition %+v", tr)
+	}
+	tr, ok = w.transition(v1.InvoiceStatusNotReviewed,
+		v1.InvoiceStatusWithdrawn)
+	if !ok || tr.Role != 0 || tr.ReasonRequired {
+		t.Errorf("unexpected unreviewed to withdrawn transition %+v", tr)
+	}
+	if _, ok := w.transition(v1.InvoiceStatusPaid,
+		v1.InvoiceStatusApproved); ok {
+		t.Errorf("unexpected paid to approved transition")
+	}
+
+	// Only invoices which haven't been approved can be edited.
+	for status, editable := range map[v1.InvoiceStatusT]bool{
+		v1.InvoiceStatusNotReviewed:       true,
+		v1.InvoiceStatusUnreviewedChanges: true,
+		v1.InvoiceStatusRejected:          true,
+		v1.InvoiceStatusApproved:          false,
+		v1.InvoiceStatusOnHold:            false,
+		v1.InvoiceStatusPaid:              false,
+		v1.InvoiceStatusDisputed:          false,
+		v1.InvoiceStatusWithdrawn:         false,
+		v1.InvoiceStatusAwaitingApproval:  false,
+	} {
+		if w.isEditable(status) != editable {
+			t.Errorf("%v: got editable %v, want %v",
+				v1.InvoiceStatus[status], !editable, editable)
+		}
+	}
+}
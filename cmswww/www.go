@@ -23,7 +23,6 @@ import (
 	"github.com/decred/politeia/util"
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
-	"github.com/gorilla/sessions"
 
 	"github.com/decred/contractor-mgmt/cmswww/api/v1"
 	"github.com/decred/contractor-mgmt/cmswww/database"
@@ -37,6 +36,13 @@ const (
 	permissionPublic permission = iota
 	permissionLogin
 	permissionAdmin
+	permissionReview       // Approve and reject invoices
+	permissionPay          // Pay invoices and update payments
+	permissionSetStatus    // Change invoice statuses; see HandleSetInvoiceStatus
+	permissionManageUsers  // Invite and manage users
+	permissionViewInvoices // View all invoices
+	permissionViewUsers    // View all users
+	permissionLeadReview   // Review the invoices of the user's contract domain
 
 	csrfKeyLength = 32
 
@@ -44,15 +50,46 @@ const (
 	indexFile = "index.md"
 
 	// mdStream* indicate the metadata stream used for various types
-	mdStreamGeneral  = 0 // General information for this invoice
-	mdStreamChanges  = 1 // Changes to the invoice status
-	mdStreamPayments = 2 // Payments made for this invoice
-
-	VersionBackendInvoiceMetadata  = 1
-	VersionBackendInvoiceMDChange  = 1
-	VersionBackendInvoiceMDPayment = 1
+	mdStreamGeneral     = 0 // General information for this invoice
+	mdStreamChanges     = 1 // Changes to the invoice status
+	mdStreamPayments    = 2 // Payments made for this invoice
+	mdStreamLeadReviews = 3 // Reviews by domain leads
+	mdStreamComments    = 4 // Comments on the invoice
+
+	VersionBackendInvoiceMetadata     = 1
+	VersionBackendInvoiceMDChange     = 1
+	VersionBackendInvoiceMDPayment    = 1
+	VersionBackendInvoiceMDLeadReview = 1
+	VersionBackendInvoiceMDComment    = 1
 )
 
+// permissionRoles maps the role-based permissions to the roles which are
+// granted them; admins are granted every permission.
+var permissionRoles = map[permission]v1.UserRoleT{
+	permissionReview:      v1.UserRoleReviewer,
+	permissionPay:         v1.UserRoleTreasurer,
+	permissionSetStatus:   v1.UserRoleReviewer | v1.UserRoleTreasurer,
+	permissionManageUsers: v1.UserRoleUserManager,
+	permissionViewInvoices: v1.UserRoleReviewer | v1.UserRoleTreasurer |
+		v1.UserRoleAuditor,
+	permissionViewUsers:  v1.AllUserRoles,
+	permissionLeadReview: v1.UserRoleDomainLead,
+}
+
+// hasPermission returns whether the user has been granted the permission.
+func hasPermission(user *database.User, perm permission) bool {
+	switch perm {
+	case permissionPublic:
+		return true
+	case permissionLogin:
+		return user != nil
+	case permissionAdmin:
+		return user != nil && user.Admin
+	}
+
+	return user != nil && user.HasRole(permissionRoles[perm])
+}
+
 // cmswww application context.
 type cmswww struct {
 	sync.RWMutex // lock for inventory and caches
@@ -60,7 +97,10 @@ type cmswww struct {
 	cfg    *config
 	router *mux.Router
 
-	store *sessions.FilesystemStore
+	store *sessionStore
+
+	ipRateLimiter      *rateLimiter // Keyed on client address
+	accountRateLimiter *rateLimiter // Keyed on email address
 
 	db             database.Database
 	rateCalculator *ratecalc.Calculator
@@ -88,6 +128,9 @@ func RespondWithError(
 	if userErr, ok := args[0].(v1.UserError); ok {
 		if userHTTPCode == 0 {
 			userHTTPCode = http.StatusBadRequest
+			if userErr.ErrorCode == v1.ErrorStatusTooManyRequests {
+				userHTTPCode = http.StatusTooManyRequests
+			}
 		}
 
 		if len(userErr.ErrorContext) == 0 {
@@ -144,6 +187,28 @@ func RespondWithError(
 		})
 }
 
+// convertErrorToErrorReply returns the error code and context that
+// RespondWithError would reply with for the error, for replies which report
+// the errors of several operations at once. Internal errors are logged and
+// reported with a timestamp error code, like RespondWithError does.
+func convertErrorToErrorReply(r *http.Request, err error) (int64, []string) {
+	if userErr, ok := err.(v1.UserError); ok {
+		return int64(userErr.ErrorCode), userErr.ErrorContext
+	}
+
+	if pdError, ok := err.(v1.PDError); ok {
+		pdErrorCode := convertErrorStatusFromPD(pdError.ErrorReply.ErrorCode)
+		if pdErrorCode != v1.ErrorStatusInvalid {
+			return int64(pdErrorCode), pdError.ErrorReply.ErrorContext
+		}
+	}
+
+	errorCode := time.Now().Unix()
+	log.Errorf("%v %v %v %v Internal error %v: %v", remoteAddr(r),
+		r.Method, r.URL, r.Proto, errorCode, err)
+	return errorCode, nil
+}
+
 // version is an HTTP GET to determine what version and API route this backend
 // is using.  Additionally it is used to obtain a CSRF token.
 func (c *cmswww) HandleVersion(w http.ResponseWriter, r *http.Request) {
@@ -153,7 +218,9 @@ func (c *cmswww) HandleVersion(w http.ResponseWriter, r *http.Request) {
 		Version:   v1.APIVersion,
 		Route:     v1.APIRoute,
 		PublicKey: hex.EncodeToString(c.cfg.Identity.Key[:]),
-		TestNet:   c.cfg.TestNet,
+		SigningPublicKey: hex.EncodeToString(
+			c.cfg.SigningIdentity.Public.Key[:]),
+		TestNet: c.cfg.TestNet,
 	}
 
 	// Check if there's an invalid session that the client thinks is active.
@@ -210,13 +277,19 @@ func (c *cmswww) HandlePolicy(
 		MaxUsernameLength:      v1.PolicyMaxUsernameLength,
 		UsernameSupportedChars: v1.PolicyUsernameSupportedChars,
 		ListPageSize:           v1.ListPageSize,
-		ValidMIMETypes: []string{
-			"text/plain; charset=utf-8",
-		},
+		MaxCommentLength:       v1.PolicyMaxCommentLength,
+		MaxAttachments:         v1.PolicyMaxAttachments,
+		MaxAttachmentSize:      v1.PolicyMaxAttachmentSize,
+		MaxBatchStatusChanges:  v1.PolicyMaxBatchStatusChanges,
+		ValidMIMETypes:         v1.PolicyValidMIMETypes,
 		Invoice: v1.InvoicePolicy{
 			FieldDelimiterChar: v1.PolicyInvoiceFieldDelimiterChar,
 			CommentChar:        v1.PolicyInvoiceCommentChar,
 			Fields:             v1.InvoiceFields,
+			ExpenseType:        v1.PolicyInvoiceExpenseType,
+			ExpenseCategories:  v1.PolicyExpenseCategories,
+			ApprovalRules:      c.cfg.InvoiceApprovalRules,
+			Workflow:           c.cfg.InvoiceWorkflow.InvoiceWorkflow,
 		},
 	}, nil
 }
@@ -285,12 +358,21 @@ func _main() error {
 		params:         activeNetParams.Params,
 		polledPayments: make(map[string]polledPayment),
 	}
+	c.ipRateLimiter = newRateLimiter(c.cfg.RateLimitIP,
+		c.cfg.RateLimitIPBurst)
+	c.accountRateLimiter = newRateLimiter(c.cfg.RateLimitAccount,
+		c.cfg.RateLimitAccountBurst)
 
 	// Check if this command is being run to fetch the identity.
 	if c.cfg.FetchIdentity {
 		return c.RemoteIdentity()
 	}
 
+	// Check if this command is being run to create the signing identity.
+	if c.cfg.CreateSigningIdentity {
+		return c.CreateSigningIdentity()
+	}
+
 	// Setup database.
 	cockroachdb.UseLogger(cockroachdbLog)
 	c.db, err = cockroachdb.New(c.cfg.DataDir, c.cfg.CockroachDBName,
@@ -357,6 +439,11 @@ func _main() error {
 	if err != nil {
 		return err
 	}
+
+	// Set up the logic that reminds contractors to submit invoices.
+	c.initInvoiceReminders()
+	c.initUserDeactivation()
+
 	// Make sure the cookie path is explicitly set to the root path, to fix
 	// an issue where multiple CSRF tokens were being stored in the cookie.
 	csrfHandle := csrf.Protect(csrfKey, csrf.Path("/"))
@@ -392,7 +479,7 @@ func _main() error {
 				TLSNextProto: make(map[string]func(*http.Server,
 					*tls.Conn, http.Handler)),
 			}
-			srv.Handler = csrfHandle(c.router)
+			srv.Handler = skipCSRFForAPITokens(csrfHandle(c.router))
 			log.Infof("Listen: %v", listen)
 			listenC <- srv.ListenAndServeTLS(loadedCfg.HTTPSCert,
 				loadedCfg.HTTPSKey)
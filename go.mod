@@ -1,5 +1,7 @@
 module github.com/decred/contractor-mgmt
 
+go 1.27.1
+
 require (
 	github.com/badoux/checkmail v0.0.0-20181210160741-9661bd69e9ad
 	github.com/btcsuite/go-flags v0.0.0-20150116065318-6c288d648c1c
@@ -8,27 +10,118 @@ require (
 	github.com/decred/dcrd/chaincfg v1.2.1
 	github.com/decred/dcrd/dcrutil v1.2.0
 	github.com/decred/dcrd/wire v1.2.0
+	github.com/decred/dcrtime v0.0.0-20180808181920-4c91c4cbed09
 	github.com/decred/dcrwallet v1.2.2
 	github.com/decred/politeia v0.0.0-20190114033329-793ab1b7b1e9
 	github.com/decred/slog v1.0.0
-	github.com/denisenkom/go-mssqldb v0.0.0-20190111225525-2fea367d496d // indirect
-	github.com/erikstmartin/go-testdb v0.0.0-20160219214506-8d10e4a1bae5 // indirect
-	github.com/go-sql-driver/mysql v1.4.1 // indirect
-	github.com/gofrs/uuid v3.2.0+incompatible // indirect
 	github.com/golang/crypto v0.0.0-20190211182817-74369b46fc67
 	github.com/golang/net v0.0.0-20190213061140-3a22650c66bd
-	github.com/google/go-cmp v0.2.0 // indirect
 	github.com/gorilla/csrf v1.5.1
 	github.com/gorilla/mux v1.6.2
 	github.com/gorilla/schema v1.0.2
+	github.com/gorilla/securecookie v1.1.1
 	github.com/gorilla/sessions v1.1.3
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/jinzhu/gorm v1.9.2
-	github.com/jinzhu/inflection v0.0.0-20180308033659-04140366298a // indirect
-	github.com/jinzhu/now v0.0.0-20181116074157-8ec929ed50c3 // indirect
 	github.com/jrick/logrotate v1.0.0
 	github.com/kennygrant/sanitize v1.2.4
 	github.com/lib/pq v1.0.0
-	github.com/mattn/go-sqlite3 v1.10.0 // indirect
 	golang.org/x/crypto v0.0.0-20190103213133-ff983b9c42bc
 )
+
+require (
+	cloud.google.com/go v0.26.0 // indirect
+	github.com/aead/siphash v0.0.0-20170329201724-e404fcfc8885 // indirect
+	github.com/agl/ed25519 v0.0.0-20170116200512-5312a6153412 // indirect
+	github.com/boltdb/bolt v1.3.1 // indirect
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd // indirect
+	github.com/btcsuite/goleveldb v1.0.0 // indirect
+	github.com/btcsuite/snappy-go v1.0.0 // indirect
+	github.com/client9/misspell v0.3.4 // indirect
+	github.com/dchest/blake256 v1.0.0 // indirect
+	github.com/dchest/siphash v1.2.0 // indirect
+	github.com/decred/base58 v1.0.0 // indirect
+	github.com/decred/dcrd/addrmgr v1.0.2 // indirect
+	github.com/decred/dcrd/blockchain v1.0.2 // indirect
+	github.com/decred/dcrd/blockchain/stake v1.0.2 // indirect
+	github.com/decred/dcrd/certgen v1.0.1 // indirect
+	github.com/decred/dcrd/chaincfg/chainhash v1.0.1 // indirect
+	github.com/decred/dcrd/connmgr v1.0.1 // indirect
+	github.com/decred/dcrd/database v1.0.2 // indirect
+	github.com/decred/dcrd/dcrec v0.0.0-20180816212643-20eda7ec9229 // indirect
+	github.com/decred/dcrd/dcrec/edwards v0.0.0-20181208004914-a0816cf4301f // indirect
+	github.com/decred/dcrd/dcrec/secp256k1 v1.0.1 // indirect
+	github.com/decred/dcrd/dcrjson v1.0.0 // indirect
+	github.com/decred/dcrd/gcs v1.0.2 // indirect
+	github.com/decred/dcrd/hdkeychain v1.1.0 // indirect
+	github.com/decred/dcrd/mempool v1.0.1 // indirect
+	github.com/decred/dcrd/mining v1.0.1 // indirect
+	github.com/decred/dcrd/rpcclient v1.0.1 // indirect
+	github.com/decred/dcrd/txscript v1.0.1 // indirect
+	github.com/decred/dcrdata v2.1.3+incompatible // indirect
+	github.com/decred/dcrwallet/chain v1.0.0 // indirect
+	github.com/decred/dcrwallet/deployments v1.0.0 // indirect
+	github.com/decred/dcrwallet/errors v1.0.0 // indirect
+	github.com/decred/dcrwallet/internal/helpers v1.0.0 // indirect
+	github.com/decred/dcrwallet/internal/zero v1.0.0 // indirect
+	github.com/decred/dcrwallet/lru v1.0.0 // indirect
+	github.com/decred/dcrwallet/p2p v1.0.0 // indirect
+	github.com/decred/dcrwallet/pgpwordlist v1.0.0 // indirect
+	github.com/decred/dcrwallet/rpc/walletrpc v0.1.0 // indirect
+	github.com/decred/dcrwallet/spv v1.0.0 // indirect
+	github.com/decred/dcrwallet/ticketbuyer v1.0.0 // indirect
+	github.com/decred/dcrwallet/ticketbuyer/v2 v2.0.0 // indirect
+	github.com/decred/dcrwallet/validate v1.0.1 // indirect
+	github.com/decred/dcrwallet/version v1.0.0 // indirect
+	github.com/decred/dcrwallet/wallet v1.0.0 // indirect
+	github.com/decred/dcrwallet/walletseed v1.0.0 // indirect
+	github.com/denisenkom/go-mssqldb v0.0.0-20190111225525-2fea367d496d // indirect
+	github.com/erikstmartin/go-testdb v0.0.0-20160219214506-8d10e4a1bae5 // indirect
+	github.com/fsnotify/fsnotify v1.4.7 // indirect
+	github.com/go-sql-driver/mysql v1.4.1 // indirect
+	github.com/gofrs/uuid v3.2.0+incompatible // indirect
+	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b // indirect
+	github.com/golang/lint v0.0.0-20180702182130-06c8688daad7 // indirect
+	github.com/golang/mock v1.1.1 // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db // indirect
+	github.com/google/go-cmp v0.2.0 // indirect
+	github.com/google/uuid v1.0.0 // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/gorilla/websocket v1.2.0 // indirect
+	github.com/h2non/go-is-svg v0.0.0-20160927212452-35e8c4b0612c // indirect
+	github.com/hpcloud/tail v1.0.0 // indirect
+	github.com/jinzhu/inflection v0.0.0-20180308033659-04140366298a // indirect
+	github.com/jinzhu/now v0.0.0-20181116074157-8ec929ed50c3 // indirect
+	github.com/jrick/bitset v1.0.0 // indirect
+	github.com/kisielk/gotool v1.0.0 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/kr/pty v1.1.2 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/mattn/go-sqlite3 v1.10.0 // indirect
+	github.com/onsi/ginkgo v1.7.0 // indirect
+	github.com/onsi/gomega v1.4.1 // indirect
+	github.com/otiai10/copy v0.0.0-20180813032824-7e9a647135a1 // indirect
+	github.com/otiai10/mint v1.2.1 // indirect
+	github.com/pkg/errors v0.8.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/robfig/cron v0.0.0-20180505203441-b41be1df6967 // indirect
+	github.com/stretchr/testify v1.2.2 // indirect
+	github.com/subosito/norma v0.0.0-20140814002436-523a8b2df221 // indirect
+	github.com/syndtr/goleveldb v0.0.0-20180815032940-ae2bd5eed72d // indirect
+	golang.org/x/lint v0.0.0-20180702182130-06c8688daad7 // indirect
+	golang.org/x/net v0.0.0-20181005035420-146acd28ed58 // indirect
+	golang.org/x/oauth2 v0.0.0-20180821212333-d2e6202438be // indirect
+	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f // indirect
+	golang.org/x/sys v0.0.0-20180830151530-49385e6e1522 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/tools v0.0.0-20180828015842-6cd1fcedba52 // indirect
+	google.golang.org/appengine v1.1.0 // indirect
+	google.golang.org/genproto v0.0.0-20180817151627-c66870c02cf8 // indirect
+	google.golang.org/grpc v1.15.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/fsnotify.v1 v1.4.7 // indirect
+	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
+	honnef.co/go/tools v0.0.0-20180728063816-88497007e858 // indirect
+)